@@ -0,0 +1,51 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// A module may state `assert e1 == e2` between its definitions, checked as
+// it is loaded: e1 and e2, with the definitions so far filled in, must be
+// beta-eta equivalent. A library of encodings can so test itself in place,
+// as in `assert not true == false`.
+
+// isAssertion tells the assertions of a module from its definitions
+func isAssertion(line string) bool {
+	return strings.HasPrefix(line, "assert ")
+}
+
+// checkAssertion checks the assertion line against the definitions of env,
+// normalizing each side in at most maxSteps reductions. A failure comes with
+// the column of the line it is at, counting from 1.
+func checkAssertion(line string, env Environment, maxSteps int) (int, error) {
+	left, right, ok := strings.Cut(strings.TrimPrefix(line, "assert "), "==")
+	if !ok {
+		return 1, fmt.Errorf("usage: assert e1 == e2")
+	}
+	sides := [2]Expression{}
+	columns := [2]int{}
+	start := len("assert ")
+	for i, text := range []string{left, right} {
+		trimmed := strings.TrimSpace(text)
+		columns[i] = utf8.RuneCountInString(line[:start+strings.Index(text, trimmed)]) + 1
+		start += len(text) + len("==")
+		exp, err := parse(trimmed)
+		if err != nil {
+			if e, ok := err.(syntaxError); ok {
+				return columns[i] + e.offset, err
+			}
+			return columns[i], err
+		}
+		if sides[i], err = normalize(resolve(exp, env), maxSteps); err == ErrStepLimit {
+			return columns[i], fmt.Errorf("%v has no normal form within %v steps", trimmed, maxSteps)
+		}
+	}
+	// normal forms have no beta redexes left, so only eta applies
+	a, b := Simplify(sides[0]), Simplify(sides[1])
+	if len(Diff(a, b, true)) != 0 {
+		return columns[0], fmt.Errorf("assertion failed: the left side is %v, but the right side is %v", format(a), format(b))
+	}
+	return 0, nil
+}
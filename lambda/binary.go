@@ -0,0 +1,309 @@
+package lambda
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The binary encoding writes terms compactly for the cache of normal forms
+// and state files, where they are only read back by the program. It starts
+// with a header, binaryMagic and the version of the encoding, followed by the
+// terms, each node a tag byte and its parts in prefix order. Names are
+// interned: a name's first occurrence is written out, and every later one is
+// its number among the names written before, so a term mentioning a name
+// thousands of times spells it once.
+
+// binaryMagic starts every binary encoding
+var binaryMagic = []byte("λbin")
+
+// binaryVersion is the version of the encoding, which a decoder only reads
+// if it is the same
+const binaryVersion = 1
+
+// maxBinaryString bounds the length of a name or source decoded, so a
+// corrupt length fails rather than allocating all memory
+const maxBinaryString = 1 << 24
+
+const (
+	binaryVariable byte = iota
+	binaryFree
+	binaryAbstraction
+	binaryApplication
+	binaryLet
+	binaryDefinition
+	binaryHole
+	binaryQuoted
+	binaryUnquoted
+)
+
+// ErrNotBinary is returned when decoding what wasn't written by an Encoder of
+// this version
+var ErrNotBinary = errors.New("not a binary encoding of terms of this version")
+
+// An Encoder writes terms in the binary encoding to w, the header before the
+// first, with the names interned across all of them
+type Encoder struct {
+	w     *bufio.Writer
+	names map[string]uint64
+	err   error
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Encode writes exp and flushes it to the underlying writer
+func (e *Encoder) Encode(exp Expression) error {
+	e.term(exp)
+	return e.flush()
+}
+
+func (e *Encoder) flush() error {
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.err
+}
+
+func (e *Encoder) header() {
+	if e.names != nil {
+		return
+	}
+	e.names = map[string]uint64{}
+	e.w.Write(binaryMagic)
+	e.uvarint(binaryVersion)
+}
+
+func (e *Encoder) uvarint(n uint64) {
+	e.header()
+	if e.err == nil {
+		_, e.err = e.w.Write(binary.AppendUvarint(nil, n))
+	}
+}
+
+// string writes s out in full, as its length and then its bytes
+func (e *Encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	if e.err == nil {
+		_, e.err = e.w.WriteString(s)
+	}
+}
+
+// name writes 0 and then name the first time, and its number plus one after
+func (e *Encoder) name(name string) {
+	e.header()
+	if n, ok := e.names[name]; ok {
+		e.uvarint(n + 1)
+		return
+	}
+	e.names[name] = uint64(len(e.names))
+	e.uvarint(0)
+	e.string(name)
+}
+
+func (e *Encoder) tag(tag byte) {
+	e.header()
+	if e.err == nil {
+		e.err = e.w.WriteByte(tag)
+	}
+}
+
+func (e *Encoder) term(exp Expression) {
+	switch exp := exp.(type) {
+	case binding:
+		e.tag(binaryLet)
+		e.name(exp.name.identifier)
+		e.term(exp.value)
+		e.term(exp.body)
+	case replBinding:
+		e.tag(binaryDefinition)
+		e.name(exp.name.identifier)
+		e.term(exp.value)
+	case abstraction:
+		e.tag(binaryAbstraction)
+		e.name(exp.param.identifier)
+		e.term(exp.expr)
+	case application:
+		e.tag(binaryApplication)
+		e.term(exp.left)
+		e.term(exp.right)
+	case strictApplication:
+		e.term(exp.application)
+	case freeVariable:
+		e.tag(binaryFree)
+		e.name(exp.identifier)
+	case variable:
+		e.tag(binaryVariable)
+		e.name(exp.identifier)
+	case hole:
+		e.tag(binaryHole)
+	case quoted:
+		e.tag(binaryQuoted)
+		e.term(exp.expr)
+	case unquoted:
+		e.tag(binaryUnquoted)
+		e.term(exp.expr)
+	default:
+		if e.err == nil {
+			e.err = fmt.Errorf("can't encode %v", exp)
+		}
+	}
+}
+
+// A Decoder reads terms an Encoder wrote from r, checking the header before
+// the first
+type Decoder struct {
+	r     *bufio.Reader
+	names []string
+	read  bool
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next term, failing with io.EOF when there are no more
+func (d *Decoder) Decode() (exp Expression, err error) {
+	if err := d.header(); err != nil {
+		return nil, err
+	}
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	err = d.catch(func() { exp = d.term() })
+	return exp, err
+}
+
+// catch runs read, returning the error it fails with
+func (d *Decoder) catch(read func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ev, ok := r.(evalError)
+			if !ok {
+				panic(r)
+			}
+			err = ev.err
+		}
+	}()
+	read()
+	return nil
+}
+
+func (d *Decoder) header() error {
+	if d.read {
+		return nil
+	}
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(d.r, magic); err != nil || !bytes.Equal(magic, binaryMagic) {
+		return ErrNotBinary
+	}
+	if version, err := binary.ReadUvarint(d.r); err != nil || version != binaryVersion {
+		return ErrNotBinary
+	}
+	d.read = true
+	return nil
+}
+
+// fail stops decoding, as evaluation is stopped, with err, io.EOF being an
+// unexpected end in the middle of a term
+func (d *Decoder) fail(err error) {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	panic(evalError{err})
+}
+
+func (d *Decoder) uvarint() uint64 {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.fail(err)
+	}
+	return n
+}
+
+func (d *Decoder) string() string {
+	n := d.uvarint()
+	if n > maxBinaryString {
+		d.fail(fmt.Errorf("string of %v bytes is too long", n))
+	}
+	s := make([]byte, n)
+	if _, err := io.ReadFull(d.r, s); err != nil {
+		d.fail(err)
+	}
+	return string(s)
+}
+
+func (d *Decoder) name() string {
+	n := d.uvarint()
+	if n == 0 {
+		name := d.string()
+		d.names = append(d.names, name)
+		return name
+	}
+	if n > uint64(len(d.names)) {
+		d.fail(fmt.Errorf("name %v of only %v", n, len(d.names)))
+	}
+	return d.names[n-1]
+}
+
+func (d *Decoder) term() Expression {
+	tag, err := d.r.ReadByte()
+	if err != nil {
+		d.fail(err)
+	}
+	switch tag {
+	case binaryLet:
+		name := d.name()
+		value := d.term()
+		return binding{variable{name}, value, d.term()}
+	case binaryDefinition:
+		name := d.name()
+		return replBinding{variable{name}, d.term()}
+	case binaryAbstraction:
+		param := d.name()
+		return abstraction{variable{param}, d.term()}
+	case binaryApplication:
+		left := d.term()
+		return application{left, d.term()}
+	case binaryFree:
+		return freeVariable{d.name()}
+	case binaryVariable:
+		return variable{d.name()}
+	case binaryHole:
+		return hole{}
+	case binaryQuoted:
+		return quoted{d.term()}
+	case binaryUnquoted:
+		return unquoted{d.term()}
+	default:
+		d.fail(fmt.Errorf("unknown tag %v", tag))
+		return nil
+	}
+}
+
+// EncodeBinary is exp in the binary encoding
+func EncodeBinary(exp Expression) ([]byte, error) {
+	var b bytes.Buffer
+	err := NewEncoder(&b).Encode(exp)
+	return b.Bytes(), err
+}
+
+// DecodeBinary reads the one term data encodes
+func DecodeBinary(data []byte) (Expression, error) {
+	d := NewDecoder(bytes.NewReader(data))
+	exp, err := d.Decode()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.r.Peek(1); err != io.EOF {
+		return nil, fmt.Errorf("more after the term")
+	}
+	return exp, nil
+}
@@ -0,0 +1,77 @@
+package lambda
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	programs := []string{"x", "𝞴x y.x", "f (𝞴x.x) y", "𝞴n.let x = n in x", "'id = 𝞴x.x", "𝞴f.f _", "quote (𝞴x.f ~x)"}
+	for _, program := range programs {
+		exp, err := parse(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := EncodeBinary(exp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := DecodeBinary(data)
+		if err != nil || back != exp {
+			t.Errorf("%v: expected it back, but got %v, %v", program, back, err)
+		}
+	}
+	if back, _ := DecodeBinary(mustEncode(t, freeVariable{"succ"})); back != (freeVariable{"succ"}) {
+		t.Errorf("expected a free variable back, but got %#v", back)
+	}
+}
+
+func mustEncode(t *testing.T, exp Expression) []byte {
+	data, err := EncodeBinary(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBinaryStream(t *testing.T) {
+	// names are interned across the terms, so the second spells none out
+	var b bytes.Buffer
+	e := NewEncoder(&b)
+	first, _ := parse("𝞴long.long long")
+	second, _ := parse("long (𝞴long.long)")
+	e.Encode(first)
+	n := b.Len()
+	e.Encode(second)
+	if bytes.Contains(b.Bytes()[n:], []byte("long")) {
+		t.Errorf("expected the name interned, but got %q", b.Bytes()[n:])
+	}
+	d := NewDecoder(&b)
+	for _, expected := range []Expression{first, second} {
+		if got, err := d.Decode(); err != nil || got != expected {
+			t.Errorf("expected %v, but got %v, %v", expected, got, err)
+		}
+	}
+	if _, err := d.Decode(); err != io.EOF {
+		t.Errorf("expected EOF, but got %v", err)
+	}
+}
+
+func TestBinaryCorrupt(t *testing.T) {
+	data := mustEncode(t, application{variable{"f"}, variable{"x"}})
+	if _, err := DecodeBinary([]byte("(f x)")); err != ErrNotBinary {
+		t.Errorf("expected %v, but got %v", ErrNotBinary, err)
+	}
+	if _, err := DecodeBinary(data[:len(data)-1]); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected %v, but got %v", io.ErrUnexpectedEOF, err)
+	}
+	// a reference to a name not yet written
+	bad := append(data[:len(binaryMagic)+1:len(binaryMagic)+1], binaryVariable, 5)
+	if _, err := DecodeBinary(bad); err == nil {
+		t.Errorf("expected a name out of range to fail")
+	}
+	if _, err := DecodeBinary(append(data, binaryHole)); err == nil {
+		t.Errorf("expected more after the term to fail")
+	}
+}
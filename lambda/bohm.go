@@ -0,0 +1,83 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BohmTree is a node of the Böhm tree of a term: its head normal form, whose
+// arguments are the children, or ⊥ when it has none. Children are only head
+// reduced when asked for, since the whole tree is usually infinite.
+type BohmTree struct {
+	term     Expression
+	maxSteps int
+	reduced  bool
+	hnf      HeadNormalForm
+	solvable bool
+	children []*BohmTree
+}
+
+// NewBohmTree is the Böhm tree of e, head reducing each node at most maxSteps
+// times before taking it to be unsolvable
+func NewBohmTree(e Expression, maxSteps int) *BohmTree {
+	return &BohmTree{term: e, maxSteps: maxSteps}
+}
+
+func (t *BohmTree) reduce() {
+	if t.reduced {
+		return
+	}
+	t.reduced = true
+	hnf, err := HeadNormalize(t.term, t.maxSteps)
+	if err != nil {
+		return
+	}
+	t.hnf, t.solvable = hnf, true
+	for _, arg := range hnf.Args {
+		t.children = append(t.children, NewBohmTree(arg, t.maxSteps))
+	}
+}
+
+// Solvable reports whether the node reached head normal form
+func (t *BohmTree) Solvable() bool {
+	t.reduce()
+	return t.solvable
+}
+
+// Node is 𝞴x1 ... xn.y for a head normal form 𝞴x1 ... xn.y M1 ... Mk, or ⊥
+func (t *BohmTree) Node() string {
+	t.reduce()
+	if !t.solvable {
+		return "⊥"
+	}
+	if len(t.hnf.Binders) == 0 {
+		return t.hnf.Head
+	}
+	return fmt.Sprintf("𝞴%v.%v", strings.Join(t.hnf.Binders, " "), t.hnf.Head)
+}
+
+// Children are the Böhm trees of the arguments of the head variable
+func (t *BohmTree) Children() []*BohmTree {
+	t.reduce()
+	return t.children
+}
+
+// Format prints the tree down to depth levels, a child per line indented
+// under its parent, with … where deeper levels were cut off
+func (t *BohmTree) Format(depth int) string {
+	var b strings.Builder
+	var walk func(t *BohmTree, level int)
+	walk = func(t *BohmTree, level int) {
+		indent := strings.Repeat("  ", level)
+		if level == depth {
+			fmt.Fprintf(&b, "%v…\n", indent)
+			return
+		}
+		fmt.Fprintf(&b, "%v%v\n", indent, t.Node())
+		for _, child := range t.Children() {
+			walk(child, level+1)
+		}
+	}
+	walk(t, 0)
+	return b.String()
+}
@@ -0,0 +1,24 @@
+package lambda
+
+import "testing"
+
+func TestBohmTree(t *testing.T) {
+	bohmCases := []struct {
+		program string
+		depth   int
+		tree    string
+	}{
+		{"𝞴f x.f (f x)", 4, "𝞴f x.f\n  f\n    x\n"},
+		{"𝞴x.x ((𝞴y.y y) (𝞴y.y y)) a", 4, "𝞴x.x\n  ⊥\n  a\n"},
+		// Y f unfolds to f (f (f ...)), an infinite tree
+		{"(𝞴f.(𝞴x.f (x x)) (𝞴x.f (x x))) g", 3, "g\n  g\n    g\n      …\n"},
+	}
+	for _, tt := range bohmCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			if tree := NewBohmTree(exp, 100).Format(tt.depth); tree != tt.tree {
+				t.Errorf("expected %q, but got %q", tt.tree, tree)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package lambda
+
+import (
+	"fmt"
+	"sync"
+)
+
+type builtin struct {
+	arity int
+	fn    func([]Expression) (Expression, error)
+}
+
+var (
+	builtinsMu sync.RWMutex
+	builtins   = map[string]builtin{}
+)
+
+// RegisterBuiltin makes name a host function of arity arguments. Wherever name
+// is not otherwise bound, an application of it to arity values is replaced by
+// what fn returns for them, which is then evaluated in turn. Values fn builds
+// can come from Parse.
+func RegisterBuiltin(name string, arity int, fn func([]Expression) (Expression, error)) {
+	if arity < 0 {
+		panic(fmt.Sprintf("builtin %v has negative arity %v", name, arity))
+	}
+	scanner := Scanner{Program: []rune(name)}
+	if tokens, err := scanner.Scan(); err != nil || len(tokens) != 1 || tokens[0].tokenType != identifier {
+		panic(fmt.Sprintf("builtin name %v is not an identifier", name))
+	}
+	builtinsMu.Lock()
+	defer builtinsMu.Unlock()
+	builtins[name] = builtin{arity, fn}
+}
+
+func lookupBuiltin(name string) (builtin, bool) {
+	builtinsMu.RLock()
+	defer builtinsMu.RUnlock()
+	b, ok := builtins[name]
+	return b, ok
+}
+
+// Parse scans and parses a program into an expression
+func Parse(program string) (Expression, error) {
+	return parse(program)
+}
+
+// delta applies a builtin once exp is the builtin applied to exactly its arity,
+// evaluating what it returns in env, where exp was evaluated
+func (i *Interpreter) delta(exp Expression, env Environment) (Expression, bool) {
+	head, args := spine(exp)
+	v, ok := head.(freeVariable)
+	if !ok {
+		return nil, false
+	}
+	b, ok := lookupBuiltin(v.identifier)
+	if !ok || b.arity != len(args) {
+		return nil, false
+	}
+	res, err := b.fn(args)
+	if err != nil {
+		panic(evalError{fmt.Errorf("%v: %v", v.identifier, err)})
+	}
+	// the arguments may hold the parameters of the abstractions exp is in,
+	// which are bound to themselves in env until those are applied
+	return i.eval(res, env), true
+}
@@ -0,0 +1,62 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBuiltin(t *testing.T) {
+	printed := []string{}
+	RegisterBuiltin("testPrint", 1, func(args []Expression) (Expression, error) {
+		printed = append(printed, args[0].String())
+		return args[0], nil
+	})
+	RegisterBuiltin("testConst", 2, func(args []Expression) (Expression, error) {
+		return args[0], nil
+	})
+	RegisterBuiltin("testTrue", 0, func(args []Expression) (Expression, error) {
+		return Parse("𝞴x y.x")
+	})
+	RegisterBuiltin("testFail", 1, func(args []Expression) (Expression, error) {
+		return nil, errors.New("no")
+	})
+	builtinCases := []struct {
+		program string
+		value   string
+		err     string
+	}{
+		{"testPrint ((𝞴x.x) y)", "y", ""},
+		{"testConst a", "(testConst a)", ""},
+		{"testConst a b", "a", ""},
+		{"testTrue a b", "a", ""},
+		{"(𝞴testPrint.testPrint) z", "z", ""},
+		{"(𝞴x.testConst x b) z", "z", ""},
+		{"(𝞴x y.testConst y x) z w", "w", ""},
+		{"testFail a", "", "testFail: no"},
+	}
+	for _, tt := range builtinCases {
+		t.Run(tt.program, func(t *testing.T) {
+			ast, _ := parse(tt.program)
+			interpreter := Interpreter{Ast: ast}
+			value, err := interpreter.Interpret(Environment{})
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("expected %v, but got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil || value.String() != tt.value {
+				t.Errorf("expected %v, but got %v %v", tt.value, value, err)
+			}
+		})
+	}
+	if len(printed) != 1 || printed[0] != "y" {
+		t.Errorf("expected testPrint to see y, but got %v", printed)
+	}
+	// a parameter a builtin returns is still bound when strict
+	ast, _ := parse("(𝞴x.testConst x x) (𝞴y.y)")
+	interpreter := Interpreter{Ast: ast, Strict: true}
+	if value, err := interpreter.Interpret(Environment{}); err != nil || value.String() != "(𝞴y.y)" {
+		t.Errorf("expected 𝞴y.y, but got %v %v", value, err)
+	}
+}
@@ -0,0 +1,270 @@
+package lambda
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Bytecode is a program compiled for a machine that evaluates it as the
+// HOAS evaluator does, by building values and reading them back, but runs
+// instructions rather than walking a tree of names: variables are looked up
+// by how many binders out theirs is, so running a program never compares or
+// substitutes names. The instructions are the term's nodes in prefix order,
+// and each that has two parts says where its second starts.
+
+const (
+	// opBound pushes the variable bound arg binders out
+	opBound byte = iota
+	// opFree pushes the free variable arg of the names
+	opFree
+	// opLam is an abstraction, its body the instructions after it
+	opLam
+	// opApp applies the function after it to the argument at arg, evaluated
+	// only if it is needed
+	opApp
+	// opStrict is opApp for an argument known to be needed, evaluated first
+	opStrict
+	// opLet binds the value after it in the body at arg
+	opLet
+)
+
+type instruction struct {
+	op  byte
+	arg int
+}
+
+type bytecode struct {
+	info  ArtifactInfo
+	names []string
+	code  []instruction
+}
+
+// bytecodeMagic starts every bytecode artifact
+var bytecodeMagic = []byte("λlbc")
+
+// compileBytecode compiles exp, failing on the quotes and holes the machine
+// has no instructions for
+func compileBytecode(exp Expression, info ArtifactInfo) (p *bytecode, err error) {
+	p = &bytecode{info: info}
+	names := map[string]int{}
+	var walk func(exp Expression, scope []string)
+	// second marks the instruction at i as having its second part next
+	second := func(i int) {
+		p.code[i].arg = len(p.code)
+	}
+	walk = func(exp Expression, scope []string) {
+		switch exp := exp.(type) {
+		case binding:
+			i := len(p.code)
+			p.code = append(p.code, instruction{op: opLet})
+			walk(exp.value, scope)
+			second(i)
+			walk(exp.body, append(scope[:len(scope):len(scope)], exp.name.identifier))
+		case replBinding:
+			walk(exp.value, scope)
+		case abstraction:
+			p.code = append(p.code, instruction{op: opLam})
+			walk(exp.expr, append(scope[:len(scope):len(scope)], exp.param.identifier))
+		case application:
+			i := len(p.code)
+			p.code = append(p.code, instruction{op: opApp})
+			walk(exp.left, scope)
+			second(i)
+			walk(exp.right, scope)
+		case strictApplication:
+			i := len(p.code)
+			p.code = append(p.code, instruction{op: opStrict})
+			walk(exp.left, scope)
+			second(i)
+			walk(exp.right, scope)
+		case variable, freeVariable:
+			name, _ := variableName(exp)
+			if _, ok := exp.(variable); ok {
+				for i := len(scope) - 1; i >= 0; i-- {
+					if scope[i] == name {
+						p.code = append(p.code, instruction{opBound, len(scope) - 1 - i})
+						return
+					}
+				}
+			}
+			if _, ok := names[name]; !ok {
+				names[name] = len(p.names)
+				p.names = append(p.names, name)
+			}
+			p.code = append(p.code, instruction{opFree, names[name]})
+		default:
+			if err == nil {
+				err = fmt.Errorf("can't compile %v", format(exp))
+			}
+		}
+	}
+	walk(markStrict(exp), nil)
+	return p, err
+}
+
+// encode writes p as an artifact: bytecodeMagic, the version, the info as
+// JSON, the names and then the instructions, every number a uvarint
+func (p *bytecode) encode() ([]byte, error) {
+	info, err := json.Marshal(p.info)
+	if err != nil {
+		return nil, err
+	}
+	b := append([]byte{}, bytecodeMagic...)
+	b = binary.AppendUvarint(b, artifactVersion)
+	b = binary.AppendUvarint(b, uint64(len(info)))
+	b = append(b, info...)
+	b = binary.AppendUvarint(b, uint64(len(p.names)))
+	for _, name := range p.names {
+		b = binary.AppendUvarint(b, uint64(len(name)))
+		b = append(b, name...)
+	}
+	b = binary.AppendUvarint(b, uint64(len(p.code)))
+	for _, in := range p.code {
+		b = append(b, in.op)
+		b = binary.AppendUvarint(b, uint64(in.arg))
+	}
+	return b, nil
+}
+
+// decodeBytecode reads an artifact encode wrote, checking that its
+// instructions make up one term whose variables are all bound or named
+func decodeBytecode(data []byte) (p *bytecode, err error) {
+	r := bytes.NewReader(data)
+	magic := make([]byte, len(bytecodeMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, bytecodeMagic) {
+		return nil, ErrNotArtifact
+	}
+	if version, err := binary.ReadUvarint(r); err != nil || version != artifactVersion {
+		return nil, ErrNotArtifact
+	}
+	d := Decoder{}
+	p = &bytecode{}
+	err = d.catch(func() {
+		number := func(most uint64) int {
+			n, err := binary.ReadUvarint(r)
+			if err != nil {
+				d.fail(err)
+			}
+			if n > most {
+				d.fail(fmt.Errorf("%v is more than the %v expected", n, most))
+			}
+			return int(n)
+		}
+		chunk := func() []byte {
+			b := make([]byte, number(uint64(r.Len())))
+			io.ReadFull(r, b)
+			return b
+		}
+		if err := json.Unmarshal(chunk(), &p.info); err != nil {
+			d.fail(err)
+		}
+		p.names = make([]string, number(uint64(r.Len())))
+		for i := range p.names {
+			p.names[i] = string(chunk())
+		}
+		// every instruction takes at least two bytes
+		p.code = make([]instruction, number(uint64(r.Len()/2)))
+		for i := range p.code {
+			op, err := r.ReadByte()
+			if err != nil {
+				d.fail(err)
+			}
+			p.code[i] = instruction{op, number(uint64(len(p.code)))}
+		}
+		if r.Len() > 0 {
+			d.fail(fmt.Errorf("more after the instructions"))
+		}
+		if len(p.code) == 0 || p.end(0, 0) != len(p.code) {
+			d.fail(fmt.Errorf("instructions left over"))
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("bad bytecode: %w", err)
+	}
+	return p, nil
+}
+
+// end checks the term at pc, with depth binders around it, returning where
+// the instructions after it start
+func (p *bytecode) end(pc, depth int) int {
+	if pc >= len(p.code) {
+		panic(evalError{io.ErrUnexpectedEOF})
+	}
+	in := p.code[pc]
+	switch in.op {
+	case opBound:
+		if in.arg >= depth {
+			panic(evalError{fmt.Errorf("variable %v binders out under %v at %v", in.arg, depth, pc)})
+		}
+		return pc + 1
+	case opFree:
+		if in.arg >= len(p.names) {
+			panic(evalError{fmt.Errorf("name %v of only %v at %v", in.arg, len(p.names), pc)})
+		}
+		return pc + 1
+	case opLam:
+		return p.end(pc+1, depth+1)
+	case opApp, opStrict, opLet:
+		inner := 0
+		if in.op == opLet {
+			inner = 1
+		}
+		if p.end(pc+1, depth) != in.arg {
+			panic(evalError{fmt.Errorf("second part at %v doesn't follow the first at %v", in.arg, pc)})
+		}
+		return p.end(in.arg, depth+inner)
+	default:
+		panic(evalError{fmt.Errorf("unknown instruction %v at %v", in.op, pc)})
+	}
+}
+
+// frame is a value bound around the instruction running, and the frames of
+// the binders outside it
+type frame struct {
+	value Value
+	next  *frame
+}
+
+// machine runs bytecode, with the limits of the HOAS evaluator
+type machine struct {
+	hoas
+	*bytecode
+}
+
+func (m *machine) eval(pc int, env *frame) Value {
+	in := m.code[pc]
+	switch in.op {
+	case opBound:
+		for i := 0; i < in.arg; i++ {
+			env = env.next
+		}
+		return env.value
+	case opFree:
+		return Var(m.names[in.arg])
+	case opLam:
+		return Lam(func(x Value) Value {
+			return m.eval(pc+1, &frame{x, env})
+		})
+	case opApp:
+		arg := &thunk{compute: func() Value { return m.eval(in.arg, env) }}
+		return m.apply(m.eval(pc+1, env), arg)
+	case opStrict:
+		f := m.eval(pc+1, env)
+		return m.apply(f, force(m.eval(in.arg, env)))
+	default:
+		value := &thunk{compute: func() Value { return m.eval(pc+1, env) }}
+		return m.eval(in.arg, &frame{value, env})
+	}
+}
+
+// execute finds the normal form of the program and how many applications of
+// abstractions that took, failing with ErrStepLimit after maxSteps of them, 0
+// meaning no limit
+func (p *bytecode) execute(maxSteps int) (Expression, int, error) {
+	m := &machine{hoas{maxSteps: maxSteps}, p}
+	value, err := m.run(func() Expression { return m.readback(m.eval(0, nil), 0) })
+	return value, m.steps, err
+}
@@ -0,0 +1,156 @@
+package lambda
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// Cache keeps the normal forms of closed terms in a directory, so evaluating
+// a term evaluated before, in this session or an earlier one, reads its
+// normal form back instead. A term's file is named by its Hash, and holds the
+// term and then its normal form in the binary encoding, so a term whose hash
+// collides with another's isn't given the other's normal form. A normal form read back is
+// named as it was for the term first evaluated, which the term is equal to
+// up to renaming.
+type Cache struct {
+	Dir string
+	// hits and misses count the closed terms looked up since the cache was
+	// opened
+	hits, misses atomic.Int64
+}
+
+// CacheStats says how much a cache is used and how large it is
+type CacheStats struct {
+	Hits, Misses int64
+	// Entries is how many normal forms are kept, in Bytes in all
+	Entries int
+	Bytes   int64
+}
+
+func (s CacheStats) String() string {
+	return fmt.Sprintf("%v hits, %v misses, %v entries, %v bytes", s.Hits, s.Misses, s.Entries, s.Bytes)
+}
+
+// cacheFile matches the files of a cache's normal forms
+const cacheFile = "????????????????.bin"
+
+// Stats counts the hits and misses since the cache was opened, and the
+// normal forms kept in it by every session
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load()}
+	files, err := filepath.Glob(filepath.Join(c.Dir, cacheFile))
+	if err != nil {
+		return stats, err
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			// another session cleared it
+			continue
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+	}
+	return stats, nil
+}
+
+// Clear removes every normal form kept, leaving the directory
+func (c *Cache) Clear() error {
+	files, err := filepath.Glob(filepath.Join(c.Dir, cacheFile))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewCache keeps normal forms in dir, creating it if it doesn't exist
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+func (c *Cache) file(term Expression) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("%016x.bin", Hash(term)))
+}
+
+// get reads the normal form of term, reporting false when it isn't cached
+func (c *Cache) get(term Expression) (Expression, bool) {
+	f, err := os.Open(c.file(term))
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	d := NewDecoder(f)
+	key, err := d.Decode()
+	if err != nil || !alphaEquivalent(key, term) {
+		return nil, false
+	}
+	normal, err := d.Decode()
+	if err != nil {
+		return nil, false
+	}
+	return normal, true
+}
+
+// put writes the normal form of term. It is written to a temporary file
+// first, so another session never reads half of it.
+func (c *Cache) put(term, normal Expression) error {
+	f, err := os.CreateTemp(c.Dir, "tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	e := NewEncoder(f)
+	e.term(term)
+	err = e.Encode(normal)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), c.file(term))
+}
+
+// interpretCached is Interpret reading the value from i.Cache when the
+// program, with the definitions of env filled in, is a closed term that was
+// evaluated before, and adding it otherwise. A definition's value is cached,
+// not the definition.
+func (i *Interpreter) interpretCached(env Environment) Expression {
+	term := i.Ast
+	d, isDefinition := term.(replBinding)
+	if isDefinition {
+		term = d.value
+	}
+	term = resolve(term, env)
+	if len(freeVariables(term)) > 0 {
+		return i.eval(i.Ast, env)
+	}
+	normal, ok := i.Cache.get(term)
+	if ok {
+		i.Cache.hits.Add(1)
+		if isDefinition {
+			return replBinding{d.name, normal}
+		}
+		return normal
+	}
+	i.Cache.misses.Add(1)
+	value := i.eval(i.Ast, env)
+	normal = value
+	if v, ok := value.(replBinding); ok {
+		normal = v.value
+	}
+	// the cache only saves time, so a normal form that can't be written is
+	// just evaluated again next time
+	i.Cache.put(term, normal)
+	return value
+}
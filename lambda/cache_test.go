@@ -0,0 +1,63 @@
+package lambda
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	cache, err := NewCache(filepath.Join(t.TempDir(), "cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := Environment{}.bind(variable{"id"}, abstraction{variable{"x"}, variable{"x"}})
+	interpret := func(program string) string {
+		ast, err := parse(program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		interpreter := Interpreter{Ast: ast, Cache: cache}
+		value, err := interpreter.Interpret(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return format(value)
+	}
+	if got := interpret("id (𝞴y.y) z"); got != "z" {
+		t.Errorf("expected z, but got %v", got)
+	}
+	if files, _ := os.ReadDir(cache.Dir); len(files) != 0 {
+		t.Errorf("expected a term with free variables not cached, but got %v files", len(files))
+	}
+	if got := interpret("'k = id (𝞴a b.a)"); got != "'k = 𝞴a b.a" {
+		t.Errorf("expected 'k = 𝞴a b.a, but got %v", got)
+	}
+	// the definition's value is cached, so the same term renamed reads it
+	// back, which it is seen to do by changing what is cached
+	term, _ := parse("(𝞴x.x) (𝞴a b.a)")
+	file := cache.file(term)
+	write := func(key string) {
+		f, err := os.Create(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		keyTerm, _ := parse(key)
+		normal, _ := parse("𝞴p q.q")
+		e := NewEncoder(f)
+		e.term(keyTerm)
+		if err := e.Encode(normal); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("(𝞴y.y) (𝞴c d.c)")
+	if got := interpret("id (𝞴u v.u)"); got != "𝞴p q.q" {
+		t.Errorf("expected the cached 𝞴p q.q, but got %v", got)
+	}
+	// a file for another term, whose hash collides, isn't read
+	write("𝞴a.a")
+	if got := interpret("id (𝞴u v.u)"); got != "𝞴u v.u" {
+		t.Errorf("expected 𝞴u v.u evaluated again, but got %v", got)
+	}
+}
@@ -0,0 +1,173 @@
+package lambda
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// canonicalName is the nth name of the scheme a, b, ..., z, a1, b1, ...
+func canonicalName(n int) string {
+	name := string(rune('a' + n%26))
+	if n >= 26 {
+		name += strconv.Itoa(n / 26)
+	}
+	return name
+}
+
+// Canonicalize renames bound variables to a, b, c, ... in the order their
+// binders appear, skipping names that occur free, so alpha-equivalent terms
+// come out identical.
+func Canonicalize(e Expression) Expression {
+	free := freeVariables(e)
+	next := 0
+	newName := func() variable {
+		for {
+			name := canonicalName(next)
+			next += 1
+			if !free[name] {
+				return variable{name}
+			}
+		}
+	}
+	var walk func(exp Expression, names map[string]string) Expression
+	scoped := func(names map[string]string, from, to string) map[string]string {
+		inner := make(map[string]string, len(names)+1)
+		for k, v := range names {
+			inner[k] = v
+		}
+		inner[from] = to
+		return inner
+	}
+	walk = func(exp Expression, names map[string]string) Expression {
+		switch exp := exp.(type) {
+		case binding:
+			name := newName()
+			value := walk(exp.value, names)
+			return binding{name, value, walk(exp.body, scoped(names, exp.name.identifier, name.identifier))}
+		case replBinding:
+			return replBinding{exp.name, walk(exp.value, names)}
+		case abstraction:
+			param := newName()
+			return abstraction{param, walk(exp.expr, scoped(names, exp.param.identifier, param.identifier))}
+		case application:
+			left := walk(exp.left, names)
+			return application{left, walk(exp.right, names)}
+		case variable:
+			if name, ok := names[exp.identifier]; ok {
+				return variable{name}
+			}
+			return exp
+		default:
+			return exp
+		}
+	}
+	return walk(e, map[string]string{})
+}
+
+// The canonical serialization of a term is the same for every term equal to
+// it up to renaming of bound variables, so it can address the term by its
+// content. It serializes the de Bruijn form: binders leave their names out,
+// and a bound variable is how many binders out its binder is. After a header,
+// canonicalMagic and the version, each node is a tag byte and its parts in
+// prefix order, so serializations can follow one another, as the terms of a
+// trace do.
+
+// canonicalMagic starts every canonical serialization
+var canonicalMagic = []byte("λcan")
+
+// canonicalVersion is the version of the canonical serialization, which
+// changing changes every address
+const canonicalVersion = 1
+
+const (
+	canonicalLet byte = iota
+	canonicalDefinition
+	canonicalAbstraction
+	canonicalApplication
+	canonicalBound
+	canonicalFree
+	canonicalQuoted
+	canonicalUnquoted
+	canonicalHole
+)
+
+// CanonicalBytes is the canonical serialization of e
+func CanonicalBytes(e Expression) []byte {
+	return CanonicalTrace([]Expression{e})
+}
+
+// CanonicalTrace is the canonical serialization of the terms of a trace, one
+// after another
+func CanonicalTrace(terms []Expression) []byte {
+	var b bytes.Buffer
+	b.Write(canonicalMagic)
+	b.Write(binary.AppendUvarint(nil, canonicalVersion))
+	for _, term := range terms {
+		writeCanonical(&b, term, nil)
+	}
+	return b.Bytes()
+}
+
+// ContentAddress names data by its SHA-256, as sha256: and then it in hex, so
+// the address of CanonicalBytes or CanonicalTrace stores a term or trace once
+// however its binders are named
+func ContentAddress(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+// writeCanonical writes the canonical serialization of exp to w, but for the
+// header, the binders in scope named by scope, innermost last. Free variables
+// are written by name, whether or not they are bound by definition.
+func writeCanonical(w io.Writer, exp Expression, scope []string) {
+	switch exp := exp.(type) {
+	case binding:
+		w.Write([]byte{canonicalLet})
+		writeCanonical(w, exp.value, scope)
+		writeCanonical(w, exp.body, append(scope[:len(scope):len(scope)], exp.name.identifier))
+	case replBinding:
+		// the name of a definition is part of what it means
+		w.Write([]byte{canonicalDefinition})
+		writeCanonicalName(w, exp.name.identifier)
+		writeCanonical(w, exp.value, scope)
+	case abstraction:
+		w.Write([]byte{canonicalAbstraction})
+		writeCanonical(w, exp.expr, append(scope[:len(scope):len(scope)], exp.param.identifier))
+	case application:
+		w.Write([]byte{canonicalApplication})
+		writeCanonical(w, exp.left, scope)
+		writeCanonical(w, exp.right, scope)
+	case strictApplication:
+		writeCanonical(w, exp.application, scope)
+	case quoted:
+		w.Write([]byte{canonicalQuoted})
+		writeCanonical(w, exp.expr, scope)
+	case unquoted:
+		w.Write([]byte{canonicalUnquoted})
+		writeCanonical(w, exp.expr, scope)
+	case hole:
+		w.Write([]byte{canonicalHole})
+	case freeVariable:
+		w.Write([]byte{canonicalFree})
+		writeCanonicalName(w, exp.identifier)
+	case variable:
+		for i := len(scope) - 1; i >= 0; i-- {
+			if scope[i] == exp.identifier {
+				w.Write(binary.AppendUvarint([]byte{canonicalBound}, uint64(len(scope)-1-i)))
+				return
+			}
+		}
+		w.Write([]byte{canonicalFree})
+		writeCanonicalName(w, exp.identifier)
+	}
+}
+
+// writeCanonicalName writes name with its length first, so names next to each
+// other can't run together
+func writeCanonicalName(w io.Writer, name string) {
+	w.Write(binary.AppendUvarint(nil, uint64(len(name))))
+	io.WriteString(w, name)
+}
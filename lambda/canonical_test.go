@@ -0,0 +1,65 @@
+package lambda
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	canonicalCases := []struct {
+		program   string
+		canonical string
+	}{
+		{"𝞴x.x", "𝞴a.a"},
+		{"𝞴x y.y x", "𝞴a b.b a"},
+		{"𝞴x.x (𝞴x.x)", "𝞴a.a (𝞴b.b)"},
+		{"𝞴x.a x", "𝞴b.a b"},
+		{"let id = 𝞴x.x in id id", "let a = 𝞴b.b in a a"},
+		{"'k = 𝞴u v.u", "'k = 𝞴a b.a"},
+	}
+	for _, tt := range canonicalCases {
+		t.Run(tt.program, func(t *testing.T) {
+			ast, _ := parse(tt.program)
+			if res := format(Canonicalize(ast)); res != tt.canonical {
+				t.Errorf("expected %v, but got %v", tt.canonical, res)
+			}
+		})
+	}
+	if canonicalName(27) != "b1" {
+		t.Errorf("expected b1, but got %v", canonicalName(27))
+	}
+}
+
+func TestCanonicalBytes(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"𝞴x y.x", "𝞴a b.a", true},
+		{"𝞴x y.x", "𝞴x y.y", false},
+		{"let x = f in 𝞴y.x y", "let y = f in 𝞴x.y x", true},
+		// binders inside a quote are renamed with the rest
+		{"𝞴x.quote (f ~x)", "𝞴y.quote (f ~y)", true},
+		{"𝞴x.z", "𝞴x.w", false},
+	}
+	for _, test := range tests {
+		a, _ := parse(test.a)
+		b, _ := parse(test.b)
+		if equal := string(CanonicalBytes(a)) == string(CanonicalBytes(b)); equal != test.equal {
+			t.Errorf("expected serializations of %v and %v equal %v, but got %v", test.a, test.b, test.equal, equal)
+		}
+	}
+	// a variable bound by definition is free like any other
+	if string(CanonicalBytes(freeVariable{"x"})) != string(CanonicalBytes(variable{"x"})) {
+		t.Errorf("expected free variables serialized alike")
+	}
+	id, _ := parse("𝞴x.x")
+	renamed, _ := parse("𝞴y.y")
+	trace := CanonicalTrace([]Expression{id, id})
+	if ContentAddress(trace) != ContentAddress(CanonicalTrace([]Expression{renamed, renamed})) {
+		t.Errorf("expected traces equal up to renaming at the same address")
+	}
+	if ContentAddress(trace) == ContentAddress(CanonicalBytes(id)) {
+		t.Errorf("expected a trace of two terms at another address than one term")
+	}
+	if address := ContentAddress(nil); address != "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("expected the SHA-256 of nothing, but got %v", address)
+	}
+}
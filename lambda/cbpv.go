@@ -0,0 +1,366 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Call-by-push-value splits terms into values, which are, and computations,
+// which do. A value is a variable or a suspended computation, thunk M; a
+// computation returns a value, sequences two computations with M to x. N,
+// forces a thunk, or is a function popping its argument off the stack that
+// M V pushes V onto. Both call by value and call by name translate into it,
+// the difference between them becoming where the thunks go.
+
+// Embedding picks how a lambda term is translated into call-by-push-value
+type Embedding int
+
+const (
+	// CallByValue evaluates arguments before passing them, as returned values
+	CallByValue Embedding = iota
+	// CallByName passes arguments as thunks, forced where they are used
+	CallByName
+)
+
+func (e Embedding) String() string {
+	if e == CallByName {
+		return "call-by-name"
+	}
+	return "call-by-value"
+}
+
+func parseEmbedding(name string) (Embedding, error) {
+	switch name {
+	case "cbv":
+		return CallByValue, nil
+	case "cbn":
+		return CallByName, nil
+	default:
+		return CallByValue, fmt.Errorf("unknown embedding %v, expected cbv or cbn", name)
+	}
+}
+
+type cbpvValue interface {
+	isCBPVValue()
+	String() string
+}
+
+// Computation is a call-by-push-value computation
+type Computation interface {
+	isComputation()
+	String() string
+}
+
+type cVar struct {
+	name string
+}
+
+type cThunk struct {
+	body Computation
+}
+
+type cReturn struct {
+	value cbpvValue
+}
+
+// cTo runs bound, then body with name bound to the value it returned
+type cTo struct {
+	bound Computation
+	name  string
+	body  Computation
+}
+
+type cLam struct {
+	param string
+	body  Computation
+}
+
+// cPush pushes arg, then runs fn
+type cPush struct {
+	fn  Computation
+	arg cbpvValue
+}
+
+type cForce struct {
+	value cbpvValue
+}
+
+type cLet struct {
+	name  string
+	value cbpvValue
+	body  Computation
+}
+
+func (cVar) isCBPVValue()      {}
+func (cThunk) isCBPVValue()    {}
+func (cReturn) isComputation() {}
+func (cTo) isComputation()     {}
+func (cLam) isComputation()    {}
+func (cPush) isComputation()   {}
+func (cForce) isComputation()  {}
+func (cLet) isComputation()    {}
+
+func (v cVar) String() string   { return v.name }
+func (v cThunk) String() string { return "thunk (" + v.body.String() + ")" }
+
+func (c cReturn) String() string { return "return " + cbpvAtom(c.value) }
+func (c cTo) String() string {
+	bound := c.bound.String()
+	switch c.bound.(type) {
+	case cTo, cLam, cLet:
+		bound = "(" + bound + ")"
+	}
+	return fmt.Sprintf("%v to %v. %v", bound, c.name, c.body)
+}
+func (c cLam) String() string { return fmt.Sprintf("𝞴%v.%v", c.param, c.body) }
+func (c cPush) String() string {
+	fn := c.fn.String()
+	if _, ok := c.fn.(cPush); !ok {
+		fn = "(" + fn + ")"
+	}
+	return fn + " " + cbpvAtom(c.arg)
+}
+func (c cForce) String() string { return "force " + cbpvAtom(c.value) }
+func (c cLet) String() string {
+	return fmt.Sprintf("let %v = %v in %v", c.name, c.value, c.body)
+}
+
+func cbpvAtom(v cbpvValue) string {
+	if _, ok := v.(cVar); ok {
+		return v.String()
+	}
+	return "(" + v.String() + ")"
+}
+
+// TranslateCBPV embeds e into call-by-push-value. Under CallByValue a term
+// becomes a computation returning its value, abstractions returning thunks of
+// functions; under CallByName variables are thunks to force and arguments are
+// passed unevaluated as thunks.
+func TranslateCBPV(e Expression, embedding Embedding) Computation {
+	if v, ok := e.(replBinding); ok {
+		e = v.value
+	}
+	// the names the call-by-value translation binds for the function and
+	// argument of an application, which nothing in e can be called
+	avoid := map[string]bool{}
+	allNames(e, avoid)
+	name := func(n string) string {
+		if avoid[n] {
+			n = fresh(n, avoid)
+		}
+		avoid[n] = true
+		return n
+	}
+	f, a := name("f"), name("a")
+	var cbv, cbn func(e Expression) Computation
+	cbv = func(e Expression) Computation {
+		switch e := e.(type) {
+		case binding:
+			return cTo{cbv(e.value), e.name.identifier, cbv(e.body)}
+		case abstraction:
+			return cReturn{cThunk{cLam{e.param.identifier, cbv(e.expr)}}}
+		case application:
+			return cTo{cbv(e.left), f, cTo{cbv(e.right), a, cPush{cForce{cVar{f}}, cVar{a}}}}
+		default:
+			name, _ := variableName(e)
+			return cReturn{cVar{name}}
+		}
+	}
+	cbn = func(e Expression) Computation {
+		switch e := e.(type) {
+		case binding:
+			return cLet{e.name.identifier, cThunk{cbn(e.value)}, cbn(e.body)}
+		case abstraction:
+			return cLam{e.param.identifier, cbn(e.expr)}
+		case application:
+			return cPush{cbn(e.left), cThunk{cbn(e.right)}}
+		default:
+			name, _ := variableName(e)
+			return cForce{cVar{name}}
+		}
+	}
+	if embedding == CallByName {
+		return cbn(e)
+	}
+	return cbv(e)
+}
+
+// cbpvNames collects the names in t, with free those occurring free
+func cbpvNames(t interface{}, bound map[string]int, names, free map[string]bool) {
+	binder := func(name string, body Computation) {
+		names[name] = true
+		bound[name] += 1
+		cbpvNames(body, bound, names, free)
+		bound[name] -= 1
+	}
+	switch t := t.(type) {
+	case cVar:
+		names[t.name] = true
+		if bound[t.name] == 0 {
+			free[t.name] = true
+		}
+	case cThunk:
+		cbpvNames(t.body, bound, names, free)
+	case cReturn:
+		cbpvNames(t.value, bound, names, free)
+	case cTo:
+		cbpvNames(t.bound, bound, names, free)
+		binder(t.name, t.body)
+	case cLam:
+		binder(t.param, t.body)
+	case cPush:
+		cbpvNames(t.fn, bound, names, free)
+		cbpvNames(t.arg, bound, names, free)
+	case cForce:
+		cbpvNames(t.value, bound, names, free)
+	case cLet:
+		cbpvNames(t.value, bound, names, free)
+		binder(t.name, t.body)
+	}
+}
+
+// cbpvSubstitute replaces the free occurrences of name in c with v, whose free
+// variables are free, renaming binders that would capture them
+func cbpvSubstitute(c Computation, name string, v cbpvValue, free map[string]bool) Computation {
+	value := func(w cbpvValue) cbpvValue {
+		switch w := w.(type) {
+		case cVar:
+			if w.name == name {
+				return v
+			}
+			return w
+		case cThunk:
+			return cThunk{cbpvSubstitute(w.body, name, v, free)}
+		default:
+			return w
+		}
+	}
+	// binder substitutes in body beneath a binder of x, renaming it if needed
+	binder := func(x string, body Computation) (string, Computation) {
+		if x == name {
+			return x, body
+		}
+		names, bodyFree := map[string]bool{}, map[string]bool{}
+		cbpvNames(body, map[string]int{}, names, bodyFree)
+		if !bodyFree[name] {
+			return x, body
+		}
+		if free[x] {
+			for n := range free {
+				names[n] = true
+			}
+			names[name] = true
+			renamed := fresh(x, names)
+			body = cbpvSubstitute(body, x, cVar{renamed}, map[string]bool{renamed: true})
+			x = renamed
+		}
+		return x, cbpvSubstitute(body, name, v, free)
+	}
+	switch c := c.(type) {
+	case cReturn:
+		return cReturn{value(c.value)}
+	case cTo:
+		x, body := binder(c.name, c.body)
+		return cTo{cbpvSubstitute(c.bound, name, v, free), x, body}
+	case cLam:
+		x, body := binder(c.param, c.body)
+		return cLam{x, body}
+	case cPush:
+		return cPush{cbpvSubstitute(c.fn, name, v, free), value(c.arg)}
+	case cForce:
+		return cForce{value(c.value)}
+	case cLet:
+		x, body := binder(c.name, c.body)
+		return cLet{x, value(c.value), body}
+	default:
+		return c
+	}
+}
+
+func cbpvBind(body Computation, name string, v cbpvValue) Computation {
+	names, free := map[string]bool{}, map[string]bool{}
+	cbpvNames(v, map[string]int{}, names, free)
+	return cbpvSubstitute(body, name, v, free)
+}
+
+// cbpvFrame is what the stack holds: a pushed argument, or the rest of a
+// sequence, waiting for a value to be returned
+type cbpvFrame struct {
+	arg  cbpvValue
+	name string
+	body Computation
+}
+
+// RunCBPV runs c on a stack machine until it can't go on, returning the
+// computation it stopped at, with whatever is left on the stack put back
+// around it, and how many reductions it took: returns to a sequence, pops by
+// a function, forces of a thunk and lets. It fails with ErrStepLimit after
+// maxSteps reductions, 0 means no limit.
+func RunCBPV(c Computation, maxSteps int) (Computation, int, error) {
+	stack := []cbpvFrame{}
+	steps := 0
+	for {
+		var next Computation
+		switch t := c.(type) {
+		case cTo:
+			stack = append(stack, cbpvFrame{name: t.name, body: t.body})
+			c = t.bound
+			continue
+		case cPush:
+			stack = append(stack, cbpvFrame{arg: t.arg})
+			c = t.fn
+			continue
+		case cReturn:
+			if n := len(stack); n > 0 && stack[n-1].arg == nil {
+				next = cbpvBind(stack[n-1].body, stack[n-1].name, t.value)
+				stack = stack[:n-1]
+			}
+		case cLam:
+			if n := len(stack); n > 0 && stack[n-1].arg != nil {
+				next = cbpvBind(t.body, t.param, stack[n-1].arg)
+				stack = stack[:n-1]
+			}
+		case cForce:
+			if thunk, ok := t.value.(cThunk); ok {
+				next = thunk.body
+			}
+		case cLet:
+			next = cbpvBind(t.body, t.name, t.value)
+		}
+		if next == nil {
+			break
+		}
+		if maxSteps > 0 && steps == maxSteps {
+			return plugCBPV(next, stack), steps, ErrStepLimit
+		}
+		steps += 1
+		c = next
+	}
+	return plugCBPV(c, stack), steps, nil
+}
+
+// plugCBPV puts the frames of stack back around c, innermost last
+func plugCBPV(c Computation, stack []cbpvFrame) Computation {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].arg != nil {
+			c = cPush{c, stack[i].arg}
+		} else {
+			c = cTo{c, stack[i].name, stack[i].body}
+		}
+	}
+	return c
+}
+
+// formatCBPV prints what :cbpv shows for one embedding of e
+func formatCBPV(e Expression, embedding Embedding, maxSteps int) string {
+	var b strings.Builder
+	c := TranslateCBPV(e, embedding)
+	fmt.Fprintf(&b, "%v: %v\n", embedding, c)
+	res, steps, err := RunCBPV(c, maxSteps)
+	if err != nil {
+		fmt.Fprintf(&b, "  %v after %v steps\n", err, steps)
+	} else {
+		fmt.Fprintf(&b, "  ⇓ %v in %v steps\n", res, steps)
+	}
+	return b.String()
+}
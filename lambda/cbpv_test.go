@@ -0,0 +1,43 @@
+package lambda
+
+import "testing"
+
+func TestCBPV(t *testing.T) {
+	cbpvCases := []struct {
+		program, cbv, cbn string
+	}{
+		{"𝞴x.x", "return (thunk (𝞴x.return x))", "𝞴x.force x"},
+		{"(𝞴x.x) y",
+			"return (thunk (𝞴x.return x)) to f. return y to a. (force f) a",
+			"(𝞴x.force x) (thunk (force y))"},
+		{"let x = y in x", "return y to x. return x", "let x = thunk (force y) in force x"},
+	}
+	for _, tt := range cbpvCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			if res := TranslateCBPV(exp, CallByValue).String(); res != tt.cbv {
+				t.Errorf("expected %v, but got %v", tt.cbv, res)
+			}
+			if res := TranslateCBPV(exp, CallByName).String(); res != tt.cbn {
+				t.Errorf("expected %v, but got %v", tt.cbn, res)
+			}
+		})
+	}
+
+	// the argument diverges, which only call by value runs
+	exp, _ := parse("(𝞴x y.y) ((𝞴x.x x) (𝞴x.x x))")
+	if _, _, err := RunCBPV(TranslateCBPV(exp, CallByValue), 100); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+	res, steps, err := RunCBPV(TranslateCBPV(exp, CallByName), 100)
+	if err != nil || res.String() != "𝞴y.force y" || steps != 1 {
+		t.Errorf("expected 𝞴y.force y in 1 step, but got %v in %v %v", res, steps, err)
+	}
+
+	// a free variable stops the machine with its arguments still pushed
+	exp, _ = parse("g ((𝞴x.x) a)")
+	res, _, err = RunCBPV(TranslateCBPV(exp, CallByValue), 100)
+	if err != nil || res.String() != "(force g) a" {
+		t.Errorf("expected (force g) a, but got %v %v", res, err)
+	}
+}
@@ -0,0 +1,221 @@
+package lambda
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// Check reads a module as loading it would, but evaluates nothing: each line
+// is only scanned, parsed and its names resolved against the definitions
+// before it and those of its imports. Rather than stopping at the first
+// error, every one is reported, along with the warnings of each line, so an
+// editor or a check of course materials learns of them all at once, and
+// quickly, however long the definitions would take to evaluate.
+
+// Problem is an error or warning found at Line and Column of File, counting
+// from 1
+type Problem struct {
+	File         string
+	Line, Column int
+	Message      string
+	Warning      bool
+}
+
+func (p Problem) String() string {
+	if p.Warning {
+		return fmt.Sprintf("%v:%v:%v: warning: %v", p.File, p.Line, p.Column, p.Message)
+	}
+	return fmt.Sprintf("%v:%v:%v: %v", p.File, p.Line, p.Column, p.Message)
+}
+
+// Check reports the problems of the module in file and of the modules it
+// imports, which are looked for along path, or the default path when it is
+// nil. The error is only for file not being read.
+func Check(file string, path []string) ([]Problem, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	c := newChecker(path)
+	c.module("", file, string(text))
+	return c.problems, nil
+}
+
+// Failed tells whether any of problems is an error rather than a warning
+func Failed(problems []Problem) bool {
+	for _, p := range problems {
+		if !p.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// checker checks modules as modules loads them, each once however often it
+// is imported
+type checker struct {
+	modules
+	checked map[string]module
+	// definitions is those of every module checked, in the order they were
+	// made. Each is named by its name and then # and its number, which no
+	// variable of the source syntax can be called, and the names of the
+	// definitions it uses are so numbered too, so a definition made again
+	// doesn't change those made before.
+	definitions []envBinding
+	problems    []Problem
+}
+
+// newChecker checks modules looked for along path, or the default path when
+// it is nil
+func newChecker(path []string) *checker {
+	if path == nil {
+		path = defaultModulePath()
+	}
+	return &checker{modules: modules{path: path}, checked: map[string]module{}}
+}
+
+func (c *checker) report(file string, line, column int, warning bool, format string, args ...interface{}) {
+	c.problems = append(c.problems, Problem{file, line, column, fmt.Sprintf(format, args...), warning})
+}
+
+// module checks module name, whose text came from file. Its definitions are
+// bound to the numbered names of c.definitions.
+func (c *checker) module(name, file, text string) module {
+	mod := module{name: name}
+	env := Environment{}
+	for i, line := range strings.Split(text, "\n") {
+		// the columns are those of the line as written, before it is trimmed
+		indent := utf8.RuneCountInString(line[:len(line)-len(strings.TrimLeft(line, " \t\r"))])
+		line = strings.TrimSpace(line)
+		if line == "" || isComment(line) {
+			continue
+		}
+		if declared, ok := keywordLine(line, "module"); ok {
+			if name != "" && declared != name {
+				c.report(file, i+1, indent+1, false, "module %v is in %v", declared, filepath.Base(file))
+			}
+			continue
+		}
+		if imported, ok := keywordLine(line, "import"); ok {
+			dependency, err := c.load(imported)
+			if err != nil {
+				c.report(file, i+1, indent+1, false, "%v", err)
+				continue
+			}
+			env = dependency.bind(env)
+			continue
+		}
+		if isAssertion(line) {
+			left, right, ok := strings.Cut(strings.TrimPrefix(line, "assert "), "==")
+			if !ok {
+				c.report(file, i+1, indent+1, false, "usage: assert e1 == e2")
+				continue
+			}
+			start := len("assert ")
+			for _, side := range []string{left, right} {
+				trimmed := strings.TrimSpace(side)
+				column := indent + utf8.RuneCountInString(line[:start+strings.Index(side, trimmed)])
+				start += len(side) + len("==")
+				c.line(file, i+1, column, trimmed, env)
+			}
+			continue
+		}
+		ast, ok := c.line(file, i+1, indent, line, env)
+		if !ok {
+			continue
+		}
+		d, ok := ast.(replBinding)
+		if !ok {
+			c.report(file, i+1, indent+1, false, "a module only defines names with ', but got %v", line)
+			continue
+		}
+		numbered := variable{fmt.Sprintf("%v#%v", d.name, len(c.definitions))}
+		c.definitions = append(c.definitions, envBinding{numbered, resolve(d.value, env), line})
+		env = env.define(d.name, numbered, line)
+		mod.definitions = append(mod.definitions, envBinding{d.name, numbered, line})
+	}
+	mod.scope = env
+	return mod
+}
+
+// line checks text, which starts after column runes of line n of file,
+// reporting its syntax error, or else the variables it uses that env
+// doesn't bind and its warnings
+func (c *checker) line(file string, n, column int, text string, env Environment) (Expression, bool) {
+	d := newDocument(text)
+	if d.err != nil {
+		offset := 0
+		if e, ok := d.err.(syntaxError); ok {
+			offset = e.offset
+		}
+		c.report(file, n, column+offset+1, false, "%v", d.err)
+		return nil, false
+	}
+	_, free := d.uses()
+	for _, s := range free {
+		name := s.exp.(variable).identifier
+		if _, ok := lookupBuiltin(name); ok {
+			continue
+		}
+		if _, ok := env.find(variable{name}); !ok {
+			_, at := position(d.text, s.start)
+			c.report(file, n, column+at, false, "%v", unboundError{name, env.suggest(name)})
+		}
+	}
+	defined := map[string]bool{}
+	for _, name := range env.names() {
+		defined[name] = true
+	}
+	for _, w := range append(d.shadowing(defined), d.unused(true, false)...) {
+		w = w.relocate(n, column+1)
+		c.report(file, w.Line, w.Column, true, "%v", w.Message)
+	}
+	return d.ast, true
+}
+
+// load checks module name and the modules it imports, if they haven't been
+func (c *checker) load(name string) (module, error) {
+	if mod, ok := c.checked[name]; ok {
+		return mod, nil
+	}
+	for i, loading := range c.loading {
+		if loading == name {
+			cycle := append(append([]string{}, c.loading[i:]...), name)
+			return module{}, fmt.Errorf("import cycle: %v", strings.Join(cycle, " imports "))
+		}
+	}
+	file, err := c.find(name)
+	if err != nil {
+		return module{}, err
+	}
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return module{}, err
+	}
+	c.loading = append(c.loading, name)
+	defer func() { c.loading = c.loading[:len(c.loading)-1] }()
+	mod := c.module(name, file, string(text))
+	c.checked[name] = mod
+	return mod, nil
+}
+
+// program is exp, in which the definitions checked are bound to their
+// numbered names, with the definitions it uses, and those they use, let bound
+// around it in the order they were made
+func (c *checker) program(exp Expression) Expression {
+	used := freeVariables(exp)
+	for i := len(c.definitions) - 1; i >= 0; i-- {
+		d := c.definitions[i]
+		if !used[d.name.identifier] {
+			continue
+		}
+		for name := range freeVariables(d.value) {
+			used[name] = true
+		}
+		exp = binding{d.name, d.value, exp}
+	}
+	return exp
+}
@@ -0,0 +1,48 @@
+package lambda
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"bool": "module bool\n'true = 𝞴x y.x\n'false = 𝞴x y.y\n'bad = (𝞴x.x\n",
+		"main": `import bool
+import missing
+'id = 𝞴x.x
+'not = 𝞴b.b bool.false bool.ture
+  'k = 𝞴id.let y = id in 𝞴z.𝞴z.z
+assert not bool.true == nto bool.true
+'omega = (𝞴x.x x) (𝞴x.x x)
+not bool.true
+`,
+	})
+	problems, err := Check(filepath.Join(dir, "main.lam"), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bool, main := filepath.Join(dir, "bool.lam"), filepath.Join(dir, "main.lam")
+	expected := []Problem{
+		{bool, 4, 13, "expect rightParen, but got eof", false},
+		{main, 2, 1, "module missing not found in " + dir, false},
+		{main, 4, 24, "unbound variable bool.ture, did you mean bool.true?", false},
+		{main, 5, 9, "id shadows the definition of id", true},
+		// where the binder shadowed is, in the file rather than the line
+		{main, 5, 30, "z shadows the z bound at 5:27", true},
+		{main, 5, 16, "y is never used", true},
+		{main, 6, 25, "unbound variable nto", false},
+		{main, 8, 1, "a module only defines names with ', but got not bool.true", false},
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %v, but got %v", expected, problems)
+	}
+	for i := range expected {
+		if problems[i] != expected[i] {
+			t.Errorf("expected %v, but got %v", expected[i], problems[i])
+		}
+	}
+	if !Failed(problems) || Failed(problems[3:6]) {
+		t.Errorf("expected only errors to fail")
+	}
+}
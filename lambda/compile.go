@@ -0,0 +1,289 @@
+package lambda
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// lambda compile turns a module into an artifact running one of its
+// definitions, the entry, with the definitions it uses: bytecode for lambda
+// exec, a term of the combinators S, K and I, or a Go program to go build.
+// The module is checked, not evaluated, so compiling takes no longer however
+// long the program runs. Every artifact says what it was compiled from and
+// how, so it can be compiled again and compared.
+
+// artifactVersion is the version of the artifacts compiled, which Exec only
+// runs if it is the same
+const artifactVersion = 1
+
+// ErrNotArtifact is returned when running what Compile didn't write
+var ErrNotArtifact = errors.New("not a compiled program of this version")
+
+// CompileOptions say what to compile a module to
+type CompileOptions struct {
+	// Target is bytecode, ski or go, bytecode if empty
+	Target string `json:"target"`
+	// Entry is the definition to run, main if empty
+	Entry string `json:"entry"`
+}
+
+// ArtifactInfo is kept in an artifact, to tell what it was compiled from and
+// how
+type ArtifactInfo struct {
+	Version int            `json:"version"`
+	Options CompileOptions `json:"options"`
+	// Source is the content address of the module's text
+	Source string `json:"source"`
+	// Program is the content address of the canonical serialization of the
+	// entry with the definitions it uses, so compiling a module reformatted,
+	// or one importing modules changed, can be told from compiling the same
+	// program
+	Program string `json:"program"`
+}
+
+// compileTargets write the artifact of a program, by the names of the targets
+var compileTargets = map[string]func(program Expression, info ArtifactInfo) ([]byte, error){
+	"bytecode": bytecodeArtifact,
+	"ski":      skiArtifact,
+	"go":       goArtifact,
+}
+
+// Compile compiles the module in file, whose imports are looked for along
+// path, or the default path when it is nil. A module with errors, as Check
+// finds them, fails to compile with every one.
+func Compile(file string, path []string, options CompileOptions) ([]byte, error) {
+	if options.Target == "" {
+		options.Target = "bytecode"
+	}
+	if options.Entry == "" {
+		options.Entry = "main"
+	}
+	target, ok := compileTargets[options.Target]
+	if !ok {
+		names := []string{}
+		for name := range compileTargets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown target %v, expected one of %v", options.Target, strings.Join(names, ", "))
+	}
+	c, mod, text, err := checkModule(file, path)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := mod.scope.find(variable{options.Entry})
+	if !ok {
+		return nil, fmt.Errorf("%v defines no %v to run", file, options.Entry)
+	}
+	program := c.program(entry)
+	info := ArtifactInfo{
+		Version: artifactVersion,
+		Options: options,
+		Source:  ContentAddress(text),
+		Program: ContentAddress(CanonicalBytes(program)),
+	}
+	return target(program, info)
+}
+
+// checkModule checks the module in file, as Check does, failing with every
+// error it finds, and returns its text
+func checkModule(file string, path []string) (*checker, module, []byte, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, module{}, nil, err
+	}
+	c := newChecker(path)
+	mod := c.module("", file, string(text))
+	errs := []string{}
+	for _, p := range c.problems {
+		if !p.Warning {
+			errs = append(errs, p.String())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, module{}, nil, fmt.Errorf("%v", strings.Join(errs, "\n"))
+	}
+	return c, mod, text, nil
+}
+
+func bytecodeArtifact(program Expression, info ArtifactInfo) ([]byte, error) {
+	p, err := compileBytecode(program, info)
+	if err != nil {
+		return nil, err
+	}
+	return p.encode()
+}
+
+// skiHeader starts the comments an SKI artifact begins with, the second
+// being its info as JSON
+const skiHeader = "-- lambda ski artifact\n"
+
+// skiCombinators are the names and terms of the combinators an SKI artifact
+// is a term of
+var skiCombinators = [][2]string{
+	{"S", "𝞴x y z.x z (y z)"},
+	{"K", "𝞴x y.x"},
+	{"I", "𝞴x.x"},
+}
+
+// skiArtifact writes program as a term of S, K and I with its free
+// variables, which mustn't be the combinators' names
+func skiArtifact(program Expression, info ArtifactInfo) ([]byte, error) {
+	free := freeVariables(program)
+	for _, c := range skiCombinators {
+		if free[c[0]] {
+			return nil, fmt.Errorf("free variable %v would be taken for the combinator", c[0])
+		}
+	}
+	ski, err := toSKI(Canonicalize(program))
+	if err != nil {
+		return nil, err
+	}
+	header, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("%v-- %s\n%v\n", skiHeader, header, format(ski))), nil
+}
+
+// toSKI removes the abstractions of exp by bracket abstraction, whose
+// binders mustn't be named S, K or I, as Canonicalize never names them
+func toSKI(exp Expression) (Expression, error) {
+	switch exp := exp.(type) {
+	case binding:
+		return toSKI(application{abstraction{exp.name, exp.body}, exp.value})
+	case replBinding:
+		return toSKI(exp.value)
+	case abstraction:
+		body, err := toSKI(exp.expr)
+		if err != nil {
+			return nil, err
+		}
+		return bracket(exp.param.identifier, body), nil
+	case application:
+		left, err := toSKI(exp.left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := toSKI(exp.right)
+		return application{left, right}, err
+	case strictApplication:
+		return toSKI(exp.application)
+	case variable:
+		return exp, nil
+	case freeVariable:
+		return variable{exp.identifier}, nil
+	default:
+		return nil, fmt.Errorf("can't compile %v", format(exp))
+	}
+}
+
+// bracket is 𝞴x.exp as combinators, for exp without abstractions
+func bracket(x string, exp Expression) Expression {
+	if !freeVariables(exp)[x] {
+		return application{variable{"K"}, exp}
+	}
+	app, ok := exp.(application)
+	if !ok {
+		return variable{"I"}
+	}
+	// 𝞴x.f x is f, when x isn't free in f
+	if v, ok := app.right.(variable); ok && v.identifier == x && !freeVariables(app.left)[x] {
+		return app.left
+	}
+	return application{application{variable{"S"}, bracket(x, app.left)}, bracket(x, app.right)}
+}
+
+// goArtifact writes a Go program running program, compiled to bytecode. It
+// imports this package, so it builds where this module can be found.
+func goArtifact(program Expression, info ArtifactInfo) ([]byte, error) {
+	code, err := bytecodeArtifact(program, info)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by lambda compile; DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "// Entry: %v\n// Source: %v\n// Program: %v\n\n", info.Options.Entry, info.Source, info.Program)
+	fmt.Fprintf(&b, `package main
+
+import (
+	"fmt"
+	"os"
+
+	"june/lambda/lambda"
+)
+
+// artifact is the program compiled to bytecode
+var artifact = []byte(%q)
+
+func main() {
+	value, err := lambda.Exec(artifact, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(lambda.Format(value))
+}
+`, code)
+	return b.Bytes(), nil
+}
+
+// ReadArtifactInfo reads what a bytecode or SKI artifact was compiled from
+func ReadArtifactInfo(artifact []byte) (ArtifactInfo, error) {
+	if bytes.HasPrefix(artifact, bytecodeMagic) {
+		p, err := decodeBytecode(artifact)
+		if err != nil {
+			return ArtifactInfo{}, err
+		}
+		return p.info, nil
+	}
+	info, _, err := readSKI(artifact)
+	return info, err
+}
+
+// readSKI reads an SKI artifact's info and term
+func readSKI(artifact []byte) (ArtifactInfo, Expression, error) {
+	var info ArtifactInfo
+	if !bytes.HasPrefix(artifact, []byte(skiHeader)) {
+		return info, nil, ErrNotArtifact
+	}
+	header, term, _ := strings.Cut(string(artifact[len(skiHeader):]), "\n")
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(header, "-- ")), &info); err != nil || info.Version != artifactVersion {
+		return info, nil, ErrNotArtifact
+	}
+	exp, err := parse(strings.TrimSpace(term))
+	return info, exp, err
+}
+
+// Exec runs a bytecode or SKI artifact, returning the normal form of its
+// entry. It fails with ErrStepLimit after maxSteps applications of
+// abstractions, 0 meaning no limit.
+func Exec(artifact []byte, maxSteps int) (Expression, error) {
+	if bytes.HasPrefix(artifact, bytecodeMagic) {
+		p, err := decodeBytecode(artifact)
+		if err != nil {
+			return nil, err
+		}
+		value, _, err := p.execute(maxSteps)
+		return value, err
+	}
+	info, exp, err := readSKI(artifact)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(skiCombinators) - 1; i >= 0; i-- {
+		combinator, _ := parse(skiCombinators[i][1])
+		exp = binding{variable{skiCombinators[i][0]}, combinator, exp}
+	}
+	p, err := compileBytecode(exp, info)
+	if err != nil {
+		return nil, err
+	}
+	value, _, err := p.execute(maxSteps)
+	return value, err
+}
@@ -0,0 +1,80 @@
+package lambda
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"bool": "module bool\n'true = 𝞴x y.x\n'false = 𝞴x y.y\n",
+		"prog": `import bool
+'omega = (𝞴x.x x) (𝞴x.x x)
+'not = 𝞴b.b bool.false bool.true
+-- a definition that never terminates is compiled, not evaluated
+'main = (𝞴u.not (not bool.false)) omega
+'loop = omega
+`,
+		"broken": "'main = nope\n",
+	})
+	file := filepath.Join(dir, "prog.lam")
+	for _, target := range []string{"bytecode", "ski"} {
+		artifact, err := Compile(file, []string{dir}, CompileOptions{Target: target})
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := Compile(file, []string{dir}, CompileOptions{Target: target})
+		if !bytes.Equal(artifact, again) {
+			t.Errorf("expected compiling %v again to give the same artifact", target)
+		}
+		value, err := Exec(artifact, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format(value) != "𝞴a b.b" {
+			t.Errorf("expected %v to run to false, but got %v", target, format(value))
+		}
+		info, err := ReadArtifactInfo(artifact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, _ := os.ReadFile(file)
+		if info.Options != (CompileOptions{target, "main"}) || info.Source != ContentAddress(text) || !strings.HasPrefix(info.Program, "sha256:") {
+			t.Errorf("expected the info of %v, but got %+v", target, info)
+		}
+	}
+
+	artifact, _ := Compile(file, []string{dir}, CompileOptions{Entry: "loop"})
+	if _, err := Exec(artifact, 100); err != ErrStepLimit {
+		t.Errorf("expected a step limit, but got %v", err)
+	}
+	source, err := Compile(file, []string{dir}, CompileOptions{Target: "go"})
+	if err != nil || !bytes.Contains(source, []byte("package main")) || !bytes.Contains(source, []byte("lambda.Exec(artifact, 0)")) {
+		t.Errorf("expected a Go program, but got %s, %v", source, err)
+	}
+
+	if _, err := Compile(file, []string{dir}, CompileOptions{Entry: "missing"}); err == nil || err.Error() != file+" defines no missing to run" {
+		t.Errorf("expected a missing entry, but got %v", err)
+	}
+	if _, err := Compile(file, []string{dir}, CompileOptions{Target: "wasm"}); err == nil || err.Error() != "unknown target wasm, expected one of bytecode, go, ski" {
+		t.Errorf("expected an unknown target, but got %v", err)
+	}
+	broken := filepath.Join(dir, "broken.lam")
+	if _, err := Compile(broken, []string{dir}, CompileOptions{}); err == nil || err.Error() != broken+":1:9: unbound variable nope" {
+		t.Errorf("expected the module's errors, but got %v", err)
+	}
+
+	artifact, _ = Compile(file, []string{dir}, CompileOptions{})
+	for _, corrupt := range [][]byte{artifact[:len(artifact)-1], append(append([]byte{}, artifact...), 0), []byte("main")} {
+		if _, err := Exec(corrupt, 0); err == nil {
+			t.Errorf("expected %q to fail", corrupt)
+		}
+	}
+	if _, err := Exec([]byte("main"), 0); !errors.Is(err, ErrNotArtifact) {
+		t.Errorf("expected not an artifact, but got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package lambda
+
+// Fork is a pair of one-step reducts of a term, contracting the redexes at
+// LeftPath and RightPath, that have no common reduct within the bound
+type Fork struct {
+	LeftPath  string
+	Left      Expression
+	RightPath string
+	Right     Expression
+}
+
+// reachable lists the terms exp reaches within bound steps, choosing any redex
+// at each, by their canonical form
+func reachable(exp Expression, bound int) (map[string]bool, error) {
+	seen := map[string]bool{format(Canonicalize(exp)): true}
+	frontier := []Expression{exp}
+	for depth := 0; depth < bound && len(frontier) > 0; depth++ {
+		next := []Expression{}
+		for _, term := range frontier {
+			for _, r := range reducts(term) {
+				k := format(Canonicalize(r.term))
+				if seen[k] {
+					continue
+				}
+				if len(seen) == pathTermLimit {
+					return seen, ErrTooManyTerms
+				}
+				seen[k] = true
+				next = append(next, r.term)
+			}
+		}
+		frontier = next
+	}
+	return seen, nil
+}
+
+// CheckConfluence tests the Church-Rosser property on e: every two of its
+// one-step reducts should reduce to a common term. It returns the first pair
+// that doesn't within bound steps from each side, or nil when all pairs join.
+func CheckConfluence(e Expression, bound int) (*Fork, error) {
+	next := reducts(e)
+	reached := make([]map[string]bool, len(next))
+	for i, r := range next {
+		terms, err := reachable(r.term, bound)
+		if err != nil {
+			return nil, err
+		}
+		reached[i] = terms
+	}
+	for i := range next {
+		for j := i + 1; j < len(next); j++ {
+			if !meet(reached[i], reached[j]) {
+				return &Fork{next[i].c.path, next[i].term, next[j].c.path, next[j].term}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func meet(a, b map[string]bool) bool {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	for k := range a {
+		if b[k] {
+			return true
+		}
+	}
+	return false
+}
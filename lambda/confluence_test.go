@@ -0,0 +1,29 @@
+package lambda
+
+import "testing"
+
+func TestCheckConfluence(t *testing.T) {
+	confluenceCases := []struct {
+		program string
+		bound   int
+		joins   bool
+	}{
+		{"(𝞴x.x x) ((𝞴y.y) z)", 2, true},
+		{"(𝞴x y.y) ((𝞴x.x x) (𝞴x.x x))", 1, true},
+		// contracting the copies of the inner redex takes three steps
+		{"(𝞴x.x x x) ((𝞴y.y) z)", 1, false},
+		{"(𝞴x.x x x) ((𝞴y.y) z)", 3, true},
+	}
+	for _, tt := range confluenceCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			fork, err := CheckConfluence(exp, tt.bound)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (fork == nil) != tt.joins {
+				t.Errorf("expected joins %v, but got %+v", tt.joins, fork)
+			}
+		})
+	}
+}
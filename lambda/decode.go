@@ -0,0 +1,37 @@
+package lambda
+
+import "strconv"
+
+// decode reads a normal form back as the Church encoded value it looks like,
+// 𝞴f x.f (f x) is 2 and 𝞴x y.x is true, reporting false when it isn't one
+func decode(exp Expression) (string, bool) {
+	outer, ok := exp.(abstraction)
+	if !ok {
+		return "", false
+	}
+	inner, ok := outer.expr.(abstraction)
+	if !ok || inner.param == outer.param {
+		return "", false
+	}
+	if inner.expr == Expression(outer.param) {
+		return "true", true
+	}
+	// count applications of f down to x
+	n := 0
+	body := inner.expr
+	for {
+		app, ok := body.(application)
+		if !ok || app.left != Expression(outer.param) {
+			break
+		}
+		n += 1
+		body = app.right
+	}
+	if body != Expression(inner.param) {
+		return "", false
+	}
+	if n == 0 {
+		return "0 or false", true
+	}
+	return strconv.Itoa(n), true
+}
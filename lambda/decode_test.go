@@ -0,0 +1,25 @@
+package lambda
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	decodeCases := []struct {
+		program string
+		decoded string
+	}{
+		{"𝞴f x.f (f (f x))", "3"},
+		{"𝞴x y.x", "true"},
+		{"𝞴x y.y", "0 or false"},
+		{"𝞴x x.x", ""},
+		{"𝞴f x.f x x", ""},
+		{"y", ""},
+	}
+	for _, tt := range decodeCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			if res, _ := decode(exp); res != tt.decoded {
+				t.Errorf("expected %v, but got %v", tt.decoded, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,751 @@
+package lambda
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The dependently typed mode is a small core apart from the untyped calculus:
+// Π types, where the type of the result may mention the argument, a hierarchy
+// of cumulative universes Type : Type1 : Type2 ..., and abstractions that may annotate
+// their parameters, as in 𝞴(A : Type) (x : A).x. A → B is a Π type whose
+// result doesn't mention its argument. Two types are equal when they have the
+// same normal form, so checking runs the normalizer on types.
+
+// DTerm is a term of the dependently typed mode, types included
+type DTerm interface {
+	isDTerm()
+	String() string
+}
+
+type dVar struct {
+	name string
+}
+
+type dUniverse struct {
+	level int
+}
+
+// dPi is Π(name : domain).codomain, name being _ for A → B
+type dPi struct {
+	name     string
+	domain   DTerm
+	codomain DTerm
+}
+
+// dLam is an abstraction, domain nil when its parameter isn't annotated
+type dLam struct {
+	name   string
+	domain DTerm
+	body   DTerm
+}
+
+type dApp struct {
+	fn, arg DTerm
+}
+
+// dAnn is term annotated with typ, as in (term : typ)
+type dAnn struct {
+	term, typ DTerm
+}
+
+// dLet binds name to value in body, typ nil when it isn't annotated
+type dLet struct {
+	name        string
+	typ         DTerm
+	value, body DTerm
+}
+
+func (dVar) isDTerm()      {}
+func (dUniverse) isDTerm() {}
+func (dPi) isDTerm()       {}
+func (dLam) isDTerm()      {}
+func (dApp) isDTerm()      {}
+func (dAnn) isDTerm()      {}
+func (dLet) isDTerm()      {}
+
+func (t dVar) String() string { return t.name }
+func (t dUniverse) String() string {
+	if t.level == 0 {
+		return "Type"
+	}
+	return fmt.Sprintf("Type%v", t.level)
+}
+func (t dPi) String() string {
+	if !dFree(t.codomain)[t.name] {
+		domain := t.domain.String()
+		switch t.domain.(type) {
+		case dPi, dLam, dLet:
+			domain = "(" + domain + ")"
+		}
+		return fmt.Sprintf("%v → %v", domain, t.codomain)
+	}
+	return fmt.Sprintf("Π(%v : %v).%v", t.name, t.domain, t.codomain)
+}
+func (t dLam) String() string {
+	if t.domain == nil {
+		return fmt.Sprintf("𝞴%v.%v", t.name, t.body)
+	}
+	return fmt.Sprintf("𝞴(%v : %v).%v", t.name, t.domain, t.body)
+}
+func (t dApp) String() string {
+	fn := t.fn.String()
+	switch t.fn.(type) {
+	case dPi, dLam, dLet:
+		fn = "(" + fn + ")"
+	}
+	arg := t.arg.String()
+	switch t.arg.(type) {
+	case dVar, dUniverse, dAnn, dHole:
+	default:
+		arg = "(" + arg + ")"
+	}
+	return fn + " " + arg
+}
+func (t dAnn) String() string { return fmt.Sprintf("(%v : %v)", t.term, t.typ) }
+func (t dLet) String() string {
+	if t.typ == nil {
+		return fmt.Sprintf("let %v = %v in %v", t.name, t.value, t.body)
+	}
+	return fmt.Sprintf("let %v : %v = %v in %v", t.name, t.typ, t.value, t.body)
+}
+
+// dParser parses the dependently typed mode from the tokens of the untyped
+// calculus, whitespace dropped since application is juxtaposition of atoms
+type dParser struct {
+	Parser
+	// holes counts the holes parsed, numbering each
+	holes int
+}
+
+func newDParser(program string) (*dParser, error) {
+	scanner := Scanner{Program: []rune(program)}
+	tokens, err := scanner.Scan()
+	if err != nil {
+		return nil, err
+	}
+	p := &dParser{}
+	for _, t := range tokens {
+		if t.tokenType != whiteSpace {
+			p.Tokens = append(p.Tokens, t)
+		}
+	}
+	return p, nil
+}
+
+func (p *dParser) run(parse func() DTerm) (t DTerm, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = syntaxError{p.offset(), fmt.Sprintf("%v", r)}
+		}
+	}()
+	t = parse()
+	if !p.isEnd() {
+		panic(fmt.Sprintf("unexpected %v %v", p.current().tokenType, p.current().lexeme))
+	}
+	return t, nil
+}
+
+// ParseDependent parses a term of the dependently typed mode
+func ParseDependent(program string) (DTerm, error) {
+	p, err := newDParser(program)
+	if err != nil {
+		return nil, err
+	}
+	return p.run(p.term)
+}
+
+// parseDependentLine parses a line of the dependently typed mode: a term, or
+// a definition 'name = term or 'name : type = term, name "" for a term
+func parseDependentLine(program string) (string, DTerm, error) {
+	p, err := newDParser(program)
+	if err != nil {
+		return "", nil, err
+	}
+	name := ""
+	t, err := p.run(func() DTerm {
+		if p.isEnd() || p.current().tokenType != quote {
+			return p.term()
+		}
+		p.consume(quote)
+		name = p.name()
+		var typ DTerm
+		if p.check(colon) {
+			p.consume(colon)
+			typ = p.term()
+		}
+		p.consume(equal)
+		value := p.term()
+		if typ != nil {
+			return dAnn{value, typ}
+		}
+		return value
+	})
+	return name, t, err
+}
+
+func (p *dParser) check(tt tokenType) bool {
+	return !p.isEnd() && p.current().tokenType == tt
+}
+
+func (p *dParser) name() string {
+	name := p.current().lexeme
+	p.consume(identifier)
+	return name
+}
+
+func (p *dParser) term() DTerm {
+	switch {
+	case p.check(let):
+		p.consume(let)
+		t := dLet{name: p.name()}
+		if p.check(colon) {
+			p.consume(colon)
+			t.typ = p.term()
+		}
+		p.consume(equal)
+		t.value = p.term()
+		p.consume(in)
+		t.body = p.term()
+		return t
+	case p.check(lambda):
+		p.consume(lambda)
+		params := p.binders(false)
+		p.consume(dot)
+		body := p.term()
+		for i := len(params) - 1; i >= 0; i-- {
+			body = dLam{params[i].name, params[i].domain, body}
+		}
+		return body
+	case p.check(pi):
+		p.consume(pi)
+		params := p.binders(true)
+		p.consume(dot)
+		body := p.term()
+		for i := len(params) - 1; i >= 0; i-- {
+			body = dPi{params[i].name, params[i].domain, body}
+		}
+		return body
+	}
+	t := p.application()
+	if p.check(arrow) {
+		p.consume(arrow)
+		return dPi{"_", t, p.term()}
+	}
+	return t
+}
+
+// binders parses x, or (x y : A), any number of times, before a dot
+func (p *dParser) binders(annotated bool) []dLam {
+	params := []dLam{}
+	for !p.check(dot) {
+		if !annotated && p.check(identifier) {
+			params = append(params, dLam{name: p.name()})
+			continue
+		}
+		p.consume(leftParen)
+		names := []string{p.name()}
+		for p.check(identifier) {
+			names = append(names, p.name())
+		}
+		p.consume(colon)
+		domain := p.term()
+		p.consume(rightParen)
+		for _, name := range names {
+			params = append(params, dLam{name: name, domain: domain})
+		}
+	}
+	if len(params) == 0 {
+		panic("expect a parameter")
+	}
+	return params
+}
+
+func (p *dParser) application() DTerm {
+	t := p.atom()
+	for p.check(identifier) || p.check(leftParen) || p.check(underscore) {
+		t = dApp{t, p.atom()}
+	}
+	return t
+}
+
+func (p *dParser) atom() DTerm {
+	if p.check(underscore) {
+		p.consume(underscore)
+		p.holes += 1
+		return dHole{p.holes - 1}
+	}
+	if p.check(identifier) {
+		name := p.name()
+		if level, ok := universeLevel(name); ok {
+			return dUniverse{level}
+		}
+		return dVar{name}
+	}
+	p.consume(leftParen)
+	t := p.term()
+	if p.check(colon) {
+		p.consume(colon)
+		t = dAnn{t, p.term()}
+	}
+	p.consume(rightParen)
+	return t
+}
+
+// universeLevel reads Type as 0 and Typen as n
+func universeLevel(name string) (int, bool) {
+	if name == "Type" {
+		return 0, true
+	}
+	digits := strings.TrimPrefix(name, "Type")
+	if digits == name || digits == "" || digits[0] == '0' {
+		return 0, false
+	}
+	level, err := strconv.Atoi(digits)
+	return level, err == nil
+}
+
+// dFree collects the names free in t
+func dFree(t DTerm) map[string]bool {
+	free := map[string]bool{}
+	var walk func(t DTerm, bound map[string]int)
+	binder := func(name string, body DTerm, bound map[string]int) {
+		bound[name] += 1
+		walk(body, bound)
+		bound[name] -= 1
+	}
+	walk = func(t DTerm, bound map[string]int) {
+		switch t := t.(type) {
+		case dVar:
+			if bound[t.name] == 0 {
+				free[t.name] = true
+			}
+		case dPi:
+			walk(t.domain, bound)
+			binder(t.name, t.codomain, bound)
+		case dLam:
+			if t.domain != nil {
+				walk(t.domain, bound)
+			}
+			binder(t.name, t.body, bound)
+		case dApp:
+			walk(t.fn, bound)
+			walk(t.arg, bound)
+		case dAnn:
+			walk(t.term, bound)
+			walk(t.typ, bound)
+		case dLet:
+			if t.typ != nil {
+				walk(t.typ, bound)
+			}
+			walk(t.value, bound)
+			binder(t.name, t.body, bound)
+		}
+	}
+	walk(t, map[string]int{})
+	return free
+}
+
+// dNames collects every name in t
+func dNames(t DTerm, names map[string]bool) {
+	switch t := t.(type) {
+	case dVar:
+		names[t.name] = true
+	case dPi:
+		names[t.name] = true
+		dNames(t.domain, names)
+		dNames(t.codomain, names)
+	case dLam:
+		names[t.name] = true
+		if t.domain != nil {
+			dNames(t.domain, names)
+		}
+		dNames(t.body, names)
+	case dApp:
+		dNames(t.fn, names)
+		dNames(t.arg, names)
+	case dAnn:
+		dNames(t.term, names)
+		dNames(t.typ, names)
+	case dLet:
+		names[t.name] = true
+		if t.typ != nil {
+			dNames(t.typ, names)
+		}
+		dNames(t.value, names)
+		dNames(t.body, names)
+	}
+}
+
+// dSubstitute replaces the free occurrences of name in t with value,
+// renaming binders that would capture its free variables
+func dSubstitute(t DTerm, name string, value DTerm) DTerm {
+	free := dFree(value)
+	var subst func(t DTerm) DTerm
+	// binder substitutes beneath a binder of x in body
+	binder := func(x string, body DTerm) (string, DTerm) {
+		if x == name || !dFree(body)[name] {
+			return x, body
+		}
+		if free[x] {
+			avoid := map[string]bool{name: true}
+			for n := range free {
+				avoid[n] = true
+			}
+			dNames(body, avoid)
+			renamed := fresh(x, avoid)
+			body = dSubstitute(body, x, dVar{renamed})
+			x = renamed
+		}
+		return x, subst(body)
+	}
+	maybe := func(t DTerm) DTerm {
+		if t == nil {
+			return nil
+		}
+		return subst(t)
+	}
+	subst = func(t DTerm) DTerm {
+		switch t := t.(type) {
+		case dVar:
+			if t.name == name {
+				return value
+			}
+			return t
+		case dPi:
+			x, codomain := binder(t.name, t.codomain)
+			return dPi{x, subst(t.domain), codomain}
+		case dLam:
+			x, body := binder(t.name, t.body)
+			return dLam{x, maybe(t.domain), body}
+		case dApp:
+			return dApp{subst(t.fn), subst(t.arg)}
+		case dAnn:
+			return dAnn{subst(t.term), subst(t.typ)}
+		case dLet:
+			x, body := binder(t.name, t.body)
+			return dLet{x, maybe(t.typ), subst(t.value), body}
+		default:
+			return t
+		}
+	}
+	return subst(t)
+}
+
+// dEntry is a name in scope with its type, and its value when it is defined
+// rather than bound
+type dEntry struct {
+	name  string
+	typ   DTerm
+	value DTerm
+}
+
+// typeError is a term that doesn't have the type it should
+type typeError struct {
+	message string
+}
+
+func (e typeError) Error() string {
+	return e.message
+}
+
+// dChecker checks and normalizes terms in a context, innermost entry last,
+// failing with ErrStepLimit after maxSteps reductions
+type dChecker struct {
+	context  []dEntry
+	maxSteps int
+	steps    int
+	// holes collects the holes checked, by number, when it isn't nil, the
+	// context from scope on being what they are beneath
+	holes map[int]Hole
+	scope int
+}
+
+func (c *dChecker) lookup(name string) (dEntry, bool) {
+	for i := len(c.context) - 1; i >= 0; i-- {
+		if c.context[i].name == name {
+			return c.context[i], true
+		}
+	}
+	return dEntry{}, false
+}
+
+// enter brings a binder of name with type typ into scope for body, renaming
+// it first if it would shadow a name already in scope, whose type may refer
+// to it. The caller leaves the scope by restoring the context.
+func (c *dChecker) enter(name string, typ, value, body DTerm) (string, DTerm) {
+	// _ can't be referred to, so it never needs renaming
+	if _, ok := c.lookup(name); ok && name != "_" {
+		avoid := map[string]bool{}
+		for _, e := range c.context {
+			avoid[e.name] = true
+		}
+		dNames(body, avoid)
+		renamed := fresh(name, avoid)
+		body = dSubstitute(body, name, dVar{renamed})
+		name = renamed
+	}
+	c.context = append(c.context, dEntry{name, typ, value})
+	return name, body
+}
+
+func (c *dChecker) step() {
+	c.steps += 1
+	if c.maxSteps > 0 && c.steps > c.maxSteps {
+		panic(evalError{ErrStepLimit})
+	}
+}
+
+// whnf reduces t until its head is neither a redex nor a definition
+func (c *dChecker) whnf(t DTerm) DTerm {
+	for {
+		switch u := t.(type) {
+		case dVar:
+			e, ok := c.lookup(u.name)
+			if !ok || e.value == nil {
+				return t
+			}
+			t = e.value
+		case dApp:
+			fn := c.whnf(u.fn)
+			lam, ok := fn.(dLam)
+			if !ok {
+				return dApp{fn, u.arg}
+			}
+			t = dSubstitute(lam.body, lam.name, u.arg)
+		case dAnn:
+			t = u.term
+		case dLet:
+			t = dSubstitute(u.body, u.name, u.value)
+		default:
+			return t
+		}
+		c.step()
+	}
+}
+
+// normalize reduces t to normal form, dropping annotations on parameters
+func (c *dChecker) normalize(t DTerm) DTerm {
+	saved := c.context
+	defer func() { c.context = saved }()
+	switch t := c.whnf(t).(type) {
+	case dPi:
+		domain := c.normalize(t.domain)
+		name, codomain := c.enter(t.name, domain, nil, t.codomain)
+		return dPi{name, domain, c.normalize(codomain)}
+	case dLam:
+		name, body := c.enter(t.name, nil, nil, t.body)
+		return dLam{name, nil, c.normalize(body)}
+	case dApp:
+		return dApp{c.normalize(t.fn), c.normalize(t.arg)}
+	default:
+		return t
+	}
+}
+
+// convertible reports whether a and b have the same normal form
+func (c *dChecker) convertible(a, b DTerm) bool {
+	return dAlphaEqual(c.normalize(a), c.normalize(b), map[string]int{}, map[string]int{}, 0)
+}
+
+// dAlphaEqual compares terms up to renaming of bound variables, left and
+// right giving the depth of the binder each side's bound names refer to
+func dAlphaEqual(a, b DTerm, left, right map[string]int, depth int) bool {
+	binder := func(x, y string, ab, bb DTerm) bool {
+		return dAlphaEqual(ab, bb, bindLevel(left, x, depth), bindLevel(right, y, depth), depth+1)
+	}
+	switch a := a.(type) {
+	case dVar:
+		b, ok := b.(dVar)
+		if !ok {
+			return false
+		}
+		x, xBound := left[a.name]
+		y, yBound := right[b.name]
+		if xBound || yBound {
+			return xBound && yBound && x == y
+		}
+		return a.name == b.name
+	case dUniverse:
+		return a == b
+	case dPi:
+		b, ok := b.(dPi)
+		return ok && dAlphaEqual(a.domain, b.domain, left, right, depth) && binder(a.name, b.name, a.codomain, b.codomain)
+	case dLam:
+		b, ok := b.(dLam)
+		return ok && binder(a.name, b.name, a.body, b.body)
+	case dApp:
+		b, ok := b.(dApp)
+		return ok && dAlphaEqual(a.fn, b.fn, left, right, depth) && dAlphaEqual(a.arg, b.arg, left, right, depth)
+	default:
+		return false
+	}
+}
+
+// universe infers the type of t, which must be a universe, returning its level
+func (c *dChecker) universe(t DTerm) int {
+	typ := c.whnf(c.infer(t))
+	u, ok := typ.(dUniverse)
+	if !ok {
+		panic(typeError{fmt.Sprintf("%v is not a type, its type is %v", t, c.normalize(typ))})
+	}
+	return u.level
+}
+
+// infer finds the type of t
+func (c *dChecker) infer(t DTerm) DTerm {
+	saved := c.context
+	defer func() { c.context = saved }()
+	switch t := t.(type) {
+	case dVar:
+		e, ok := c.lookup(t.name)
+		if !ok {
+			panic(typeError{fmt.Sprintf("unbound variable %v", t.name)})
+		}
+		return e.typ
+	case dUniverse:
+		return dUniverse{t.level + 1}
+	case dPi:
+		i := c.universe(t.domain)
+		_, codomain := c.enter(t.name, t.domain, nil, t.codomain)
+		return dUniverse{maxInt(i, c.universe(codomain))}
+	case dLam:
+		if t.domain == nil {
+			panic(typeError{fmt.Sprintf("can't infer the type of %v, annotate its parameter", t)})
+		}
+		c.universe(t.domain)
+		name, body := c.enter(t.name, t.domain, nil, t.body)
+		return dPi{name, t.domain, c.infer(body)}
+	case dApp:
+		fnType := c.whnf(c.infer(t.fn))
+		pi, ok := fnType.(dPi)
+		if !ok {
+			panic(typeError{fmt.Sprintf("%v is not a function, its type is %v", t.fn, c.normalize(fnType))})
+		}
+		c.check(t.arg, pi.domain)
+		return dSubstitute(pi.codomain, pi.name, t.arg)
+	case dAnn:
+		c.universe(t.typ)
+		c.check(t.term, t.typ)
+		return t.typ
+	case dLet:
+		typ := c.letType(t)
+		name, body := c.enter(t.name, typ, t.value, t.body)
+		return dSubstitute(c.infer(body), name, t.value)
+	case dHole:
+		panic(typeError{"can't infer the type of _, annotate it as in (_ : A)"})
+	default:
+		panic(fmt.Sprintf("infer %T", t))
+	}
+}
+
+// letType checks the value of a let against its annotation, or infers it
+func (c *dChecker) letType(t dLet) DTerm {
+	if t.typ == nil {
+		return c.infer(t.value)
+	}
+	c.universe(t.typ)
+	c.check(t.value, t.typ)
+	return t.typ
+}
+
+// check makes sure t has type typ
+func (c *dChecker) check(t DTerm, typ DTerm) {
+	saved := c.context
+	defer func() { c.context = saved }()
+	switch t := t.(type) {
+	case dLam:
+		pi, ok := c.whnf(typ).(dPi)
+		if !ok {
+			if t.domain == nil {
+				panic(typeError{fmt.Sprintf("%v is a function, but expected %v", t, c.normalize(typ))})
+			}
+			break
+		}
+		if t.domain != nil {
+			c.universe(t.domain)
+			if !c.convertible(t.domain, pi.domain) {
+				panic(typeError{fmt.Sprintf("parameter %v has type %v, but expected %v", t.name, c.normalize(t.domain), c.normalize(pi.domain))})
+			}
+		}
+		name, body := c.enter(t.name, pi.domain, nil, t.body)
+		c.check(body, dSubstitute(pi.codomain, pi.name, dVar{name}))
+		return
+	case dLet:
+		letType := c.letType(t)
+		_, body := c.enter(t.name, letType, t.value, t.body)
+		c.check(body, typ)
+		return
+	case dHole:
+		c.fill(t, typ)
+		return
+	}
+	actual := c.infer(t)
+	// universes are cumulative, a type in one being a type in those above it
+	if u, ok := c.whnf(actual).(dUniverse); ok {
+		if v, ok := c.whnf(typ).(dUniverse); ok && u.level <= v.level {
+			return
+		}
+	}
+	if !c.convertible(actual, typ) {
+		panic(typeError{fmt.Sprintf("%v has type %v, but expected %v", t, c.normalize(actual), c.normalize(typ))})
+	}
+}
+
+// run runs f, turning type errors and the step limit into errors
+func (c *dChecker) run(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch e := r.(type) {
+			case typeError:
+				err = e
+			case evalError:
+				err = e.err
+			default:
+				panic(r)
+			}
+		}
+	}()
+	c.steps = 0
+	f()
+	return nil
+}
+
+// Dependent holds the definitions made in the dependently typed mode
+type Dependent struct {
+	// MaxSteps bounds the reductions checking a term may take, 0 means
+	// defaultGradeSteps
+	MaxSteps    int
+	definitions []dEntry
+}
+
+func (d *Dependent) checker() *dChecker {
+	maxSteps := d.MaxSteps
+	if maxSteps == 0 {
+		maxSteps = defaultGradeSteps
+	}
+	return &dChecker{context: d.definitions, maxSteps: maxSteps}
+}
+
+// Check type checks t against the definitions so far, returning its type and
+// normal form
+func (d *Dependent) Check(t DTerm) (typ, normal DTerm, err error) {
+	c := d.checker()
+	err = c.run(func() {
+		typ = c.normalize(c.infer(t))
+		normal = c.normalize(t)
+	})
+	return typ, normal, err
+}
+
+// Define type checks t and binds name to it, returning its type. Definitions
+// unfold during checking, so later types can compute with them.
+func (d *Dependent) Define(name string, t DTerm) (DTerm, error) {
+	typ, _, err := d.Check(t)
+	if err != nil {
+		return nil, err
+	}
+	d.definitions = append(d.definitions[:len(d.definitions):len(d.definitions)], dEntry{name, typ, t})
+	return typ, nil
+}
@@ -0,0 +1,59 @@
+package lambda
+
+import "testing"
+
+func TestDependent(t *testing.T) {
+	var d Dependent
+	definitions := []struct {
+		name, program, typ string
+	}{
+		{"id", "𝞴(A : Type) (x : A).x", "Π(A : Type).A → A"},
+		{"const", "(𝞴A B x y.x : Π(A B : Type).A → B → A)", "Π(A : Type).Π(B : Type).A → B → A"},
+		{"Nat", "Π(A : Type).(A → A) → A → A", "Type1"},
+		{"two", "(𝞴A f x.f (f x) : Nat)", "Π(A : Type).(A → A) → A → A"},
+		{"Endo", "𝞴(A : Type).A → A", "Type → Type"},
+		// the type of the parameter is computed by applying Endo
+		{"twice", "𝞴(A : Type) (f : Endo A) (x : A).f (f x)", "Π(A : Type).(A → A) → A → A"},
+	}
+	for _, tt := range definitions {
+		term, err := ParseDependent(tt.program)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.program, err)
+		}
+		typ, err := d.Define(tt.name, term)
+		if err != nil || typ.String() != tt.typ {
+			t.Errorf("%v: expected %v, but got %v %v", tt.name, tt.typ, typ, err)
+		}
+	}
+
+	checkCases := []struct {
+		program, normal, typ string
+	}{
+		{"𝞴(B : Type) (b : B).twice B (id B) b", "𝞴B.𝞴b.b", "Π(B : Type).B → B"},
+		{"Type", "Type", "Type1"},
+		{"let B : Type1 = Π(A : Type).A in B → B", "(Π(A : Type).A) → Π(A : Type).A", "Type1"},
+	}
+	for _, tt := range checkCases {
+		term, _ := ParseDependent(tt.program)
+		typ, normal, err := d.Check(term)
+		if err != nil || normal.String() != tt.normal || typ.String() != tt.typ {
+			t.Errorf("%v: expected %v : %v, but got %v : %v %v", tt.program, tt.normal, tt.typ, normal, typ, err)
+		}
+	}
+
+	errorCases := []struct {
+		program, err string
+	}{
+		{"id Type Type", "Type has type Type1, but expected Type"},
+		{"𝞴x.x", "can't infer the type of 𝞴x.x, annotate its parameter"},
+		{"Nat Type", "Nat is not a function, its type is Type1"},
+		{"(𝞴(x : Type1).x : Type → Type)", "parameter x has type Type1, but expected Type"},
+		{"y", "unbound variable y"},
+	}
+	for _, tt := range errorCases {
+		term, _ := ParseDependent(tt.program)
+		if _, _, err := d.Check(term); err == nil || err.Error() != tt.err {
+			t.Errorf("%v: expected %v, but got %v", tt.program, tt.err, err)
+		}
+	}
+}
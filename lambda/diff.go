@@ -0,0 +1,284 @@
+package lambda
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Difference is a pair of subterms that don't match, at the same path in both terms
+type Difference struct {
+	// Path names the steps from the root, as in /body/fn/arg
+	Path  string
+	Left  Expression
+	Right Expression
+}
+
+// Diff aligns two terms and reports the smallest subterms where they differ.
+// With alpha, bound variables match when they refer to corresponding binders,
+// whatever they are called.
+func Diff(left, right Expression, alpha bool) []Difference {
+	d := differ{alpha: alpha}
+	d.diff("", left, right, map[string]int{}, map[string]int{}, 0)
+	return d.differences
+}
+
+type differ struct {
+	alpha       bool
+	differences []Difference
+}
+
+func (d *differ) report(path string, left, right Expression) {
+	if path == "" {
+		path = "/"
+	}
+	d.differences = append(d.differences, Difference{path, left, right})
+}
+
+// bind records that name is bound at depth, in a copy of levels
+func bindLevel(levels map[string]int, name string, depth int) map[string]int {
+	inner := make(map[string]int, len(levels)+1)
+	for k, v := range levels {
+		inner[k] = v
+	}
+	inner[name] = depth
+	return inner
+}
+
+// sameBinder compares binder names, which only matter without alpha
+func (d *differ) sameBinder(path string, left, right variable) bool {
+	if d.alpha || left == right {
+		return true
+	}
+	d.report(path, left, right)
+	return false
+}
+
+func (d *differ) diff(path string, left, right Expression, leftLevels, rightLevels map[string]int, depth int) {
+	switch l := left.(type) {
+	case binding:
+		r, ok := right.(binding)
+		if !ok {
+			d.report(path, left, right)
+			return
+		}
+		d.sameBinder(path+"/name", l.name, r.name)
+		d.diff(path+"/value", l.value, r.value, leftLevels, rightLevels, depth)
+		d.diff(path+"/body", l.body, r.body,
+			bindLevel(leftLevels, l.name.identifier, depth), bindLevel(rightLevels, r.name.identifier, depth), depth+1)
+	case replBinding:
+		r, ok := right.(replBinding)
+		if !ok {
+			d.report(path, left, right)
+			return
+		}
+		// top level names are definitions, not bound variables
+		if l.name != r.name {
+			d.report(path+"/name", l.name, r.name)
+		}
+		d.diff(path+"/value", l.value, r.value, leftLevels, rightLevels, depth)
+	case abstraction:
+		r, ok := right.(abstraction)
+		if !ok {
+			d.report(path, left, right)
+			return
+		}
+		d.sameBinder(path+"/param", l.param, r.param)
+		d.diff(path+"/body", l.expr, r.expr,
+			bindLevel(leftLevels, l.param.identifier, depth), bindLevel(rightLevels, r.param.identifier, depth), depth+1)
+	case application:
+		r, ok := right.(application)
+		if !ok {
+			d.report(path, left, right)
+			return
+		}
+		d.diff(path+"/fn", l.left, r.left, leftLevels, rightLevels, depth)
+		d.diff(path+"/arg", l.right, r.right, leftLevels, rightLevels, depth)
+	default:
+		if !d.sameVariable(left, right, leftLevels, rightLevels) {
+			d.report(path, left, right)
+		}
+	}
+}
+
+func (d *differ) sameVariable(left, right Expression, leftLevels, rightLevels map[string]int) bool {
+	l, ok := variableName(left)
+	if !ok {
+		return false
+	}
+	r, ok := variableName(right)
+	if !ok {
+		return false
+	}
+	if !d.alpha {
+		return l == r
+	}
+	leftLevel, leftBound := leftLevels[l]
+	rightLevel, rightBound := rightLevels[r]
+	if leftBound || rightBound {
+		return leftBound && rightBound && leftLevel == rightLevel
+	}
+	return l == r
+}
+
+func variableName(exp Expression) (string, bool) {
+	switch exp := exp.(type) {
+	case variable:
+		return exp.identifier, true
+	case freeVariable:
+		return exp.identifier, true
+	default:
+		return "", false
+	}
+}
+
+// A library of encodings is reviewed as a set of definitions rather than one
+// term: DiffDefinitions pairs the definitions of two modules by name, and
+// says which were added or removed and where those kept differ.
+
+// DefinitionDiff is a definition added, removed or changed between two
+// modules: Left is nil for one added, Right for one removed, and for one
+// changed Differences are where its values differ
+type DefinitionDiff struct {
+	Name        string
+	Left, Right Expression
+	Differences []Difference
+}
+
+// IsDefinitions tells the text of a module, whose first line that isn't
+// blank or a comment is a ' definition, module or import, from a term
+func IsDefinitions(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isComment(line) {
+			continue
+		}
+		_, declared := keywordLine(line, "module")
+		_, imported := keywordLine(line, "import")
+		return strings.HasPrefix(line, "'") || declared || imported
+	}
+	return false
+}
+
+// definitionSet parses the definitions of a module, by name in the order
+// they are first made, a name made again having its last value. Module
+// declarations, imports and assertions aren't definitions, and are left out.
+func definitionSet(text string) ([]string, map[string]Expression, error) {
+	names := []string{}
+	values := map[string]Expression{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isComment(line) || isAssertion(line) {
+			continue
+		}
+		if _, ok := keywordLine(line, "module"); ok {
+			continue
+		}
+		if _, ok := keywordLine(line, "import"); ok {
+			continue
+		}
+		ast, err := parse(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v: %v", i+1, err)
+		}
+		d, ok := ast.(replBinding)
+		if !ok {
+			return nil, nil, fmt.Errorf("%v: a module only defines names with ', but got %v", i+1, line)
+		}
+		if _, ok := values[d.name.identifier]; !ok {
+			names = append(names, d.name.identifier)
+		}
+		values[d.name.identifier] = d.value
+	}
+	return names, values, nil
+}
+
+// DiffDefinitions compares the definitions of the modules left and right:
+// those removed and changed in the order of left, then those added in the
+// order of right. With alpha, values differing only in the names of bound
+// variables are the same. The error says at which line of which a
+// definition doesn't parse.
+func DiffDefinitions(left, right string, alpha bool) ([]DefinitionDiff, error) {
+	leftNames, leftValues, err := definitionSet(left)
+	if err != nil {
+		return nil, fmt.Errorf("old: %w", err)
+	}
+	rightNames, rightValues, err := definitionSet(right)
+	if err != nil {
+		return nil, fmt.Errorf("new: %w", err)
+	}
+	diffs := []DefinitionDiff{}
+	for _, name := range leftNames {
+		l := leftValues[name]
+		r, ok := rightValues[name]
+		if !ok {
+			diffs = append(diffs, DefinitionDiff{name, l, nil, nil})
+			continue
+		}
+		if differences := Diff(l, r, alpha); len(differences) > 0 {
+			diffs = append(diffs, DefinitionDiff{name, l, r, differences})
+		}
+	}
+	for _, name := range rightNames {
+		if _, ok := leftValues[name]; !ok {
+			diffs = append(diffs, DefinitionDiff{name, nil, rightValues[name], nil})
+		}
+	}
+	return diffs, nil
+}
+
+// WriteDiff writes differences as their paths, each followed by the left
+// subterm after - and the right after +, the paths prefixed with prefix, in
+// red and green with color
+func WriteDiff(out io.Writer, prefix string, differences []Difference, color bool) error {
+	for _, d := range differences {
+		if err := writeDiffLines(out, prefix+d.Path, d.Left, d.Right, color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDefinitionDiff writes diffs: a definition removed as - and it, one
+// added as + and it, and the differences of one changed with paths starting
+// with its name
+func WriteDefinitionDiff(out io.Writer, diffs []DefinitionDiff, color bool) error {
+	for _, d := range diffs {
+		var err error
+		switch {
+		case d.Right == nil:
+			err = writeDiffLines(out, "", replBinding{variable{d.Name}, d.Left}, nil, color)
+		case d.Left == nil:
+			err = writeDiffLines(out, "", nil, replBinding{variable{d.Name}, d.Right}, color)
+		default:
+			err = WriteDiff(out, d.Name, d.Differences, color)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDiffLines writes path, unless it is empty, then the - line of left
+// and the + line of right, leaving out those that are nil
+func writeDiffLines(out io.Writer, path string, left, right Expression, color bool) error {
+	paint := func(ansi, text string) string {
+		if color {
+			return ansi + text + ansiReset
+		}
+		return text
+	}
+	var b strings.Builder
+	if path != "" {
+		fmt.Fprintln(&b, paint(ansiDiffPath, path))
+	}
+	if left != nil {
+		fmt.Fprintln(&b, paint(ansiRemoved, "- "+format(left)))
+	}
+	if right != nil {
+		fmt.Fprintln(&b, paint(ansiAdded, "+ "+format(right)))
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
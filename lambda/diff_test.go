@@ -0,0 +1,69 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	diffCases := []struct {
+		left        string
+		right       string
+		alpha       bool
+		differences string
+	}{
+		{"𝞴x.x", "𝞴x.x", false, ""},
+		{"𝞴x.x", "𝞴y.y", false, "/param: x y, /body: x y"},
+		{"𝞴x.x", "𝞴y.y", true, ""},
+		{"𝞴x y.x", "𝞴x y.y", true, "/body/body: x y"},
+		{"f (g a)", "f (g b)", false, "/arg/arg: a b"},
+		{"f a", "𝞴x.x", false, "/: (f a) (𝞴x.x)"},
+		{"𝞴x.a", "𝞴a.a", true, "/body: a a"},
+		{"let i = 𝞴x.x in i", "let j = 𝞴y.y in j", true, ""},
+	}
+	for _, tt := range diffCases {
+		t.Run(tt.left+" "+tt.right, func(t *testing.T) {
+			left, _ := parse(tt.left)
+			right, _ := parse(tt.right)
+			res := []string{}
+			for _, d := range Diff(left, right, tt.alpha) {
+				res = append(res, fmt.Sprintf("%v: %v %v", d.Path, d.Left, d.Right))
+			}
+			if strings.Join(res, ", ") != tt.differences {
+				t.Errorf("expected %v, but got %v", tt.differences, strings.Join(res, ", "))
+			}
+		})
+	}
+}
+
+func TestDiffDefinitions(t *testing.T) {
+	old := "module bool\n-- booleans\n'true = 𝞴x y.x\n'false = 𝞴x y.y\n'not = 𝞴b.b false true\n"
+	new := "module bool\n'true = 𝞴a b.a\n'false = 𝞴x y.x\n'and = 𝞴a b.a b false\n"
+	if !IsDefinitions(old) || IsDefinitions("-- id\n𝞴x.x") {
+		t.Errorf("expected modules told from terms")
+	}
+	diffs, err := DiffDefinitions(old, new, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := WriteDefinitionDiff(&b, diffs, false); err != nil {
+		t.Fatal(err)
+	}
+	expected := "false/body/body\n- y\n+ x\n- 'not = 𝞴b.b false true\n+ 'and = 𝞴a b.a b false\n"
+	if b.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, b.String())
+	}
+	if diffs, _ := DiffDefinitions(old, old, false); len(diffs) != 0 {
+		t.Errorf("expected no differences, but got %v", diffs)
+	}
+	if _, err := DiffDefinitions(old, "'x = (", false); err == nil || !strings.HasPrefix(err.Error(), "new: 1: ") {
+		t.Errorf("expected a parse error, but got %v", err)
+	}
+	b.Reset()
+	WriteDiff(&b, "", Diff(variable{"a"}, variable{"b"}, false), true)
+	if b.String() != ansiDiffPath+"/"+ansiReset+"\n"+ansiRemoved+"- a"+ansiReset+"\n"+ansiAdded+"+ b"+ansiReset+"\n" {
+		t.Errorf("expected colored lines, but got %q", b.String())
+	}
+}
@@ -0,0 +1,101 @@
+package lambda
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// The comments of a module may hold examples, as
+//
+//	-- >>> not true
+//	-- false
+//
+// an input and, on the comment line after it, what it should evaluate to.
+// Doctest runs them, with all the module's definitions in scope, so the
+// documentation of a prelude stays true as it changes.
+
+// Example is an example in the comments of a module, at Line
+type Example struct {
+	Line     int
+	Input    string
+	Expected string
+	// Got is the normal form of Input, or why it has none
+	Got    string
+	Passed bool
+}
+
+func (e Example) String() string {
+	return fmt.Sprintf("%v: >>> %v\nexpected %v, but got %v", e.Line, e.Input, e.Expected, e.Got)
+}
+
+// examplePrompt starts the comment of an example's input
+const examplePrompt = ">>>"
+
+// Doctest runs the examples of the module in file, whose imports are looked
+// for along path, or the default path when it is nil
+func Doctest(file string, path []string) ([]Example, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		path = defaultModulePath()
+	}
+	m := modules{path: path}
+	mod, err := m.read("", file, string(text))
+	if err != nil {
+		return nil, err
+	}
+	examples := []Example{}
+	lines := strings.Split(string(text), "\n")
+	for i := 0; i < len(lines); i++ {
+		input, ok := exampleLine(lines[i])
+		if !ok || !strings.HasPrefix(input, examplePrompt) {
+			continue
+		}
+		e := Example{Line: i + 1, Input: strings.TrimSpace(strings.TrimPrefix(input, examplePrompt))}
+		expected, ok := "", false
+		if i+1 < len(lines) {
+			expected, ok = exampleLine(lines[i+1])
+		}
+		if !ok || expected == "" || strings.HasPrefix(expected, examplePrompt) {
+			return nil, fmt.Errorf("%v:%v: example %v has no expected value on the next line", file, e.Line, e.Input)
+		}
+		i++
+		e.Expected = expected
+		e.Got, e.Passed = runExample(e.Input, e.Expected, mod.scope)
+		examples = append(examples, e)
+	}
+	return examples, nil
+}
+
+// exampleLine reads the text of a comment line
+func exampleLine(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !isComment(line) {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, "--")), true
+}
+
+// runExample evaluates input in scope, telling whether its normal form is
+// that of expected, up to renaming of bound variables
+func runExample(input, expected string, scope Environment) (string, bool) {
+	forms := [2]Expression{}
+	for i, text := range []string{input, expected} {
+		// a fault in the expected value is reported as one
+		prefix := ""
+		if i == 1 {
+			prefix = "an expected value with "
+		}
+		exp, err := parse(text)
+		if err != nil {
+			return prefix + err.Error(), false
+		}
+		if forms[i], err = normalize(resolve(exp, scope), defaultGradeSteps); err != nil {
+			return fmt.Sprintf("%vno normal form within %v steps", prefix, defaultGradeSteps), false
+		}
+	}
+	return format(forms[0]), len(Diff(forms[0], forms[1], true)) == 0
+}
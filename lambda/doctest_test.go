@@ -0,0 +1,48 @@
+package lambda
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDoctest(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"bool": "module bool\n'true = 𝞴x y.x\n'false = 𝞴x y.y\n",
+		"not": `import bool
+-- not b is true when b is false
+-- >>> not bool.true
+-- bool.false
+-- >>> not bool.false
+-- 𝞴a b.a
+'not = 𝞴b.b bool.false bool.true
+-- >>> not (not bool.true)
+-- bool.false
+-- >>> not
+--   (𝞴
+`,
+	})
+	examples, err := Doctest(filepath.Join(dir, "not.lam"), []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Example{
+		{3, "not bool.true", "bool.false", "𝞴x y.y", true},
+		{5, "not bool.false", "𝞴a b.a", "𝞴x y.x", true},
+		{8, "not (not bool.true)", "bool.false", "𝞴x y.x", false},
+		{10, "not", "(𝞴", "an expected value with unexpected eof", false},
+	}
+	if len(examples) != len(expected) {
+		t.Fatalf("expected %v, but got %v", expected, examples)
+	}
+	for i := range expected {
+		if examples[i] != expected[i] {
+			t.Errorf("expected %v, but got %v", expected[i], examples[i])
+		}
+	}
+	missing := filepath.Join(dir, "missing.lam")
+	os.WriteFile(missing, []byte("-- >>> x\n'x = 𝞴x.x\n"), 0644)
+	if _, err := Doctest(missing, nil); err == nil || err.Error() != missing+":1: example x has no expected value on the next line" {
+		t.Errorf("expected a missing expected value, but got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package lambda
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// The REPL reads a program a line, but two commands take one of many lines.
+// :edit opens a definition, or the last program entered, in the user's
+// editor, and runs what was saved as if it had been typed, so a long
+// combinator is changed as text rather than retyped on one line. :paste
+// reads lines as they are until a lone ., so a definition copied from a file
+// where it spans lines isn't run a line at a time. The lines are joined into
+// one program either way, leaving out blank lines and comments.
+
+// editFile runs $VISUAL, $EDITOR or else vi on file, on the terminal the REPL
+// runs on, waiting for it to exit
+func editFile(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// the editor may be given arguments, as in EDITOR="code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], file)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// edit edits the definition of the name in args, or the last program without
+// any, leaving it to be run again once saved unless it is unchanged
+func (r *repl) edit(args []string) error {
+	var text string
+	switch {
+	case len(args) == 1:
+		b, ok := r.env.lookup(args[0])
+		if !ok {
+			return unboundError{args[0], r.env.suggest(args[0])}
+		}
+		text = b.source
+	case len(args) > 1:
+		return fmt.Errorf("usage: :edit [name]")
+	case len(r.history) == 0:
+		return fmt.Errorf("nothing to edit")
+	default:
+		text = r.history[len(r.history)-1].input
+	}
+	f, err := os.CreateTemp("", "lambda-*.lam")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = fmt.Fprintln(f, text)
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	editor := r.editor
+	if editor == nil {
+		editor = editFile
+	}
+	if err := editor(f.Name()); err != nil {
+		return fmt.Errorf("editor failed: %v", err)
+	}
+	saved, err := os.ReadFile(f.Name())
+	if err != nil {
+		return err
+	}
+	program := joinLines(string(saved))
+	switch program {
+	case "":
+		return fmt.Errorf("nothing to run, the program was deleted")
+	case text:
+		fmt.Fprintln(r.out, "unchanged")
+		return nil
+	}
+	r.next = program
+	return nil
+}
+
+// paste reads the lines of a program until a lone . or the end of input, and
+// leaves it to be run
+func (r *repl) paste() error {
+	if r.in == nil {
+		return fmt.Errorf("nothing to paste from")
+	}
+	fmt.Fprintln(r.out, "pasting, end with a lone . or Ctrl-D")
+	var text strings.Builder
+	for {
+		line, err := r.in.ReadString('\n')
+		if strings.TrimSpace(line) == "." {
+			break
+		}
+		text.WriteString(line)
+		if err != nil {
+			break
+		}
+	}
+	program := joinLines(text.String())
+	if program == "" {
+		return fmt.Errorf("nothing was pasted")
+	}
+	r.next = program
+	return nil
+}
+
+// joinLines joins the lines of text into one program, leaving out blank lines
+// and comments
+func joinLines(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !isComment(line) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " ")
+}
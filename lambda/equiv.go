@@ -0,0 +1,116 @@
+package lambda
+
+// normalize reduces exp in normal order until no redex is left, which finds the
+// normal form whenever there is one, or fails with ErrStepLimit after maxSteps
+func normalize(exp Expression, maxSteps int) (Expression, error) {
+	for steps := 0; ; steps++ {
+		next, ok := reduceStep(exp)
+		if !ok {
+			return exp, nil
+		}
+		if steps == maxSteps {
+			return exp, ErrStepLimit
+		}
+		exp = next
+	}
+}
+
+// Equivalent reports whether two terms are beta-eta equivalent, by comparing
+// their eta reduced normal forms up to renaming of bound variables. Terms without
+// a normal form within maxSteps reductions fail with ErrStepLimit.
+func Equivalent(a, b Expression, maxSteps int) (bool, error) {
+	a, err := normalize(a, maxSteps)
+	if err != nil {
+		return false, err
+	}
+	b, err = normalize(b, maxSteps)
+	if err != nil {
+		return false, err
+	}
+	// a normal form has no beta redexes left, so only eta applies
+	return len(Diff(Simplify(a), Simplify(b), true)) == 0, nil
+}
+
+// alphaEquivalent reports whether a and b are equal up to renaming of bound
+// variables, which makes them equivalent without reducing either. Their hashes
+// rule out most terms that aren't before comparing them.
+func alphaEquivalent(a, b Expression) bool {
+	return Hash(a) == Hash(b) && len(Diff(a, b, true)) == 0
+}
+
+// Verdict is what Prove concluded about two terms
+type Verdict int
+
+const (
+	// Unknown means neither normalizing nor searching for a common reduct
+	// finished within the limits
+	Unknown Verdict = iota
+	Equal
+	Distinct
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Equal:
+		return "equivalent"
+	case Distinct:
+		return "distinct"
+	default:
+		return "unknown"
+	}
+}
+
+// Prove tries to decide whether two terms are beta-eta equivalent. Terms equal
+// up to renaming of bound variables are, without reducing them. When both
+// normalize within maxSteps, their eta reduced normal forms decide, along with
+// where they differ when distinct. Otherwise it reduces both sides in normal
+// order, up to maxSteps each, looking for a term reached from both up to
+// renaming of bound variables, which proves them equal even without normal forms.
+func Prove(a, b Expression, maxSteps int) (Verdict, []Difference) {
+	if alphaEquivalent(a, b) {
+		return Equal, nil
+	}
+	normalA, errA := normalize(a, maxSteps)
+	normalB, errB := normalize(b, maxSteps)
+	if errA == nil && errB == nil {
+		differences := Diff(Simplify(normalA), Simplify(normalB), true)
+		if len(differences) == 0 {
+			return Equal, nil
+		}
+		return Distinct, differences
+	}
+	if joinable(a, b, maxSteps) {
+		return Equal, nil
+	}
+	return Unknown, nil
+}
+
+// ProveIn is Prove for terms that may use the definitions of env
+func ProveIn(a, b Expression, env Environment, maxSteps int) (Verdict, []Difference) {
+	return Prove(resolve(a, env), resolve(b, env), maxSteps)
+}
+
+// joinable reduces a and b side by side, reporting whether some term is
+// reached from both within maxSteps reductions each
+func joinable(a, b Expression, maxSteps int) bool {
+	seenA, seenB := map[string]bool{}, map[string]bool{}
+	doneA, doneB := false, false
+	for steps := 0; steps <= maxSteps && !(doneA && doneB); steps++ {
+		keyA, keyB := format(Canonicalize(a)), format(Canonicalize(b))
+		seenA[keyA], seenB[keyB] = true, true
+		if seenB[keyA] || seenA[keyB] {
+			return true
+		}
+		if next, ok := reduceStep(a); ok {
+			a = next
+		} else {
+			doneA = true
+		}
+		if next, ok := reduceStep(b); ok {
+			b = next
+		} else {
+			doneB = true
+		}
+	}
+	return false
+}
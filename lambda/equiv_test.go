@@ -0,0 +1,81 @@
+package lambda
+
+import "testing"
+
+func TestEquivalent(t *testing.T) {
+	equivCases := []struct {
+		a          string
+		b          string
+		equivalent bool
+	}{
+		{"𝞴x.x", "𝞴y.y", true},
+		{"𝞴f.𝞴x.f x", "𝞴f.f", true},
+		{"(𝞴x.x) (𝞴f x.f (f x))", "𝞴g y.g (g y)", true},
+		{"let two = 𝞴f x.f (f x) in two two", "𝞴f x.f (f (f (f x)))", true},
+		{"𝞴x y.x", "𝞴x y.y", false},
+		{"f a", "f b", false},
+	}
+	for _, tt := range equivCases {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, _ := parse(tt.a)
+			b, _ := parse(tt.b)
+			res, err := Equivalent(a, b, 1000)
+			if err != nil || res != tt.equivalent {
+				t.Errorf("expected %v, but got %v %v", tt.equivalent, res, err)
+			}
+		})
+	}
+	omega, _ := parse("(𝞴x.x x) (𝞴x.x x)")
+	if _, err := Equivalent(omega, omega, 100); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+}
+
+func TestProve(t *testing.T) {
+	proveCases := []struct {
+		a       string
+		b       string
+		verdict Verdict
+		path    string
+	}{
+		{"𝞴f.𝞴x.f x", "𝞴g.g", Equal, ""},
+		{"𝞴x y.x", "𝞴x y.y", Distinct, "/body/body"},
+		{"(𝞴x.x x) (𝞴x.x x)", "(𝞴z.z) ((𝞴y.y y) (𝞴x.x x))", Equal, ""},
+		{"(𝞴x.x x) (𝞴x.x x)", "(𝞴x.x x x) (𝞴x.x x x)", Unknown, ""},
+	}
+	for _, tt := range proveCases {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, _ := parse(tt.a)
+			b, _ := parse(tt.b)
+			verdict, differences := Prove(a, b, 100)
+			if verdict != tt.verdict {
+				t.Fatalf("expected %v, but got %v", tt.verdict, verdict)
+			}
+			if tt.path != "" && (len(differences) == 0 || differences[0].Path != tt.path) {
+				t.Errorf("expected a difference at %v, but got %v", tt.path, differences)
+			}
+		})
+	}
+}
+
+func TestProveAlphaEquivalent(t *testing.T) {
+	// omega has no normal form, but is equal to itself renamed
+	a, _ := parse("(𝞴x.x x) (𝞴x.x x)")
+	b, _ := parse("(𝞴y.y y) (𝞴z.z z)")
+	if verdict, _ := Prove(a, b, 0); verdict != Equal {
+		t.Errorf("expected %v, but got %v", Equal, verdict)
+	}
+}
+
+func TestProveIn(t *testing.T) {
+	id, _ := parse("𝞴x.x")
+	env := Environment{}.define(variable{"id"}, id, "'id = 𝞴x.x")
+	a, _ := parse("id id")
+	b, _ := parse("𝞴y.y")
+	if verdict, _ := ProveIn(a, b, env, 100); verdict != Equal {
+		t.Errorf("expected %v, but got %v", Equal, verdict)
+	}
+	if verdict, _ := ProveIn(a, b, Environment{}, 100); verdict != Distinct {
+		t.Errorf("expected %v without the definition, but got %v", Distinct, verdict)
+	}
+}
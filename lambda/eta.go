@@ -0,0 +1,32 @@
+package lambda
+
+// EtaExpand wraps e in abstractions until its head takes n explicit arguments,
+// so 𝞴x.f x is the expansion of f with n = 1. Binders e already has count
+// towards n, and the new parameters are named apart from every name in e.
+func EtaExpand(e Expression, n int) Expression {
+	params := []variable{}
+	body := e
+	for len(params) < n {
+		abs, ok := body.(abstraction)
+		if !ok {
+			break
+		}
+		params = append(params, abs.param)
+		body = abs.expr
+	}
+	avoid := map[string]bool{}
+	allNames(e, avoid)
+	for len(params) < n {
+		param := variable{"x"}
+		if avoid[param.identifier] {
+			param = variable{fresh(param.identifier, avoid)}
+		}
+		avoid[param.identifier] = true
+		params = append(params, param)
+		body = application{body, param}
+	}
+	for i := len(params) - 1; i >= 0; i-- {
+		body = abstraction{params[i], body}
+	}
+	return body
+}
@@ -0,0 +1,26 @@
+package lambda
+
+import "testing"
+
+func TestEtaExpand(t *testing.T) {
+	etaCases := []struct {
+		program  string
+		n        int
+		expanded string
+	}{
+		{"f", 1, "𝞴x.f x"},
+		{"f", 2, "𝞴x x'.f x x'"},
+		{"x", 1, "𝞴x'.x x'"},
+		{"𝞴a.f a", 2, "𝞴a x.f a x"},
+		{"𝞴a b.a", 1, "𝞴a b.a"},
+		{"f", 0, "f"},
+	}
+	for _, tt := range etaCases {
+		t.Run(tt.program, func(t *testing.T) {
+			ast, _ := parse(tt.program)
+			if res := format(EtaExpand(ast, tt.n)); res != tt.expanded {
+				t.Errorf("expected %v, but got %v", tt.expanded, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package lambda
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// replEntry is a program typed into the REPL, along with the environment it ran in
+type replEntry struct {
+	input string
+	env   Environment
+}
+
+// markdown writes the session as a document for course notes or issue reports:
+// every program with its reduction steps and result, decoded when it is a
+// Church numeral or boolean
+func (r *repl) markdown() string {
+	var b strings.Builder
+	b.WriteString("# lambda session\n")
+	for i, entry := range r.history {
+		fmt.Fprintf(&b, "\n## %v\n\n```\n%v\n```\n\n", i+1, entry.input)
+		res, _ := traceProgram(entry.input, EvalOptions{Strict: r.strict}, entry.env)
+		if len(res.Trace) > 1 {
+			fmt.Fprintf(&b, "Reduction:\n\n```\n%v\n```\n\n", strings.Join(res.Trace, "\n"))
+		}
+		if res.err != nil {
+			fmt.Fprintf(&b, "Error: %v\n", res.err)
+			continue
+		}
+		fmt.Fprintf(&b, "Result: `%v`\n", res.NormalForm)
+		normal, _ := parse(res.NormalForm)
+		if v, ok := normal.(replBinding); ok {
+			normal = v.value
+		}
+		if decoded, ok := decode(normal); ok {
+			fmt.Fprintf(&b, "\nDecoded: %v\n", decoded)
+		}
+	}
+	return b.String()
+}
+
+// exportValue writes the value printed last to file in full, however much
+// of it was printed
+func (r *repl) exportValue(file string) error {
+	if r.last == nil {
+		return fmt.Errorf("no value to export yet")
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	p := printer{out: w}
+	r.render(&p, r.last)
+	fmt.Fprintln(w)
+	err = p.err
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(r.out, "exported a value of %v nodes to %v\n", size(r.last), file)
+	return nil
+}
+
+// prelude writes the definitions in scope as a file :load reads back,
+// each after the definitions it uses. A definition is written as it was
+// entered unless a name it used has since been redefined, or it was
+// imported under another name, when its value is written instead.
+func (e Environment) prelude() string {
+	var b strings.Builder
+	latest := map[string]int{}
+	all := e.all()
+	for i, binding := range all {
+		latest[binding.name.identifier] = i
+	}
+	for i, binding := range all {
+		if latest[binding.name.identifier] != i {
+			continue
+		}
+		source := binding.source
+		if !e.current(all, i) {
+			source = format(replBinding{binding.name, binding.value})
+		}
+		fmt.Fprintln(&b, source)
+	}
+	return b.String()
+}
+
+// current says whether the source of the binding at i of all, the bindings
+// of e, still means what it did, defining the same name with definitions
+// still in scope
+func (e Environment) current(all []envBinding, i int) bool {
+	ast, err := parse(all[i].source)
+	v, ok := ast.(replBinding)
+	if err != nil || !ok || v.name != all[i].name {
+		return false
+	}
+	before := Environment{bindings: all[:i]}
+	for name := range freeVariables(v.value) {
+		// a name free then may be bound now
+		used, wasBound := before.lookup(name)
+		now, isBound := e.lookup(name)
+		if wasBound != isBound || used != now {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package lambda
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.md")
+	res := runRepl("'two = 𝞴f x.f (f x)", "(𝞴x.x) two", "x.", ":export "+file)
+	if res[3] != "exported 3 programs to "+file+"\n" {
+		t.Errorf("unexpected output %q", res[3])
+	}
+	text, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# lambda session\n" +
+		"\n## 1\n\n```\n'two = 𝞴f x.f (f x)\n```\n\nResult: `'two = 𝞴f x.f (f x)`\n\nDecoded: 2\n" +
+		"\n## 2\n\n```\n(𝞴x.x) two\n```\n\nReduction:\n\n```\n(𝞴x.x) (𝞴f x.f (f x))\n𝞴f x.f (f x)\n```\n\nResult: `𝞴f x.f (f x)`\n\nDecoded: 2\n" +
+		"\n## 3\n\n```\nx.\n```\n\nError: unexpected dot .\n"
+	if string(text) != expected {
+		t.Errorf("expected %v, but got %v", expected, string(text))
+	}
+}
+
+func TestExportEnv(t *testing.T) {
+	dir := writeModules(t, map[string]string{"bool": "'true = 𝞴x y.x\n"})
+	file := filepath.Join(t.TempDir(), "mylib.lam")
+	var out strings.Builder
+	r := repl{out: &out, modules: modules{path: []string{dir}}}
+	for _, line := range []string{
+		"import bool",
+		"'id = 𝞴x.x",
+		"'twice = 𝞴f x.f (f x)",
+		"'k = 𝞴x y.id x",
+		"'id = 𝞴y.y",
+		"'f = 𝞴x.g x",
+		"'g = 𝞴x.x",
+		":export-env " + file,
+	} {
+		out.Reset()
+		r.line(line)
+	}
+	if out.String() != "exported 6 definitions to "+file+"\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	text, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// bool.true was imported under another name, and k used an id since
+	// redefined, so they are written as their values
+	expected := "'bool.true = 𝞴x y.x\n" +
+		"'twice = 𝞴f x.f (f x)\n" +
+		"'k = 𝞴x y.x\n" +
+		"'id = 𝞴y.y\n" +
+		"'f = 𝞴x.g x\n" +
+		"'g = 𝞴x.x\n"
+	if string(text) != expected {
+		t.Errorf("expected %v, but got %v", expected, string(text))
+	}
+	loaded := repl{out: &out}
+	out.Reset()
+	loaded.line(":load " + file)
+	for _, b := range r.env.definitions() {
+		if v, _ := loaded.env.find(b.name); v != b.value {
+			t.Errorf("%v: expected %v, but got %v", b.name, b.value, v)
+		}
+	}
+}
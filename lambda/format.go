@@ -0,0 +1,20 @@
+package lambda
+
+// format prints an expression as source, with only the parentheses the parser needs
+func format(exp Expression) string {
+	return printString(exp, (*printer).format)
+}
+
+// formatValue prints the value of a binding, which cannot be a let without parentheses
+func formatValue(exp Expression) string {
+	return printString(exp, (*printer).value)
+}
+
+func formatAtom(exp Expression) string {
+	return printString(exp, (*printer).atom)
+}
+
+// Format prints an expression as source, with only the parentheses the parser needs
+func Format(exp Expression) string {
+	return format(exp)
+}
@@ -0,0 +1,32 @@
+package lambda
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	formatCases := []struct {
+		program string
+		format  string
+	}{
+		{"(𝞴x.(𝞴y.(x y)))", "𝞴x y.x y"},
+		{"((x y) z)", "x y z"},
+		{"(x (y z))", "x (y z)"},
+		{"(𝞴x.x) (𝞴y.y)", "(𝞴x.x) (𝞴y.y)"},
+		{"let  id =  𝞴x.x in (id  id)", "let id = 𝞴x.x in id id"},
+		{"' k = \\x y.x", "'k = 𝞴x y.x"},
+	}
+	for _, tt := range formatCases {
+		t.Run(tt.program, func(t *testing.T) {
+			ast, err := parse(tt.program)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res := format(ast); res != tt.format {
+				t.Errorf("expected %v, but got %v", tt.format, res)
+			}
+			// formatting must not change the meaning
+			if reparsed, err := parse(format(ast)); err != nil || reparsed.String() != ast.String() {
+				t.Errorf("expected %v to reparse as %v, but got %v", format(ast), ast, reparsed)
+			}
+		})
+	}
+}
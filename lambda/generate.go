@@ -0,0 +1,171 @@
+package lambda
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/rand"
+	"sync"
+)
+
+// termCounts memoizes how many terms of a size there are with a number of
+// binders in scope, shared by every call to GenerateClosed
+var termCounts = struct {
+	sync.Mutex
+	counts map[[2]int]*big.Int
+}{counts: map[[2]int]*big.Int{}}
+
+// countTerms is how many terms of size nodes there are whose free variables
+// are among scope binders
+func countTerms(size, scope int) *big.Int {
+	termCounts.Lock()
+	defer termCounts.Unlock()
+	return countTermsLocked(size, scope)
+}
+
+func countTermsLocked(size, scope int) *big.Int {
+	if size < 1 {
+		return new(big.Int)
+	}
+	if size == 1 {
+		return big.NewInt(int64(scope))
+	}
+	key := [2]int{size, scope}
+	if n, ok := termCounts.counts[key]; ok {
+		return n
+	}
+	n := new(big.Int).Set(countTermsLocked(size-1, scope+1))
+	for left := 1; left < size-1; left++ {
+		n.Add(n, new(big.Int).Mul(countTermsLocked(left, scope), countTermsLocked(size-1-left, scope)))
+	}
+	termCounts.counts[key] = n
+	return n
+}
+
+// GenerateClosed picks a closed term of exactly size nodes, as size counts
+// them, uniformly at random among all of them up to renaming of bound
+// variables, or nil when there are none, as for sizes below 2. Binders are
+// named a, b, c, ... by depth so none shadows another. An rng seeded alike
+// gives the same terms on every platform.
+func GenerateClosed(size int, rng *rand.Rand) Expression {
+	if countTerms(size, 0).Sign() == 0 {
+		return nil
+	}
+	return generate(size, 0, rng)
+}
+
+// GenerateOpen picks a term of exactly size nodes uniformly at random among
+// all of them whose free variables are among the first free names of a, b,
+// c, ..., or nil when there are none, as there are no terms at all with no
+// free names but closed ones. Binders are named on from the free names, so
+// none shadows another or a free variable.
+func GenerateOpen(size, free int, rng *rand.Rand) Expression {
+	if countTerms(size, free).Sign() == 0 {
+		return nil
+	}
+	return generate(size, free, rng)
+}
+
+// generate picks a term of size nodes with scope binders around it, each
+// shape chosen in proportion to how many terms have it. Given rngs seeded
+// alike, it picks the same terms on every platform.
+func generate(size, scope int, rng *rand.Rand) Expression {
+	if size == 1 {
+		return variable{canonicalName(rng.Intn(scope))}
+	}
+	pick := randomBelow(rng, countTerms(size, scope))
+	abstractions := countTerms(size-1, scope+1)
+	if pick.Cmp(abstractions) < 0 {
+		return abstraction{variable{canonicalName(scope)}, generate(size-1, scope+1, rng)}
+	}
+	pick.Sub(pick, abstractions)
+	for left := 1; ; left++ {
+		n := new(big.Int).Mul(countTerms(left, scope), countTerms(size-1-left, scope))
+		if pick.Cmp(n) < 0 {
+			return application{generate(left, scope, rng), generate(size-1-left, scope, rng)}
+		}
+		pick.Sub(pick, n)
+	}
+}
+
+// randomBelow picks a number below n, which must be positive, uniformly with
+// rng. It draws 32 bits at a time, most significant first, keeping as many as
+// n has and drawing again when that is n or more. big.Int's Rand draws a
+// machine word at a time instead, so it picks other numbers on 32 bit
+// platforms than on 64 bit ones.
+func randomBelow(rng *rand.Rand, n *big.Int) *big.Int {
+	bits := n.BitLen()
+	words := (bits + 31) / 32
+	buf := make([]byte, 4*words)
+	for {
+		for i := 0; i < words; i++ {
+			binary.BigEndian.PutUint32(buf[4*i:], rng.Uint32())
+		}
+		pick := new(big.Int).SetBytes(buf)
+		pick.Rsh(pick, uint(32*words-bits))
+		if pick.Cmp(n) < 0 {
+			return pick
+		}
+	}
+}
+
+// termKind restricts enumerate to normal forms, or to neutral terms, the
+// normal forms headed by a variable, which can be applied without a redex
+type termKind int
+
+const (
+	anyTerm termKind = iota
+	normalTerm
+	neutralTerm
+)
+
+// enumerate calls yield with every term of kind of size nodes with scope
+// binders around it: variables innermost binder last, then abstractions, then
+// applications by the size of the function, reporting false once yield does
+func enumerate(size, scope int, kind termKind, yield func(Expression) bool) bool {
+	if size == 1 {
+		for i := 0; i < scope; i++ {
+			if !yield(variable{canonicalName(i)}) {
+				return false
+			}
+		}
+		return true
+	}
+	if kind != neutralTerm {
+		param := variable{canonicalName(scope)}
+		ok := enumerate(size-1, scope+1, kind, func(body Expression) bool {
+			return yield(abstraction{param, body})
+		})
+		if !ok {
+			return false
+		}
+	}
+	fnKind, argKind := anyTerm, anyTerm
+	if kind != anyTerm {
+		fnKind, argKind = neutralTerm, normalTerm
+	}
+	for left := 1; left < size-1; left++ {
+		ok := enumerate(left, scope, fnKind, func(fn Expression) bool {
+			return enumerate(size-1-left, scope, argKind, func(arg Expression) bool {
+				return yield(application{fn, arg})
+			})
+		})
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// EnumerateClosed calls yield with every closed term of up to maxSize nodes,
+// smallest first, each once up to renaming of bound variables, stopping as
+// soon as yield returns false
+func EnumerateClosed(maxSize int, yield func(Expression) bool) {
+	for size := 1; size <= maxSize && enumerate(size, 0, anyTerm, yield); size++ {
+	}
+}
+
+// EnumerateNormal is EnumerateClosed for the closed terms in normal form
+func EnumerateNormal(maxSize int, yield func(Expression) bool) {
+	for size := 1; size <= maxSize && enumerate(size, 0, normalTerm, yield); size++ {
+	}
+}
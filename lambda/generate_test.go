@@ -0,0 +1,135 @@
+package lambda
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestGenerateClosed(t *testing.T) {
+	// the closed terms of size 4 are 𝞴a b c.a, 𝞴a b c.b, 𝞴a b c.c and 𝞴a.a a
+	counts := map[string]int{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 4000; i++ {
+		counts[format(GenerateClosed(4, rng))] += 1
+	}
+	if len(counts) != 4 {
+		t.Fatalf("expected 4 distinct terms, but got %v", counts)
+	}
+	for term, n := range counts {
+		if n < 900 || n > 1100 {
+			t.Errorf("expected about 1000 of %v, but got %v", term, n)
+		}
+	}
+	if GenerateClosed(1, rng) != nil {
+		t.Errorf("expected no closed term of size 1")
+	}
+}
+
+func TestGenerateOpen(t *testing.T) {
+	// the terms of size 2 with free variables among a and b are 𝞴c.a, 𝞴c.b
+	// and 𝞴c.c
+	counts := map[string]int{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 3000; i++ {
+		counts[format(GenerateOpen(2, 2, rng))] += 1
+	}
+	if len(counts) != 3 || counts["𝞴c.a"] < 900 || counts["𝞴c.c"] < 900 {
+		t.Errorf("expected about 1000 each of 3 distinct terms, but got %v", counts)
+	}
+	for i := 0; i < 100; i++ {
+		term := GenerateOpen(20, 3, rng)
+		for name := range freeVariables(term) {
+			if name != "a" && name != "b" && name != "c" {
+				t.Fatalf("expected only a, b and c free, but got %v", format(term))
+			}
+		}
+	}
+	if GenerateOpen(1, 0, rng) != nil {
+		t.Errorf("expected no term of size 1 without free variables")
+	}
+}
+
+// normal order reduction and the interpreter agree on random closed terms
+func TestGenerateClosedEvaluation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		term := GenerateClosed(2+rng.Intn(12), rng)
+		if size(term) < 2 || len(freeVariables(term)) != 0 {
+			t.Fatalf("expected a closed term, but got %v", format(term))
+		}
+		normal, err := normalize(term, 100)
+		if err != nil {
+			continue
+		}
+		interpreter := Interpreter{Ast: term, MaxSteps: 1000}
+		value, err := interpreter.Interpret(Environment{})
+		if err != nil {
+			t.Errorf("%v: %v", format(term), err)
+			continue
+		}
+		if len(Diff(value, normal, true)) != 0 {
+			t.Errorf("%v: interpreted to %v, but reduces to %v", format(term), format(value), format(normal))
+		}
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	terms := []string{}
+	EnumerateClosed(4, func(term Expression) bool {
+		terms = append(terms, format(term))
+		return true
+	})
+	expected := "𝞴a.a | 𝞴a b.a | 𝞴a b.b | 𝞴a b c.a | 𝞴a b c.b | 𝞴a b c.c | 𝞴a.a a"
+	if res := strings.Join(terms, " | "); res != expected {
+		t.Errorf("expected %v, but got %v", expected, res)
+	}
+	for n := 2; n <= 8; n++ {
+		count, normal := 0, 0
+		EnumerateClosed(n, func(term Expression) bool {
+			if size(term) == n {
+				count += 1
+			}
+			return true
+		})
+		EnumerateNormal(n, func(term Expression) bool {
+			if _, ok := reduceStep(term); ok {
+				t.Errorf("expected a normal form, but got %v", format(term))
+			}
+			if size(term) == n {
+				normal += 1
+			}
+			return true
+		})
+		if int64(count) != countTerms(n, 0).Int64() || normal > count {
+			t.Errorf("size %v: expected %v terms, but got %v, %v of them normal", n, countTerms(n, 0), count, normal)
+		}
+	}
+	first := 0
+	EnumerateNormal(100, func(term Expression) bool {
+		first += 1
+		return first < 3
+	})
+	if first != 3 {
+		t.Errorf("expected enumeration to stop after 3 terms, but got %v", first)
+	}
+}
+
+// the terms a seed gives are pinned, so a corpus or quiz generated from it can
+// be generated again on any platform
+func TestGenerateReproducible(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	for _, expected := range []string{
+		"𝞴a b.(𝞴c d.b (𝞴e.d)) (𝞴c d.a)",
+		"𝞴a b.(𝞴c.b (𝞴d e.c e)) a",
+		"𝞴a.(𝞴b c d.(𝞴e.e) c a) a",
+	} {
+		if term := format(GenerateClosed(12, rng)); term != expected {
+			t.Errorf("expected %v, but got %v", expected, term)
+		}
+	}
+	expected := "𝞴d e f g.(𝞴h.a (d a (g (b (𝞴i.f h i h (𝞴j.(𝞴k.d h) e))))) (𝞴i.h) (d h)) e"
+	if term := format(GenerateOpen(40, 3, rng)); term != expected {
+		t.Errorf("expected %v, but got %v", expected, term)
+	}
+}
@@ -0,0 +1,83 @@
+package lambda
+
+import "fmt"
+
+// defaultGradeSteps bounds normalizing answers when a spec gives no limit
+const defaultGradeSteps = 100000
+
+// Exercise is a prompt along with a term every correct answer is equivalent to
+type Exercise struct {
+	Name      string `json:"name"`
+	Prompt    string `json:"prompt"`
+	Reference string `json:"reference"`
+}
+
+// GradeSpec lists the exercises of an assignment, read from the JSON given to lambda grade
+type GradeSpec struct {
+	Exercises []Exercise `json:"exercises"`
+	MaxSteps  int        `json:"maxSteps"`
+	// Traces attaches the reduction of every incorrect answer to its result
+	Traces bool `json:"traces"`
+}
+
+// ExerciseResult is how one answer fared, Message says why it failed
+type ExerciseResult struct {
+	Exercise string `json:"exercise"`
+	Passed   bool   `json:"passed"`
+	Message  string `json:"message,omitempty"`
+	// Trace and Divergence are only filled for incorrect answers when the spec
+	// asks for traces. Divergence is the first place the eta reduced normal form
+	// differs from the reference's, as path: answer, expected reference.
+	Trace      []string `json:"trace,omitempty"`
+	Divergence string   `json:"divergence,omitempty"`
+}
+
+// GradeReport is one student's results, in the order of the spec
+type GradeReport struct {
+	Student string           `json:"student"`
+	Score   int              `json:"score"`
+	Results []ExerciseResult `json:"results"`
+}
+
+// Grade checks a student's answers, by exercise name, against the references of
+// spec, up to beta-eta equivalence. Errors in the spec itself are returned, while
+// problems with an answer are reported as a failed exercise.
+func Grade(spec GradeSpec, student string, answers map[string]string) (GradeReport, error) {
+	maxSteps := spec.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultGradeSteps
+	}
+	report := GradeReport{Student: student, Results: []ExerciseResult{}}
+	for _, exercise := range spec.Exercises {
+		reference, err := parse(exercise.Reference)
+		if err != nil {
+			return report, fmt.Errorf("reference for %v: %w", exercise.Name, err)
+		}
+		res := ExerciseResult{Exercise: exercise.Name}
+		answer, ok := answers[exercise.Name]
+		if !ok {
+			res.Message = "no answer"
+		} else if exp, err := parse(answer); err != nil {
+			res.Message = fmt.Sprintf("syntax error: %v", err)
+		} else if equivalent, err := Equivalent(exp, reference, maxSteps); err != nil {
+			res.Message = fmt.Sprintf("no normal form: %v", err)
+		} else if !equivalent {
+			normal, _ := normalize(exp, maxSteps)
+			expected, _ := normalize(reference, maxSteps)
+			normal, expected = Simplify(normal), Simplify(expected)
+			res.Message = fmt.Sprintf("normal form %v, expected %v", format(normal), format(expected))
+			if spec.Traces {
+				res.Trace = TraceProgram(answer, EvalOptions{MaxSteps: maxSteps}).Trace
+				if differences := Diff(normal, expected, true); len(differences) > 0 {
+					d := differences[0]
+					res.Divergence = fmt.Sprintf("%v: %v, expected %v", d.Path, format(d.Left), format(d.Right))
+				}
+			}
+		} else {
+			res.Passed = true
+			report.Score += 1
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report, nil
+}
@@ -0,0 +1,66 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGrade(t *testing.T) {
+	spec := GradeSpec{
+		Exercises: []Exercise{
+			{"two", "the church numeral 2", "𝞴f x.f (f x)"},
+			{"true", "church true", "𝞴x y.x"},
+			{"succ", "the successor of church numerals", "𝞴n f x.f (n f x)"},
+			{"omega", "a term without a normal form", "𝞴x.x"},
+		},
+		MaxSteps: 1000,
+	}
+	answers := map[string]string{
+		"two":   "let one = 𝞴s z.s z in 𝞴f x.one f (f x)",
+		"true":  "𝞴x y.y",
+		"omega": "(𝞴x.x x) (𝞴x.x x)",
+	}
+	report, err := Grade(spec, "alice", answers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := []string{}
+	for _, r := range report.Results {
+		res = append(res, fmt.Sprintf("%v %v %v", r.Exercise, r.Passed, r.Message))
+	}
+	expected := strings.Join([]string{
+		"two true ",
+		"true false normal form 𝞴x y.y, expected 𝞴x y.x",
+		"succ false no answer",
+		"omega false no normal form: " + ErrStepLimit.Error(),
+	}, "\n")
+	if strings.Join(res, "\n") != expected || report.Score != 1 {
+		t.Errorf("expected %v, but got %v (score %v)", expected, strings.Join(res, "\n"), report.Score)
+	}
+	spec.Exercises = append(spec.Exercises, Exercise{"broken", "", "𝞴x."})
+	if _, err := Grade(spec, "alice", answers); err == nil {
+		t.Errorf("expected an error for a broken reference, but got none")
+	}
+}
+
+func TestGradeTraces(t *testing.T) {
+	spec := GradeSpec{
+		Exercises: []Exercise{{"two", "the church numeral 2", "𝞴f x.f (f x)"}},
+		Traces:    true,
+	}
+	report, err := Grade(spec, "bob", map[string]string{"two": "(𝞴n f x.f (n f x)) (𝞴f x.x)"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := report.Results[0]
+	trace := "(𝞴n f x.f (n f x)) (𝞴f x.x) | 𝞴f x.f ((𝞴f x.x) f x) | 𝞴f x.f ((𝞴x.x) x) | 𝞴f x.f x"
+	if strings.Join(res.Trace, " | ") != trace {
+		t.Errorf("expected %v, but got %v", trace, strings.Join(res.Trace, " | "))
+	}
+	// 𝞴f x.f x eta reduces to 𝞴f.f
+	divergence := "/body: f, expected 𝞴x.f (f x)"
+	if res.Divergence != divergence {
+		t.Errorf("expected %v, but got %v", divergence, res.Divergence)
+	}
+}
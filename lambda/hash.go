@@ -0,0 +1,13 @@
+package lambda
+
+import "hash/fnv"
+
+// Hash hashes e the same as every term equal to it up to renaming of bound
+// variables, as it hashes the canonical serialization of e. Terms with
+// different hashes differ, so a hash decides quickly that they do, and keys
+// caches of terms.
+func Hash(e Expression) uint64 {
+	h := fnv.New64a()
+	writeCanonical(h, e, nil)
+	return h.Sum64()
+}
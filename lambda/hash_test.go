@@ -0,0 +1,35 @@
+package lambda
+
+import "testing"
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"𝞴x.x", "𝞴y.y", true},
+		{"𝞴x y.x", "𝞴a b.a", true},
+		{"𝞴x y.x", "𝞴x y.y", false},
+		{"let x = f in x x", "let y = f in y y", true},
+		{"𝞴x.y", "𝞴x.z", false},
+		// a free variable hashes by its name, not as a binder would
+		{"𝞴x.x", "𝞴y.x", false},
+		{"(𝞴x.x) z", "𝞴x.x z", false},
+		{"'id = 𝞴x.x", "'id = 𝞴y.y", true},
+		{"'id = 𝞴x.x", "'i = 𝞴x.x", false},
+		{"ab c", "a bc", false},
+	}
+	for _, test := range tests {
+		a, err := parse(test.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := parse(test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if equal := Hash(a) == Hash(b); equal != test.equal {
+			t.Errorf("expected hashes of %v and %v equal %v, but got %v", test.a, test.b, test.equal, equal)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ANSI escapes for the redex of a step and the term that replaced it
+const (
+	ansiRedex      = "\033[1;31m"
+	ansiContractum = "\033[1;32m"
+	ansiReset      = "\033[0m"
+)
+
+// ANSI escapes for the parts of an input echoed back
+const (
+	ansiKeyword = "\033[1;35m"
+	ansiBinder  = "\033[33m"
+)
+
+// ANSI escapes for the paths of a diff and the two sides of each difference
+const (
+	ansiDiffPath = "\033[1m"
+	ansiRemoved  = "\033[31m"
+	ansiAdded    = "\033[32m"
+)
+
+// ansiParens color parentheses by how deeply they nest, so a pair matches
+var ansiParens = []string{"\033[36m", "\033[34m", "\033[32m"}
+
+// highlightInput colors the keywords, binders and parentheses of the program
+// text, leaving the rest of it as it was typed. Text that doesn't scan is
+// left as it is.
+func highlightInput(text string) string {
+	program := []rune(text)
+	scanner := Scanner{Program: program}
+	tokens, err := scanner.Scan()
+	if err != nil {
+		return text
+	}
+	var b strings.Builder
+	prev, depth := 0, 0
+	// binders is whether the identifiers next are bound: after 𝞴 up to its
+	// dot, or the one after let or '
+	binders, one := false, false
+	for _, t := range tokens {
+		b.WriteString(string(program[prev:t.start]))
+		prev = t.end
+		color := ""
+		switch t.tokenType {
+		case lambda:
+			color, binders = ansiKeyword, true
+		case dot:
+			color, binders = ansiKeyword, false
+		case let, quote:
+			color, one = ansiKeyword, true
+		case in:
+			color = ansiKeyword
+		case identifier:
+			if binders || one {
+				color, one = ansiBinder, false
+			}
+		case leftParen:
+			color = ansiParens[depth%len(ansiParens)]
+			depth += 1
+		case rightParen:
+			// a parenthesis closing none is left plain
+			if depth > 0 {
+				depth -= 1
+				color = ansiParens[depth%len(ansiParens)]
+			}
+		}
+		if color == "" {
+			b.WriteString(string(program[t.start:t.end]))
+		} else {
+			b.WriteString(color + string(program[t.start:t.end]) + ansiReset)
+		}
+	}
+	b.WriteString(string(program[prev:]))
+	return b.String()
+}
+
+// formatMarked prints exp as format does, wrapping the subterm at path, as in
+// Diff without the leading slash for the root, between open and close
+func formatMarked(exp Expression, path, open, close string) string {
+	if path == "" {
+		return open + format(exp) + close
+	}
+	step, rest := path[1:], ""
+	if i := strings.Index(step, "/"); i >= 0 {
+		step, rest = step[:i], step[i:]
+	}
+	switch exp := exp.(type) {
+	case binding:
+		if step == "value" {
+			return fmt.Sprintf("let %v = %v in %v", exp.name, markedValue(exp.value, rest, open, close), format(exp.body))
+		}
+		return fmt.Sprintf("let %v = %v in %v", exp.name, formatValue(exp.value), formatMarked(exp.body, rest, open, close))
+	case replBinding:
+		return fmt.Sprintf("'%v = %v", exp.name, markedValue(exp.value, rest, open, close))
+	case abstraction:
+		// binders merge as in format, up to the marked subterm
+		params := []string{exp.param.identifier}
+		body := exp.expr
+		for strings.HasPrefix(rest, "/body") {
+			abs, ok := body.(abstraction)
+			if !ok {
+				break
+			}
+			params = append(params, abs.param.identifier)
+			body, rest = abs.expr, rest[len("/body"):]
+		}
+		return fmt.Sprintf("𝞴%v.%v", strings.Join(params, " "), formatMarked(body, rest, open, close))
+	case application:
+		left, right := format(exp.left), formatAtom(exp.right)
+		if _, ok := exp.left.(application); !ok {
+			left = formatAtom(exp.left)
+		}
+		if step == "fn" {
+			left = formatMarked(exp.left, rest, open, close)
+			if _, ok := exp.left.(application); !ok {
+				left = markedAtom(exp.left, left)
+			}
+		} else {
+			right = markedAtom(exp.right, formatMarked(exp.right, rest, open, close))
+		}
+		return fmt.Sprintf("%v %v", left, right)
+	default:
+		return format(exp)
+	}
+}
+
+func markedValue(exp Expression, path, open, close string) string {
+	marked := formatMarked(exp, path, open, close)
+	switch exp.(type) {
+	case binding, replBinding:
+		return "(" + marked + ")"
+	default:
+		return marked
+	}
+}
+
+// markedAtom parenthesizes marked as formatAtom would exp
+func markedAtom(exp Expression, marked string) string {
+	switch exp.(type) {
+	case variable, freeVariable:
+		return marked
+	default:
+		return "(" + marked + ")"
+	}
+}
+
+// markedSpan finds where the subterm at path is in format(exp), in runes
+func markedSpan(exp Expression, path string) [2]int {
+	marked := []rune(formatMarked(exp, path, "\x00", "\x01"))
+	span := [2]int{}
+	for i, c := range marked {
+		switch c {
+		case '\x00':
+			span[0] = i
+		case '\x01':
+			span[1] = i - 1
+		}
+	}
+	return span
+}
+
+// sideBySide prints a step as the term before it and the term after it, with
+// the redex and what replaced it highlighted in ANSI color, or between ⟨ and ⟩
+// without color; width pads the term before to line up a column of steps
+func sideBySide(before, after Expression, c contraction, width int, color bool) string {
+	redexOpen, contractumOpen, close := "⟨", "⟨", "⟩"
+	if color {
+		redexOpen, contractumOpen, close = ansiRedex, ansiContractum, ansiReset
+	}
+	left := formatMarked(before, c.path, redexOpen, close)
+	padding := width - len([]rune(format(before)))
+	if !color {
+		padding -= 2
+	}
+	if padding < 0 {
+		padding = 0
+	}
+	return fmt.Sprintf("%v%v │ %v", left, strings.Repeat(" ", padding), formatMarked(after, c.path, contractumOpen, close))
+}
@@ -0,0 +1,25 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHighlightInput(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"let  f = 𝞴x y.x in f", "<k>let</>  <b>f</> = <k>𝞴</><b>x</> <b>y</><k>.</>x <k>in</> f"},
+		{"((a) b)", "<0>(</><1>(</>a<1>)</> b<0>)</>"},
+		{"'id = \\x.x)", "<k>'</><b>id</> = <k>\\</><b>x</><k>.</>x)"},
+		// text that doesn't scan is echoed as it is
+		{"𝞴x.x!", "𝞴x.x!"},
+	}
+	names := strings.NewReplacer(ansiKeyword, "<k>", ansiBinder, "<b>", ansiParens[0], "<0>", ansiParens[1], "<1>", ansiReset, "</>")
+	for _, test := range tests {
+		if res := names.Replace(highlightInput(test.program)); res != test.expected {
+			t.Errorf("%v: expected %v, but got %v", test.program, test.expected, res)
+		}
+	}
+}
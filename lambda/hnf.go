@@ -0,0 +1,86 @@
+package lambda
+
+// HeadNormalForm is a term 𝞴x1 ... xn.y M1 ... Mk with no redex at its head
+type HeadNormalForm struct {
+	Binders []string
+	Head    string
+	Args    []Expression
+	// Steps is how many head reductions it took to get here
+	Steps int
+}
+
+// Expression puts the parts back together
+func (h HeadNormalForm) Expression() Expression {
+	exp := unspine(variable{h.Head}, h.Args)
+	for i := len(h.Binders) - 1; i >= 0; i-- {
+		exp = abstraction{variable{h.Binders[i]}, exp}
+	}
+	return exp
+}
+
+// HeadBound reports whether the head variable is one of the binders
+func (h HeadNormalForm) HeadBound() bool {
+	for _, b := range h.Binders {
+		if b == h.Head {
+			return true
+		}
+	}
+	return false
+}
+
+// HeadNormalize head-reduces e until it is in head normal form, which it
+// reaches exactly when it is solvable, failing with ErrStepLimit after
+// maxSteps. Unlike normalize, it never reduces the arguments.
+func HeadNormalize(e Expression, maxSteps int) (HeadNormalForm, error) {
+	if v, ok := e.(replBinding); ok {
+		e = v.value
+	}
+	// the arguments are kept last first, so the one a step consumes is
+	// popped off the end and the arguments of its result pushed there
+	h := HeadNormalForm{}
+	head, args := spine(e)
+	reverse(args)
+	for steps := 0; ; steps++ {
+		for len(args) == 0 {
+			abs, ok := head.(abstraction)
+			if !ok {
+				break
+			}
+			h.Binders = append(h.Binders, abs.param.identifier)
+			head, args = spine(abs.expr)
+			reverse(args)
+		}
+		var next Expression
+		switch exp := head.(type) {
+		case binding:
+			// a let at the head is a redex whatever it is applied to
+			next = contract(exp.name, exp.body, exp.value, true, false).result
+		case abstraction:
+			next = contract(exp.param, exp.expr, args[len(args)-1], false, false).result
+		}
+		if next == nil {
+			h.Head, _ = variableName(head)
+			h.Args = reverse(args)
+			h.Steps = steps
+			return h, nil
+		}
+		if steps == maxSteps {
+			return HeadNormalForm{Steps: steps}, ErrStepLimit
+		}
+		if _, ok := head.(abstraction); ok {
+			args = args[:len(args)-1]
+		}
+		var inner []Expression
+		head, inner = spine(next)
+		reverse(inner)
+		args = append(args, inner...)
+	}
+}
+
+// reverse reverses args in place, returning them
+func reverse(args []Expression) []Expression {
+	for i, j := 0, len(args)-1; i < j; i, j = i+1, j-1 {
+		args[i], args[j] = args[j], args[i]
+	}
+	return args
+}
@@ -0,0 +1,37 @@
+package lambda
+
+import "testing"
+
+func TestHeadNormalize(t *testing.T) {
+	hnfCases := []struct {
+		program string
+		hnf     string
+		steps   int
+		args    int
+	}{
+		{"x", "x", 0, 0},
+		{"(𝞴x y.y x) a", "𝞴y.y a", 1, 1},
+		// the argument is never normalized, it has no normal form
+		{"(𝞴x.𝞴f.f x) ((𝞴x.x x) (𝞴x.x x))", "𝞴f.f ((𝞴x.x x) (𝞴x.x x))", 1, 1},
+		{"let k = 𝞴x y.x in k a b", "a", 3, 0},
+		// a let applied to arguments is contracted too
+		{"(let i = 𝞴x.x in i) a", "a", 2, 0},
+	}
+	for _, tt := range hnfCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			h, err := HeadNormalize(exp, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hnf := format(h.Expression()); hnf != tt.hnf || h.Steps != tt.steps || len(h.Args) != tt.args {
+				t.Errorf("expected %v after %v steps with %v arguments, but got %v after %v with %v",
+					tt.hnf, tt.steps, tt.args, hnf, h.Steps, len(h.Args))
+			}
+		})
+	}
+	omega, _ := parse("(𝞴x.x x) (𝞴x.x x) y")
+	if _, err := HeadNormalize(omega, 100); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+}
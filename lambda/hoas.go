@@ -0,0 +1,185 @@
+package lambda
+
+import "fmt"
+
+// Value is a term in higher-order abstract syntax: abstractions are Go
+// functions, so substitution is function application and can't capture
+// anything. Values are normalized by evaluating them and reading the result
+// back as an Expression.
+type Value interface {
+	isValue()
+}
+
+// Lam is an abstraction, its body computed from the argument
+type Lam func(Value) Value
+
+// neutral is a variable applied to arguments, which can't reduce further
+type neutral struct {
+	head string
+	args []Value
+}
+
+// thunk is an argument not evaluated yet, so ones that are never used, and
+// may not terminate, are never evaluated
+type thunk struct {
+	compute func() Value
+	value   Value
+}
+
+func (Lam) isValue()      {}
+func (*neutral) isValue() {}
+func (*thunk) isValue()   {}
+
+// Var is a free variable
+func Var(name string) Value {
+	return &neutral{head: name}
+}
+
+func force(v Value) Value {
+	for {
+		t, ok := v.(*thunk)
+		if !ok {
+			return v
+		}
+		if t.compute != nil {
+			t.value, t.compute = t.compute(), nil
+		}
+		v = t.value
+	}
+}
+
+// Apply applies f to x
+func Apply(f, x Value) Value {
+	switch f := force(f).(type) {
+	case Lam:
+		return f(x)
+	case *neutral:
+		return &neutral{f.head, append(f.args[:len(f.args):len(f.args)], x)}
+	default:
+		panic(fmt.Sprintf("apply %T", f))
+	}
+}
+
+// hoas evaluates and reads back, counting applications of abstractions and
+// how deeply it nests, failing as the interpreter does past its limits
+type hoas struct {
+	maxSteps int
+	steps    int
+	depth    int
+}
+
+func (h *hoas) enter() {
+	h.depth += 1
+	if h.depth > defaultMaxDepth {
+		panic(evalError{ErrDepthExceeded})
+	}
+}
+
+func (h *hoas) apply(f, x Value) Value {
+	h.enter()
+	defer func() { h.depth -= 1 }()
+	if _, ok := force(f).(Lam); ok {
+		h.steps += 1
+		if h.maxSteps > 0 && h.steps > h.maxSteps {
+			panic(evalError{ErrStepLimit})
+		}
+	}
+	return Apply(f, x)
+}
+
+// eval turns exp into a value, its free variables looked up in env
+func (h *hoas) eval(exp Expression, env map[string]Value) Value {
+	switch exp := exp.(type) {
+	case binding:
+		value := &thunk{compute: func() Value { return h.eval(exp.value, env) }}
+		return h.eval(exp.body, extend(env, exp.name.identifier, value))
+	case replBinding:
+		return h.eval(exp.value, env)
+	case abstraction:
+		return Lam(func(x Value) Value {
+			return h.eval(exp.expr, extend(env, exp.param.identifier, x))
+		})
+	case application:
+		arg := &thunk{compute: func() Value { return h.eval(exp.right, env) }}
+		return h.apply(h.eval(exp.left, env), arg)
+	case strictApplication:
+		return h.apply(h.eval(exp.left, env), h.eval(exp.right, env))
+	default:
+		name, _ := variableName(exp)
+		if v, ok := env[name]; ok {
+			return v
+		}
+		return Var(name)
+	}
+}
+
+func extend(env map[string]Value, name string, v Value) map[string]Value {
+	inner := make(map[string]Value, len(env)+1)
+	for k, v := range env {
+		inner[k] = v
+	}
+	inner[name] = v
+	return inner
+}
+
+// readback turns v into a normal form, naming the binder of the nth nested
+// abstraction #n, which no variable of the source syntax can be called
+func (h *hoas) readback(v Value, level int) Expression {
+	h.enter()
+	defer func() { h.depth -= 1 }()
+	switch v := force(v).(type) {
+	case Lam:
+		name := fmt.Sprintf("#%v", level)
+		return abstraction{variable{name}, h.readback(h.apply(v, Var(name)), level+1)}
+	case *neutral:
+		var exp Expression = variable{v.head}
+		for _, arg := range v.args {
+			exp = application{exp, h.readback(arg, level)}
+		}
+		return exp
+	default:
+		panic(fmt.Sprintf("read back %T", v))
+	}
+}
+
+func (h *hoas) run(f func() Expression) (exp Expression, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(evalError)
+			if !ok {
+				panic(r)
+			}
+			err = e.err
+		}
+	}()
+	// the binders #0, #1, ... get readable names that aren't free in the result
+	return Canonicalize(f()), nil
+}
+
+// ToHOAS converts e to a value, its free variables becoming Vars
+func ToHOAS(e Expression) Value {
+	h := &hoas{}
+	return h.eval(markStrict(e), map[string]Value{})
+}
+
+// FromHOAS reads v back as its normal form, with bound variables named a, b,
+// c, ... in the order of their binders. It fails with ErrStepLimit after
+// maxSteps applications of abstractions, 0 means no limit.
+func FromHOAS(v Value, maxSteps int) (Expression, error) {
+	h := &hoas{maxSteps: maxSteps}
+	return h.run(func() Expression { return h.readback(v, 0) })
+}
+
+// NormalizeHOAS finds the normal form of e by evaluating it as a value, much
+// faster than reducing it a step at a time, failing with ErrStepLimit after
+// maxSteps applications of abstractions, 0 means no limit
+func NormalizeHOAS(e Expression, maxSteps int) (Expression, error) {
+	h := &hoas{maxSteps: maxSteps}
+	// arguments found to be needed aren't suspended
+	e = markStrict(e)
+	if v, ok := e.(replBinding); ok {
+		value, err := h.run(func() Expression { return h.readback(h.eval(v.value, map[string]Value{}), 0) })
+		return replBinding{v.name, value}, err
+	}
+	return h.run(func() Expression { return h.readback(h.eval(e, map[string]Value{}), 0) })
+}
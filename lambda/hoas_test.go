@@ -0,0 +1,56 @@
+package lambda
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNormalizeHOAS(t *testing.T) {
+	hoasCases := []struct {
+		program string
+		normal  string
+	}{
+		{"(𝞴x.x) y", "y"},
+		// the binder is renamed away from the free a
+		{"(𝞴x y.x) a", "𝞴b.a"},
+		{"let two = 𝞴f x.f (f x) in two two", "𝞴a b.a (a (a (a b)))"},
+		// the argument without a normal form is never used
+		{"(𝞴x y.y) ((𝞴x.x x) (𝞴x.x x))", "𝞴a.a"},
+	}
+	for _, tt := range hoasCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			normal, err := NormalizeHOAS(exp, 1000)
+			if err != nil || format(normal) != tt.normal {
+				t.Errorf("expected %v, but got %v %v", tt.normal, format(normal), err)
+			}
+		})
+	}
+	omega, _ := parse("(𝞴x.x x) (𝞴x.x x)")
+	if _, err := NormalizeHOAS(omega, 100); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		term := GenerateClosed(2+rng.Intn(12), rng)
+		normal, err := normalize(term, 100)
+		if err != nil {
+			continue
+		}
+		res, err := NormalizeHOAS(term, 0)
+		if err != nil || len(Diff(res, normal, true)) != 0 {
+			t.Errorf("%v: expected %v, but got %v %v", format(term), format(normal), format(res), err)
+		}
+	}
+}
+
+func TestFromHOAS(t *testing.T) {
+	// 𝞴f x.f (f x) written directly in Go
+	two := Lam(func(f Value) Value {
+		return Lam(func(x Value) Value { return Apply(f, Apply(f, x)) })
+	})
+	exp, err := FromHOAS(Apply(two, Var("g")), 0)
+	if err != nil || format(exp) != "𝞴a.g (g a)" {
+		t.Errorf("expected 𝞴a.g (g a), but got %v %v", format(exp), err)
+	}
+}
@@ -0,0 +1,167 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A hole _ stands for a part of a term not written yet. It evaluates to
+// itself, so the rest of the term can still be run, and :holes lists what may
+// fill each hole: the names bound around it, and in the dependently typed
+// mode their types and the type the hole should have.
+
+// hole is _ in a term
+type hole struct{}
+
+func (hole) isExpression()  {}
+func (hole) String() string { return "_" }
+
+// dHole is _ in a term of the dependently typed mode, numbered in the order
+// holes are parsed
+type dHole struct {
+	n int
+}
+
+func (dHole) isDTerm()       {}
+func (dHole) String() string { return "_" }
+
+// Hole is a hole at Path, a Diff path, beneath the binders of Scope,
+// innermost last
+type Hole struct {
+	Path  string
+	Scope []string
+	// Types are the types of Scope, and Type the type the hole should have,
+	// in the dependently typed mode
+	Types []DTerm
+	Type  DTerm
+}
+
+func (h Hole) String() string {
+	if h.Type == nil {
+		if len(h.Scope) == 0 {
+			return fmt.Sprintf("_ at %v, with nothing in scope", h.Path)
+		}
+		return fmt.Sprintf("_ at %v, with %v in scope", h.Path, strings.Join(h.Scope, " "))
+	}
+	if len(h.Scope) == 0 {
+		return fmt.Sprintf("_ at %v : %v", h.Path, h.Type)
+	}
+	entries := make([]string, len(h.Scope))
+	for i, name := range h.Scope {
+		entries[i] = fmt.Sprintf("%v : %v", name, h.Types[i])
+	}
+	return fmt.Sprintf("_ at %v : %v, with %v", h.Path, h.Type, strings.Join(entries, ", "))
+}
+
+// Holes finds the holes of e, in the order they appear
+func Holes(e Expression) []Hole {
+	holes := []Hole{}
+	var walk func(e Expression, path string, scope []string)
+	walk = func(e Expression, path string, scope []string) {
+		switch e := e.(type) {
+		case binding:
+			walk(e.value, path+"/value", scope)
+			walk(e.body, path+"/body", inScope(scope, e.name.identifier))
+		case replBinding:
+			walk(e.value, path+"/value", scope)
+		case abstraction:
+			walk(e.expr, path+"/body", inScope(scope, e.param.identifier))
+		case application:
+			walk(e.left, path+"/fn", scope)
+			walk(e.right, path+"/arg", scope)
+		case quoted:
+			walk(e.expr, path+"/quote", scope)
+		case unquoted:
+			walk(e.expr, path+"/splice", scope)
+		case hole:
+			holes = append(holes, Hole{Path: rootPath(path), Scope: scope})
+		}
+	}
+	walk(e, "", []string{})
+	return holes
+}
+
+// inScope adds name to a copy of scope, innermost, dropping the binder it
+// shadows
+func inScope(scope []string, name string) []string {
+	inner := []string{}
+	for _, s := range scope {
+		if s != name {
+			inner = append(inner, s)
+		}
+	}
+	return append(inner, name)
+}
+
+// dHolePaths finds the path to each hole of t, by number
+func dHolePaths(t DTerm) map[int]string {
+	paths := map[int]string{}
+	var walk func(t DTerm, path string)
+	walk = func(t DTerm, path string) {
+		switch t := t.(type) {
+		case dPi:
+			walk(t.domain, path+"/domain")
+			walk(t.codomain, path+"/codomain")
+		case dLam:
+			if t.domain != nil {
+				walk(t.domain, path+"/domain")
+			}
+			walk(t.body, path+"/body")
+		case dApp:
+			walk(t.fn, path+"/fn")
+			walk(t.arg, path+"/arg")
+		case dAnn:
+			walk(t.term, path+"/term")
+			walk(t.typ, path+"/type")
+		case dLet:
+			if t.typ != nil {
+				walk(t.typ, path+"/type")
+			}
+			walk(t.value, path+"/value")
+			walk(t.body, path+"/body")
+		case dHole:
+			// a domain shared by several parameters is reached once per
+			// parameter, but is where the first of them is
+			if _, ok := paths[t.n]; !ok {
+				paths[t.n] = rootPath(path)
+			}
+		}
+	}
+	walk(t, "")
+	return paths
+}
+
+// fill records that the hole h should have type typ in the current context
+func (c *dChecker) fill(h dHole, typ DTerm) {
+	if c.holes == nil {
+		return
+	}
+	found := Hole{Scope: []string{}, Types: []DTerm{}, Type: c.normalize(typ)}
+	for _, e := range c.context[c.scope:] {
+		// the _ of A → B can't be referred to
+		if e.name != "_" {
+			found.Scope = append(found.Scope, e.name)
+			found.Types = append(found.Types, c.normalize(e.typ))
+		}
+	}
+	c.holes[h.n] = found
+}
+
+// Holes type checks t against the definitions so far, finding its holes in
+// the order they appear with the types they should have
+func (d *Dependent) Holes(t DTerm) ([]Hole, error) {
+	c := d.checker()
+	c.holes, c.scope = map[int]Hole{}, len(c.context)
+	if err := c.run(func() { c.infer(t) }); err != nil {
+		return nil, err
+	}
+	paths := dHolePaths(t)
+	holes := []Hole{}
+	for n := 0; n < len(paths); n++ {
+		if h, ok := c.holes[n]; ok {
+			h.Path = paths[n]
+			holes = append(holes, h)
+		}
+	}
+	return holes, nil
+}
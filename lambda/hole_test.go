@@ -0,0 +1,85 @@
+package lambda
+
+import "testing"
+
+func TestHoles(t *testing.T) {
+	exp, err := parse("let f = 𝞴x y.x _ in 𝞴x.f (_ x)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"_ at /value/body/body/arg, with x y in scope",
+		"_ at /body/body/arg/fn, with f x in scope",
+	}
+	holes := Holes(exp)
+	if len(holes) != len(expected) {
+		t.Fatalf("expected %v, but got %v", expected, holes)
+	}
+	for i := range expected {
+		if holes[i].String() != expected[i] {
+			t.Errorf("expected %v, but got %v", expected[i], holes[i])
+		}
+	}
+	// a hole is a value of its own
+	value, err := (&Interpreter{Ast: exp}).Interpret(Environment{})
+	if err != nil || format(value) != "𝞴x y._ x _" {
+		t.Errorf("expected 𝞴x y._ x _, but got %v, %v", value, err)
+	}
+}
+
+func TestDependentHoles(t *testing.T) {
+	d := Dependent{}
+	tests := []struct {
+		program  string
+		expected []string
+		err      string
+	}{
+		{"(𝞴(A : Type) (x : A).x : Π(A : Type).A → A)", nil, ""},
+		{"(𝞴A x._ : Π(A : Type).A → A)", []string{"_ at /term/body/body : A, with A : Type, x : A"}, ""},
+		{"(𝞴(A : Type) (f : A → A) (x : A).f _ : Π(A : Type).(A → A) → A → A)", []string{"_ at /term/body/body/body/arg : A, with A : Type, f : A → A, x : A"}, ""},
+		{"(_ : Type)", []string{"_ at /term : Type"}, ""},
+		{"_ Type", nil, "can't infer the type of _, annotate it as in (_ : A)"},
+	}
+	for _, test := range tests {
+		term, err := ParseDependent(test.program)
+		if err != nil {
+			t.Fatalf("%v: %v", test.program, err)
+		}
+		holes, err := d.Holes(term)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%v: expected %v, but got %v", test.program, test.err, err)
+			}
+			continue
+		}
+		if err != nil || len(holes) != len(test.expected) {
+			t.Errorf("%v: expected %v, but got %v, %v", test.program, test.expected, holes, err)
+			continue
+		}
+		for i := range holes {
+			if holes[i].String() != test.expected[i] {
+				t.Errorf("%v: expected %v, but got %v", test.program, test.expected[i], holes[i])
+			}
+		}
+	}
+}
+
+func TestReplHoles(t *testing.T) {
+	res := runRepl(
+		":holes 𝞴f.f _",
+		":holes 𝞴f.f",
+		":set dependent on",
+		":holes (𝞴(A : Type) (x : A)._ : Π(A : Type).A → A)",
+	)
+	expected := []string{
+		"_ at /body/arg, with f in scope\n",
+		"no holes\n",
+		"",
+		"_ at /term/body/body : A, with A : Type, x : A\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package lambda
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// rpcMessage is an incoming JSON-RPC 2.0 request or notification, the latter has no ID
+type rpcMessage struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// rpcConn reads and writes messages framed with a Content-Length header, as in LSP
+type rpcConn struct {
+	in  *textproto.Reader
+	out io.Writer
+}
+
+func newRPCConn(in io.Reader, out io.Writer) *rpcConn {
+	return &rpcConn{textproto.NewReader(bufio.NewReader(in)), out}
+}
+
+func (c *rpcConn) read() (rpcMessage, error) {
+	var msg rpcMessage
+	header, err := c.in.ReadMIMEHeader()
+	if err != nil {
+		return msg, err
+	}
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return msg, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.in.R, body); err != nil {
+		return msg, err
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return msg, &rpcError{rpcParseError, err.Error()}
+	}
+	return msg, nil
+}
+
+func (c *rpcConn) write(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (c *rpcConn) reply(id json.RawMessage, result interface{}, err error) error {
+	res := rpcResponse{Jsonrpc: "2.0", ID: id}
+	if err != nil {
+		e, ok := err.(*rpcError)
+		if !ok {
+			e = &rpcError{rpcInternalError, err.Error()}
+		}
+		res.Error = e
+		return c.write(res)
+	}
+	if res.Result, err = json.Marshal(result); err != nil {
+		return err
+	}
+	return c.write(res)
+}
+
+func (c *rpcConn) notify(method string, params interface{}) error {
+	return c.write(rpcNotification{"2.0", method, params})
+}
+
+// unmarshalParams decodes params, reporting failures as invalid params
+func unmarshalParams(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{rpcInvalidParams, err.Error()}
+	}
+	return nil
+}
@@ -1,10 +1,11 @@
 package lambda
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 	"strings"
+	"time"
 )
 
 type tokenType string
@@ -20,15 +21,27 @@ const (
 	equal      tokenType = "equal"
 	in         tokenType = "in"
 	quote      tokenType = "'"
+	// the annotations of the dependently typed mode
+	colon tokenType = "colon"
+	arrow tokenType = "arrow"
+	pi    tokenType = "pi"
+	// ~e splices the value of e into a quoted term
+	tilde tokenType = "tilde"
+	// _ is a hole, a part of a term not written yet
+	underscore tokenType = "underscore"
 )
 
 type token struct {
 	tokenType tokenType
 	lexeme    string
+	// offsets of the token in the program, in runes
+	start int
+	end   int
 }
 
 type Scanner struct {
 	cur     int
+	start   int
 	Program []rune
 	tokens  []token
 }
@@ -45,11 +58,11 @@ func (s *Scanner) isEnd() bool {
 	return s.cur >= len(s.Program)
 }
 
-func (s *Scanner) addToken(token token) {
-	s.tokens = append(s.tokens, token)
+func (s *Scanner) addToken(tokenType tokenType, lexeme string) {
+	s.tokens = append(s.tokens, token{tokenType, lexeme, s.start, s.cur})
 }
 
-func (s *Scanner) identifier() (token, error) {
+func (s *Scanner) identifier() (string, error) {
 	var id string
 	isLetter := func(c string) bool {
 		return c >= "a" && c <= "z" || c >= "A" && c <= "Z"
@@ -60,17 +73,22 @@ func (s *Scanner) identifier() (token, error) {
 	isArithmatic := func(c string) bool {
 		return c == "+" || c == "-" || c == "*" || c == "/"
 	}
+	// primes may follow a name, as in the fresh names x' and x''
+	isPrime := func(c string) bool {
+		return id != "" && c == "'"
+	}
 	for !s.isEnd() &&
 		(isLetter(string(s.current())) ||
 			isDigit(string(s.current())) ||
-			isArithmatic(string(s.current()))) {
+			isArithmatic(string(s.current())) ||
+			isPrime(string(s.current()))) {
 		id += string(s.current())
 		s.advance()
 	}
 	if id == "" {
-		return token{}, fmt.Errorf("%v cannot be used in identifier", string(s.current()))
+		return "", syntaxError{s.cur, fmt.Sprintf("%v cannot be used in identifier", string(s.current()))}
 	}
-	return token{identifier, id}, nil
+	return id, nil
 }
 
 func (s *Scanner) match(text string) bool {
@@ -107,47 +125,66 @@ func (s *Scanner) consumeOneOf(xs []rune) error {
 }
 
 func (s *Scanner) Scan() ([]token, error) {
-	s.Program = []rune(strings.Trim(string(s.Program), " \t\n"))
 	for !s.isEnd() {
+		s.start = s.cur
 		switch cur := s.current(); cur {
 		case ' ', '\t', '\n':
 			s.consumeOneOf([]rune{' ', '\t', '\n'})
-			s.addToken(token{whiteSpace, " "})
+			s.addToken(whiteSpace, " ")
 		case '𝞴', 'λ', '\\':
 			s.consumeOneOf([]rune{'𝞴', 'λ', '\\'})
-			s.addToken(token{lambda, "𝞴"})
+			s.addToken(lambda, "𝞴")
 		case '.':
 			s.consume(".")
-			s.addToken(token{dot, "."})
+			s.addToken(dot, ".")
 		case '(':
 			s.consume("(")
-			s.addToken(token{leftParen, "("})
+			s.addToken(leftParen, "(")
 		case ')':
 			s.consume(")")
-			s.addToken(token{rightParen, ")"})
+			s.addToken(rightParen, ")")
 		case '=':
 			s.consume("=")
-			s.addToken(token{equal, "="})
+			s.addToken(equal, "=")
 		case '\'':
 			s.consume("'")
-			s.addToken(token{quote, "'"})
+			s.addToken(quote, "'")
+		case ':':
+			s.consume(":")
+			s.addToken(colon, ":")
+		case '→':
+			s.consume("→")
+			s.addToken(arrow, "→")
+		case 'Π':
+			s.consume("Π")
+			s.addToken(pi, "Π")
+		case '~':
+			s.consume("~")
+			s.addToken(tilde, "~")
+		case '_':
+			s.consume("_")
+			s.addToken(underscore, "_")
 		default:
 			// extra space to avoid confliciton with identifier starting with "let"
-			if s.match("let") {
+			if s.match("->") {
+				s.consume("->")
+				s.addToken(arrow, "→")
+			} else if s.match("let") {
 				s.consume("let")
-				s.addToken(token{let, "let"})
+				s.addToken(let, "let")
 			} else if s.match("in") {
 				s.consume("in")
-				s.addToken(token{in, "in"})
-			} else if t, err := s.identifier(); err != nil {
+				s.addToken(in, "in")
+			} else if id, err := s.identifier(); err != nil {
 				return nil, err
 			} else {
-				s.addToken(t)
+				s.addToken(identifier, id)
 			}
 		}
 	}
+	// collapse runs of whitespace and drop it from both ends
 	whiteSpaceCollaped := []token{}
-	prevIsWhiteSpace := false
+	prevIsWhiteSpace := true
 	for _, t := range s.tokens {
 		if prevIsWhiteSpace {
 			if t.tokenType == whiteSpace {
@@ -162,54 +199,68 @@ func (s *Scanner) Scan() ([]token, error) {
 		}
 		whiteSpaceCollaped = append(whiteSpaceCollaped, t)
 	}
+	if n := len(whiteSpaceCollaped); n > 0 && whiteSpaceCollaped[n-1].tokenType == whiteSpace {
+		whiteSpaceCollaped = whiteSpaceCollaped[:n-1]
+	}
 	s.tokens = whiteSpaceCollaped
 	return s.tokens, nil
 }
 
-type expression interface {
+// syntaxError is a scan or parse error at an offset of the program
+type syntaxError struct {
+	offset  int
+	message string
+}
+
+func (e syntaxError) Error() string {
+	return e.message
+}
+
+// Expression is a parsed or evaluated lambda term
+type Expression interface {
 	isExpression()
 	String() string
 }
 
 type binding struct {
 	name  variable
-	value expression
-	body  expression
+	value Expression
+	body  Expression
 }
 
 func (binding) isExpression() {}
 func (b binding) String() string {
-	return fmt.Sprintf("let %v = %v in %v", b.name, b.value, b.body)
+	return printString(b, (*printer).print)
 }
 
 type replBinding struct {
 	name  variable
-	value expression
+	value Expression
 }
 
 func (replBinding) isExpression() {}
 func (b replBinding) String() string {
-	return fmt.Sprintf("let %v = %v", b.name, b.value)
+	return printString(b, (*printer).print)
 }
 
 type abstraction struct {
 	param variable
-	expr  expression
+	expr  Expression
 }
 
 func (abstraction) isExpression() {}
 func (a abstraction) String() string {
-	return fmt.Sprintf("(𝞴%v.%v)", a.param, a.expr)
+	return printString(a, (*printer).print)
 }
 
 type application struct {
-	left  expression
-	right expression
+	left  Expression
+	right Expression
 }
 
 func (application) isExpression() {}
 func (a application) String() string {
-	return fmt.Sprintf("(%v %v)", a.left, a.right)
+	return printString(a, (*printer).print)
 }
 
 type variable struct {
@@ -230,9 +281,48 @@ func (v freeVariable) String() string {
 	return fmt.Sprintf("%v", v.identifier)
 }
 
+// size counts the nodes of an expression
+func size(exp Expression) int {
+	switch exp := exp.(type) {
+	case binding:
+		return 1 + size(exp.value) + size(exp.body)
+	case replBinding:
+		return 1 + size(exp.value)
+	case abstraction:
+		return 1 + size(exp.expr)
+	case application:
+		return 1 + size(exp.left) + size(exp.right)
+	default:
+		return 1
+	}
+}
+
 type Parser struct {
 	cur    int
 	Tokens []token
+	// where each expression and binder came from, for editor tooling
+	spans   []span
+	binders []binder
+	// quotes is how many quotes the current token is within
+	quotes int
+}
+
+// span locates a parsed expression in the program
+type span struct {
+	exp   Expression
+	start int
+	end   int
+}
+
+// binder is a name introduced by let, ' or 𝞴, along with the part of the
+// program it scopes over and, for let and ', the value it is bound to
+type binder struct {
+	name       variable
+	start      int
+	end        int
+	scopeStart int
+	scopeEnd   int
+	value      Expression
 }
 
 func (p *Parser) current() token {
@@ -256,12 +346,6 @@ func (p *Parser) consume(tt tokenType) {
 	p.advance()
 }
 
-// func (p *Parser) consumeAll(tt tokenType) {
-// 	for !p.isEnd() && p.current().tokenType == tt {
-// 		p.consume(tt)
-// 	}
-// }
-
 func (p *Parser) consumeMaybe(tt tokenType) {
 	if !p.isEnd() && p.current().tokenType == tt {
 		p.consume(tt)
@@ -272,57 +356,157 @@ func (p *Parser) isEnd() bool {
 	return p.cur >= len(p.Tokens)
 }
 
-func (p *Parser) Parse() expression {
+// offset is where the current token starts, or where the program ends
+func (p *Parser) offset() int {
+	if p.isEnd() {
+		return p.last()
+	}
+	return p.current().start
+}
+
+// last is where the most recently consumed token ends
+func (p *Parser) last() int {
+	if p.cur == 0 || len(p.Tokens) == 0 {
+		return 0
+	}
+	return p.Tokens[minInt(p.cur, len(p.Tokens))-1].end
+}
+
+func (p *Parser) mark(exp Expression, start int) Expression {
+	p.spans = append(p.spans, span{exp, start, p.last()})
+	return exp
+}
+
+func (p *Parser) Parse() (exp Expression, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println(r)
+			err = syntaxError{p.offset(), fmt.Sprintf("%v", r)}
 		}
 	}()
+	exp = p.definition()
+	// an application leaves the whitespace before what ends it, report that
+	// instead
+	p.consumeMaybe(whiteSpace)
+	if !p.isEnd() {
+		panic(fmt.Sprintf("unexpected %v %v", p.current().tokenType, p.current().lexeme))
+	}
+	return exp, nil
+}
+
+// parse scans and parses a program
+func parse(program string) (Expression, error) {
+	scanner := Scanner{Program: []rune(program)}
+	tokens, err := scanner.Scan()
+	if err != nil {
+		return nil, err
+	}
+	parser := Parser{Tokens: tokens}
+	return parser.Parse()
+}
+
+// definition parses a program, which may define a name for the rest of the
+// session in any of the ways files and the REPL do: 'x = e, def x = e, or a
+// let x = e with no body
+func (p *Parser) definition() Expression {
+	switch {
+	case p.isDef():
+		return p.replBinding()
+	case p.current().tokenType == let:
+		return p.let(true)
+	}
 	return p.expression()
 }
 
-func (p *Parser) expression() expression {
+// isDef says whether a definition def x = e starts at the current token,
+// def being a name like any other elsewhere
+func (p *Parser) isDef() bool {
+	types := []tokenType{}
+	for i := p.cur; i < len(p.Tokens) && len(types) < 5; i++ {
+		types = append(types, p.Tokens[i].tokenType)
+	}
+	if len(types) < 4 || p.current().lexeme != "def" || types[1] != whiteSpace || types[2] != identifier {
+		return false
+	}
+	return types[3] == equal || types[3] == whiteSpace && len(types) == 5 && types[4] == equal
+}
+
+func (p *Parser) expression() Expression {
 	if p.current().tokenType == quote {
 		return p.replBinding()
 	}
 	return p.binding()
 }
 
-func (p *Parser) replBinding() expression {
-	p.consume(quote)
+func (p *Parser) replBinding() Expression {
+	start := p.offset()
+	if p.current().tokenType == quote {
+		p.consume(quote)
+	} else {
+		// def
+		p.consume(identifier)
+	}
 	p.consumeMaybe(whiteSpace)
-	v := p.variable()
+	name := p.current()
+	// an exported session defines the names it imported qualified
+	v := p.qualified()
 	p.consumeMaybe(whiteSpace)
 	p.consume(equal)
 	p.consumeMaybe(whiteSpace)
 	abs := p.abstraction()
-	return replBinding{name: v, value: abs}
+	p.binders = append(p.binders, binder{v, name.start, name.end, p.last(), p.last(), abs})
+	return p.mark(replBinding{name: v, value: abs}, start)
 }
 
-func (p *Parser) binding() expression {
+func (p *Parser) binding() Expression {
 	if p.current().tokenType == let {
-		p.consume(let)
-		p.consume(whiteSpace)
-		v := p.variable()
-		p.consumeMaybe(whiteSpace)
-		p.consume(equal)
-		p.consumeMaybe(whiteSpace)
-		abs := p.abstraction()
-		p.consume(whiteSpace)
-		p.consume(in)
-		p.consume(whiteSpace)
-		body := p.binding()
-		return binding{name: v, value: abs, body: body}
+		return p.let(false)
 	}
 	return p.abstraction()
 }
 
-func (p *Parser) abstraction() expression {
+// let parses let x = e in body, or at the top level let x = e defining x
+// for the rest of the session
+func (p *Parser) let(top bool) Expression {
+	start := p.offset()
+	p.consume(let)
+	p.consume(whiteSpace)
+	name := p.current()
+	v := p.variable()
+	p.consumeMaybe(whiteSpace)
+	p.consume(equal)
+	p.consumeMaybe(whiteSpace)
+	abs := p.abstraction()
+	if top && p.isEnd() {
+		p.binders = append(p.binders, binder{v, name.start, name.end, p.last(), p.last(), abs})
+		return p.mark(replBinding{name: v, value: abs}, start)
+	}
+	p.consume(whiteSpace)
+	p.consume(in)
+	p.consume(whiteSpace)
+	bodyStart := p.offset()
+	body := p.binding()
+	p.binders = append(p.binders, binder{v, name.start, name.end, bodyStart, p.last(), abs})
+	return p.mark(binding{name: v, value: abs, body: body}, start)
+}
+
+func (p *Parser) abstraction() Expression {
 	if p.current().tokenType == lambda {
+		start := p.offset()
 		p.consume(lambda)
+		paramsStart := p.cur
 		vars := p.variables()
+		params := p.Tokens[paramsStart:p.cur]
 		p.consume(dot)
+		// a body may start on a line of its own, as the pretty printer
+		// breaks it
+		p.consumeMaybe(whiteSpace)
+		bodyStart := p.offset()
 		exp := p.expression()
+		for _, t := range params {
+			if t.tokenType == identifier {
+				p.binders = append(p.binders, binder{variable{t.lexeme}, t.start, t.end, bodyStart, p.last(), nil})
+			}
+		}
 		// build nested abstraction
 		res := abstraction{vars[len(vars)-1], exp}
 		if len(vars) > 1 {
@@ -330,32 +514,82 @@ func (p *Parser) abstraction() expression {
 				res = abstraction{vars[i], res}
 			}
 		}
-		return res
+		return p.mark(res, start)
 	}
 	return p.application()
 }
 
-func (p *Parser) application() expression {
+// applicationFollow are the tokens that may follow an application, ending it
+// where whitespace before them would otherwise be taken for another argument
+var applicationFollow = map[tokenType]bool{in: true, rightParen: true}
+
+// peek is the type of the token after the current one, or "" at the end
+func (p *Parser) peek() tokenType {
+	if p.cur+1 >= len(p.Tokens) {
+		return ""
+	}
+	return p.Tokens[p.cur+1].tokenType
+}
+
+func (p *Parser) application() Expression {
+	start := p.offset()
 	expr := p.atom()
 	for !p.isEnd() && p.current().tokenType == whiteSpace {
-		// TODO: error handling
-		if p.Tokens[p.cur+1].tokenType == in {
+		if next := p.peek(); next == "" || applicationFollow[next] {
 			return expr
 		}
 		p.consume(whiteSpace)
-		expr = application{expr, p.atom()}
+		if expr == (variable{"quote"}) {
+			// quote is a keyword, taking the term after it as data
+			p.quotes += 1
+			expr = p.mark(quoted{p.atom()}, start)
+			p.quotes -= 1
+			continue
+		}
+		expr = p.mark(application{expr, p.atom()}, start)
 	}
 	return expr
 }
 
-func (p *Parser) atom() expression {
+func (p *Parser) atom() Expression {
 	if p.current().tokenType == identifier {
-		return p.variable()
+		return p.qualified()
+	}
+	start := p.offset()
+	if p.current().tokenType == underscore {
+		p.consume(underscore)
+		return p.mark(hole{}, start)
+	}
+	if p.current().tokenType == tilde {
+		if p.quotes == 0 {
+			panic("~ splices into a quote, but is outside any")
+		}
+		p.consume(tilde)
+		p.quotes -= 1
+		defer func() { p.quotes += 1 }()
+		return p.mark(unquoted{p.atom()}, start)
 	}
 	p.consume(leftParen)
+	p.consumeMaybe(whiteSpace)
 	exp := p.expression()
+	p.consumeMaybe(whiteSpace)
 	p.consume(rightParen)
-	return exp
+	return p.mark(exp, start)
+}
+
+// qualified parses a variable, which may be a name qualified by the module
+// defining it, as in list.map. A dot can't follow a variable otherwise.
+func (p *Parser) qualified() variable {
+	start, spans := p.offset(), len(p.spans)
+	v := p.variable()
+	for p.cur+1 < len(p.Tokens) && p.current().tokenType == dot && p.Tokens[p.cur+1].tokenType == identifier {
+		p.consume(dot)
+		v = variable{v.identifier + "." + p.variable().identifier}
+		// the parts aren't variables of their own
+		p.spans = p.spans[:spans]
+		p.mark(v, start)
+	}
+	return v
 }
 
 func (p *Parser) variables() []variable {
@@ -368,132 +602,379 @@ func (p *Parser) variables() []variable {
 }
 
 func (p *Parser) variable() variable {
+	start := p.offset()
 	v := p.current().lexeme
 	p.consume(identifier)
+	p.mark(variable{v}, start)
 	return variable{v}
 }
 
 type envBinding struct {
 	name  variable
-	value expression
+	value Expression
+	// source is the text of the definition binding name in the REPL, if any
+	source string
 }
 
-type environment struct {
-	bindings []struct {
-		left  variable
-		right expression
-	}
+// Environment is the definitions programs are evaluated in. Binding a name
+// makes a new Environment, leaving the one bound in as it was. The bindings
+// of the Prelude it is built on, if any, come before its own, and are shared
+// rather than copied.
+type Environment struct {
+	shared   *Prelude
+	bindings []envBinding
 }
 
-func (e environment) clone() environment {
-	return environment{
-		bindings: append([]struct {
-			left  variable
-			right expression
-		}{}, e.bindings...),
+func (e Environment) clone() Environment {
+	return Environment{shared: e.shared, bindings: append([]envBinding{}, e.bindings...)}
+}
+
+// all is every binding of e, the prelude's first
+func (e Environment) all() []envBinding {
+	if e.shared == nil {
+		return e.bindings
 	}
+	return append(e.shared.bindings[:len(e.shared.bindings):len(e.shared.bindings)], e.bindings...)
 }
 
-func (e environment) bind(left variable, right expression) environment {
+func (e Environment) bind(left variable, right Expression) Environment {
+	return e.define(left, right, "")
+}
+
+// define binds left to right as the definition source did
+func (e Environment) define(left variable, right Expression, source string) Environment {
 	newE := e.clone()
-	newE.bindings = append(newE.bindings, struct {
-		left  variable
-		right expression
-	}{left, right})
+	newE.bindings = append(newE.bindings, envBinding{left, right, source})
 	return newE
 }
 
-func (e environment) find(left variable) (expression, bool) {
+func (e Environment) find(left variable) (Expression, bool) {
+	if b, ok := e.lookup(left.identifier); ok {
+		return b.value, true
+	}
+	return variable{}, false
+}
+
+// lookup finds the binding of name in scope
+func (e Environment) lookup(name string) (envBinding, bool) {
 	for i := len(e.bindings) - 1; i >= 0; i-- {
-		if e.bindings[i].left.identifier == left.identifier {
-			return e.bindings[i].right, true
+		if e.bindings[i].name.identifier == name {
+			return e.bindings[i], true
 		}
 	}
-	return variable{}, false
+	if e.shared != nil {
+		if i, ok := e.shared.index[name]; ok {
+			return e.shared.bindings[i], true
+		}
+	}
+	return envBinding{}, false
+}
+
+// only keeps the bindings of names, the last of each, dropping the rest
+func (e Environment) only(names map[string]bool) Environment {
+	newE := Environment{}
+	kept := map[string]bool{}
+	for i := len(e.bindings) - 1; i >= 0; i-- {
+		name := e.bindings[i].name.identifier
+		if names[name] && !kept[name] {
+			kept[name] = true
+			newE.bindings = append(newE.bindings, e.bindings[i])
+		}
+	}
+	// the prelude is shared, so keeping it costs nothing
+	newE.shared = e.shared
+	// back in the order they were made, as definitions lists them
+	for i, j := 0, len(newE.bindings)-1; i < j; i, j = i+1, j-1 {
+		newE.bindings[i], newE.bindings[j] = newE.bindings[j], newE.bindings[i]
+	}
+	return newE
+}
+
+// forget drops every binding of name. The prelude can't be changed, so
+// forgetting one of its names copies the rest of it.
+func (e Environment) forget(name string) Environment {
+	bindings := e.bindings
+	newE := Environment{shared: e.shared}
+	if e.shared != nil {
+		if _, ok := e.shared.index[name]; ok {
+			bindings = e.all()
+			newE.shared = nil
+		}
+	}
+	for _, b := range bindings {
+		if b.name.identifier != name {
+			newE.bindings = append(newE.bindings, b)
+		}
+	}
+	return newE
+}
+
+// definitions are the bindings in scope, those shadowed left out, in the
+// order they were made
+func (e Environment) definitions() []envBinding {
+	last := map[string]int{}
+	all := e.all()
+	for i, b := range all {
+		last[b.name.identifier] = i
+	}
+	defs := []envBinding{}
+	for i, b := range all {
+		if last[b.name.identifier] == i {
+			defs = append(defs, b)
+		}
+	}
+	return defs
+}
+
+func (e Environment) names() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	all := e.all()
+	for i := len(all) - 1; i >= 0; i-- {
+		name := all[i].name.identifier
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// suggest returns the bound name closest to name, or "" if nothing is close enough
+func (e Environment) suggest(name string) string {
+	best, bestDistance := "", len([]rune(name))/2+1
+	for _, candidate := range e.names() {
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	prev := make([]int, len(y)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		cur := make([]int, len(y)+1)
+		cur[0] = i
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, minInt(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev = cur
+	}
+	return prev[len(y)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+type unboundError struct {
+	name       string
+	suggestion string
+}
+
+func (e unboundError) Error() string {
+	if e.suggestion != "" {
+		return fmt.Sprintf("unbound variable %v, did you mean %v?", e.name, e.suggestion)
+	}
+	return fmt.Sprintf("unbound variable %v", e.name)
+}
+
+// Progress is a snapshot of a running evaluation
+type Progress struct {
+	Steps int // beta reductions performed so far
+	Size  int // size of the redex being contracted
+}
+
+// ErrDepthExceeded is returned when evaluation nests deeper than the interpreter allows,
+// which would otherwise exhaust the Go stack and crash the process
+var ErrDepthExceeded = errors.New("maximum recursion depth exceeded")
+
+// ErrStepLimit is returned when evaluation takes more than MaxSteps beta reductions
+var ErrStepLimit = errors.New("step limit reached")
+
+// ErrTimeout is returned when evaluation runs past its Deadline
+var ErrTimeout = errors.New("evaluation timed out")
+
+const defaultMaxDepth = 100000
+
+// evalError carries an error out of the recursive evaluator to Interpret
+type evalError struct {
+	err error
 }
 
 type Interpreter struct {
-	Ast expression
+	Ast Expression
+	// Strict makes referencing an unbound variable an error instead of a free variable
+	Strict bool
+	// Progress, if set, is called at most once per ProgressInterval while evaluating
+	Progress         func(Progress)
+	ProgressInterval time.Duration
+	// MaxDepth bounds the evaluator's recursion, 0 means defaultMaxDepth
+	MaxDepth int
+	// MaxSteps bounds the number of beta reductions, 0 means unlimited
+	MaxSteps int
+	// Deadline stops evaluation once passed, the zero time means no deadline
+	Deadline time.Time
+	// Debug, if set, is written every expression evaluated, indented by how
+	// deeply, with the names bound where it is
+	Debug io.Writer
+	// Cache, if set, keeps the normal forms of closed terms between
+	// evaluations. It isn't used while debugging, which would show nothing.
+	Cache *Cache
+	// Memo reuses the normal forms of closed applications met again within
+	// an evaluation
+	Memo bool
+	// TrackPeak measures every redex contracted, for PeakSize, which takes
+	// time in proportion to their sizes
+	TrackPeak  bool
+	memo       map[uint64][]memoEntry
+	memoHits   int
+	depth      int
+	steps      int
+	peak       int
+	lastReport time.Time
+}
+
+// Steps returns the number of beta reductions performed so far
+func (i *Interpreter) Steps() int {
+	return i.steps
+}
+
+// PeakSize returns the size of the largest redex contracted so far, with
+// TrackPeak
+func (i *Interpreter) PeakSize() int {
+	return i.peak
+}
+
+func (i *Interpreter) step(left abstraction, right Expression) {
+	i.steps += 1
+	if i.MaxSteps > 0 && i.steps > i.MaxSteps {
+		panic(evalError{ErrStepLimit})
+	}
+	if i.TrackPeak {
+		i.peak = maxInt(i.peak, 1+size(left)+size(right))
+	}
+	// checking the clock is slower than reducing, so only do it occasionally
+	if i.steps%1024 != 0 {
+		return
+	}
+	now := time.Now()
+	if !i.Deadline.IsZero() && now.After(i.Deadline) {
+		panic(evalError{ErrTimeout})
+	}
+	if i.Progress != nil && now.Sub(i.lastReport) >= i.ProgressInterval {
+		i.lastReport = now
+		i.Progress(Progress{Steps: i.steps, Size: 1 + size(left) + size(right)})
+	}
 }
 
-func (i *Interpreter) Interpret(env environment) expression {
-	return eval(i.Ast, env)
+func (i *Interpreter) Interpret(env Environment) (value Expression, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(evalError)
+			if !ok {
+				panic(r)
+			}
+			err = e.err
+		}
+	}()
+	if i.MaxDepth == 0 {
+		i.MaxDepth = defaultMaxDepth
+	}
+	i.depth = 0
+	i.peak = 0
+	i.memo = nil
+	i.lastReport = time.Now()
+	if i.Cache != nil && i.Debug == nil {
+		return i.interpretCached(env), nil
+	}
+	return i.eval(i.Ast, env), nil
+}
+
+// closure is the part of env the body of abstraction fn may look up when it
+// is applied: the bindings of its free variables, and the parameters around
+// it, which are bound to themselves and may occur in the values it is passed.
+// Only those are kept, so the environment each step binds an argument in is
+// as large as what the body needs rather than the whole session, and isn't
+// kept alive by the steps evaluating the body.
+func closure(fn abstraction, env Environment) Environment {
+	keep := freeVariables(fn.expr)
+	for _, b := range env.bindings {
+		if b.value == Expression(b.name) {
+			keep[b.name.identifier] = true
+		}
+	}
+	return env.only(keep)
 }
 
-func eval(exp expression, env environment) expression {
-	// fmt.Println(exp)
+func (i *Interpreter) eval(exp Expression, env Environment) Expression {
+	if i.Debug != nil {
+		fmt.Fprintf(i.Debug, "%v%v | %v\n", strings.Repeat(" ", i.depth), format(exp), strings.Join(env.names(), " "))
+	}
+	i.depth += 1
+	defer func() { i.depth -= 1 }()
+	if i.depth > i.MaxDepth {
+		panic(evalError{ErrDepthExceeded})
+	}
 	switch exp := exp.(type) {
 	case binding:
-		return eval(exp.body, env.bind(exp.name, eval(exp.value, env)))
+		return i.eval(exp.body, env.bind(exp.name, i.eval(exp.value, env)))
 	case replBinding:
-		return replBinding{name: exp.name, value: eval(exp.value, env)}
+		return replBinding{name: exp.name, value: i.eval(exp.value, env)}
 	case abstraction:
+		// rename the binder when it would capture a name substituted into its body
+		if avoid := captured(exp, env); avoid[exp.param.identifier] {
+			allNames(exp.expr, avoid)
+			param := variable{fresh(exp.param.identifier, avoid)}
+			exp = abstraction{param, rename(exp.expr, exp.param.identifier, param.identifier)}
+		}
 		// variable shadowing
-		return abstraction{exp.param, eval(exp.expr, env.bind(exp.param, exp.param))}
+		return abstraction{exp.param, i.eval(exp.expr, env.bind(exp.param, exp.param))}
 	case application:
-		// left := exp.left
-		// right := eval(exp.right, env)
-		// switch left := left.(type) {
-		// case abstraction:
-		// 	return eval(left.expr, env.bind(left.param, right))
-		// case application:
-		// 	return eval(application{eval(left, env), right}, env)
-		// default:
-		// 	return application{eval(left, env), right}
-		// }
-
-		left := eval(exp.left, env)
-		right := eval(exp.right, env)
+		left := i.eval(exp.left, env)
+		right := i.eval(exp.right, env)
 		switch left := left.(type) {
 		case abstraction:
-			return eval(left.expr, env.bind(left.param, right))
+			if i.Memo {
+				return i.memoized(left, right, env)
+			}
+			i.step(left, right)
+			return i.eval(left.expr, closure(left, env).bind(left.param, right))
 		default:
+			if res, ok := i.delta(application{left, right}, env); ok {
+				return res
+			}
 			return application{left, right}
 		}
-	// case freeVariable:
-	// 	return exp
+	case quoted:
+		return quoted{i.splice(exp.expr, env, map[string]int{})}
 	case variable:
 		if right, ok := env.find(exp); ok {
 			return right
 		}
-		return freeVariable(exp)
-	default:
-		return exp
-	}
-}
-
-func Repl() {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("> ")
-	env := environment{}
-	for {
-		text, err := reader.ReadString('\n')
-		if err != nil {
-			fmt.Println(err)
-			break
-		}
-		if len(text) == 1 {
-			fmt.Print("> ")
-			continue
+		if res, ok := i.delta(freeVariable(exp), env); ok {
+			return res
 		}
-		text = text[:len(text)-1]
-		scanner := Scanner{Program: []rune(text)}
-		tokens, err := scanner.Scan()
-		if err != nil {
-			fmt.Println(err)
-			fmt.Print("> ")
-			continue
+		if _, ok := lookupBuiltin(exp.identifier); ok {
+			return freeVariable(exp)
 		}
-		parser := Parser{Tokens: tokens}
-		interpreter := Interpreter{Ast: parser.Parse()}
-		value := interpreter.Interpret(env)
-		switch v := value.(type) {
-		case replBinding:
-			env = env.bind(v.name, v.value)
-			fmt.Printf("%v => %v\n", v.name, v.value)
-		default:
-			fmt.Println(value)
+		if i.Strict {
+			panic(evalError{unboundError{exp.identifier, env.suggest(exp.identifier)}})
 		}
-		fmt.Print("> ")
+		return freeVariable(exp)
+	default:
+		return exp
 	}
 }
@@ -1,6 +1,9 @@
 package lambda
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 var cases = []struct {
 	program string
@@ -67,15 +70,36 @@ var cases = []struct {
 		"((𝞴x.(x x)) y)",
 		"(y y)",
 	},
-	// {
-	// 	"(x",
-	// 	"(x",
-	// 	"(x",
-	// },
-	// {
-	// 	"(def x y)",
-	// 	"()"
-	// }
+	{
+		"(𝞴x y.x) y",
+		"((𝞴x.(𝞴y.x)) y)",
+		"(𝞴y'.y)",
+	},
+	{
+		"(𝞴x y y'.x) y",
+		"((𝞴x.(𝞴y.(𝞴y'.x))) y)",
+		"(𝞴y1.(𝞴y'.y))",
+	},
+	{
+		"(𝞴f x.f (f x)) (𝞴f x.f (f x)) f x",
+		"((((𝞴f.(𝞴x.(f (f x)))) (𝞴f.(𝞴x.(f (f x))))) f) x)",
+		"(f (f (f (f x))))",
+	},
+	{
+		"( x y )",
+		"(x y)",
+		"(x y)",
+	},
+	{
+		"(𝞴x.x x )\n",
+		"(𝞴x.(x x))",
+		"(𝞴x.(x x))",
+	},
+	{
+		"let f = 𝞴x.x\nin f y",
+		"let f = (𝞴x.x) in (f y)",
+		"y",
+	},
 }
 
 func TestScanner(t *testing.T) {
@@ -83,7 +107,8 @@ func TestScanner(t *testing.T) {
 		scanner := Scanner{Program: []rune(tt.program)}
 		tokens, _ := scanner.Scan()
 		parser := Parser{Tokens: tokens}
-		res := parser.Parse().String()
+		ast, _ := parser.Parse()
+		res := ast.String()
 		t.Run(tt.program, func(t *testing.T) {
 			if res != tt.textify {
 				t.Errorf("expected %v, but got %v", tt.textify, res)
@@ -92,14 +117,52 @@ func TestScanner(t *testing.T) {
 	}
 }
 
+func TestParseDefinitions(t *testing.T) {
+	for _, program := range []string{"'id = 𝞴x.x", "def id = 𝞴x.x", "def id=𝞴x.x", "let id = 𝞴x.x", "let id=𝞴x.x"} {
+		exp, err := parse(program)
+		if err != nil || exp != (replBinding{variable{"id"}, abstraction{variable{"x"}, variable{"x"}}}) {
+			t.Errorf("%v: expected a definition of id, but got %v, %v", program, exp, err)
+		}
+	}
+	// def is a name like any other outside definitions
+	for _, program := range []string{"def x", "𝞴def.def", "def"} {
+		if exp, err := parse(program); err != nil {
+			t.Errorf("%v: expected no error, but got %v", program, err)
+		} else if _, ok := exp.(replBinding); ok {
+			t.Errorf("%v: expected no definition, but got %v", program, exp)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+		offset   int
+	}{
+		{"(x y", "expect rightParen, but got eof", 4},
+		{"x )", "unexpected rightParen )", 2},
+		// only at the top level may a let have no body
+		{"(let x = y)", "expect whiteSpace, but got rightParen )", 10},
+		{"𝞴z.let x = y", "expect whiteSpace, but got eof", 12},
+		{"f 𝞴x.x", "expect leftParen, but got lambda 𝞴", 2},
+	}
+	for _, test := range tests {
+		_, err := parse(test.program)
+		if e, ok := err.(syntaxError); !ok || e.message != test.expected || e.offset != test.offset {
+			t.Errorf("%v: expected %v at %v, but got %#v", test.program, test.expected, test.offset, err)
+		}
+	}
+}
+
 func TestInterpreter(t *testing.T) {
 	for _, tt := range cases {
 		scanner := Scanner{Program: []rune(tt.program)}
 		tokens, _ := scanner.Scan()
 		parser := Parser{Tokens: tokens}
-		ast := parser.Parse()
+		ast, _ := parser.Parse()
 		interpreter := Interpreter{Ast: ast}
-		value := interpreter.Interpret(environment{})
+		value, _ := interpreter.Interpret(Environment{})
 		t.Run(tt.program, func(t *testing.T) {
 			if value.String() != tt.value {
 				t.Errorf("expected %v, but got %v", tt.value, value.String())
@@ -107,3 +170,87 @@ func TestInterpreter(t *testing.T) {
 		})
 	}
 }
+
+func TestStrict(t *testing.T) {
+	env := Environment{}.bind(variable{"false"}, abstraction{variable{"x"}, abstraction{variable{"y"}, variable{"y"}}})
+	strictCases := []struct {
+		program string
+		err     string
+	}{
+		{"𝞴x.x", ""},
+		{"false", ""},
+		{"𝞴x.y", "unbound variable y"},
+		{"flase", "unbound variable flase, did you mean false?"},
+	}
+	for _, tt := range strictCases {
+		ast, _ := parse(tt.program)
+		interpreter := Interpreter{Ast: ast, Strict: true}
+		_, err := interpreter.Interpret(env)
+		t.Run(tt.program, func(t *testing.T) {
+			if tt.err == "" && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+			if tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Errorf("expected %v, but got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestProgress(t *testing.T) {
+	// one step per identity, far more than one report's worth of steps
+	program := strings.Repeat("(𝞴x.x) ", 5000) + "y"
+	ast, _ := parse(program)
+	reports := []Progress{}
+	interpreter := Interpreter{Ast: ast, Progress: func(p Progress) { reports = append(reports, p) }}
+	interpreter.Interpret(Environment{})
+	if len(reports) == 0 {
+		t.Fatalf("expected progress reports after %v steps", interpreter.Steps())
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Steps <= reports[i-1].Steps {
+			t.Errorf("expected increasing steps, but got %v after %v", reports[i].Steps, reports[i-1].Steps)
+		}
+	}
+}
+
+func TestDepthExceeded(t *testing.T) {
+	// omega never reaches a normal form and recurses forever
+	ast, _ := parse("(𝞴x.x x) (𝞴x.x x)")
+	interpreter := Interpreter{Ast: ast, MaxDepth: 1000}
+	if _, err := interpreter.Interpret(Environment{}); err != ErrDepthExceeded {
+		t.Errorf("expected %v, but got %v", ErrDepthExceeded, err)
+	}
+}
+
+func TestStepLimit(t *testing.T) {
+	ast, _ := parse(strings.Repeat("(𝞴x.x) ", 100) + "y")
+	interpreter := Interpreter{Ast: ast, MaxSteps: 50}
+	if _, err := interpreter.Interpret(Environment{}); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+}
+
+func TestInterpreterDebug(t *testing.T) {
+	ast, _ := parse("(𝞴x.x) y")
+	var debug strings.Builder
+	interpreter := Interpreter{Ast: ast, Debug: &debug}
+	interpreter.Interpret(Environment{})
+	expected := "(𝞴x.x) y | \n 𝞴x.x | \n  x | x\n y | \n x | x\n"
+	if debug.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, debug.String())
+	}
+}
+
+func TestClosure(t *testing.T) {
+	env := Environment{}
+	for _, name := range []string{"id", "k", "y", "s"} {
+		env = env.bind(variable{name}, abstraction{variable{"x"}, variable{"x"}})
+	}
+	env = env.bind(variable{"z"}, variable{"z"})
+	fn := abstraction{variable{"x"}, application{variable{"x"}, variable{"y"}}}
+	// y is used, and z is a parameter around fn
+	if names := strings.Join(closure(fn, env).names(), " "); names != "z y" {
+		t.Errorf("expected z y kept, but got %v", names)
+	}
+}
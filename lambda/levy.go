@@ -0,0 +1,263 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Lévy labels name where a redex comes from: two redexes with the same degree,
+// the label of their 𝞴, belong to one family, which an optimal implementation
+// shares and contracts in a single step. A label is a word of atomic letters,
+// ⌈overlined⌉ and ⌊underlined⌋ labels.
+
+// maxLabels bounds the bytes of all the labels of a term, which can double
+// with every step, as they do reducing (𝞴x.x x) (𝞴x.x x), long before any
+// step limit is reached
+const maxLabels = 1 << 20
+
+// ErrLabelLimit is returned when the labels of a term grow past maxLabels
+var ErrLabelLimit = errors.New("labels grew too long to keep")
+
+// Strategy picks the redex a labelled reduction contracts next
+type Strategy int
+
+const (
+	// NormalOrder contracts the leftmost outermost redex
+	NormalOrder Strategy = iota
+	// ApplicativeOrder contracts the leftmost innermost redex, arguments before
+	// the redex they are passed to
+	ApplicativeOrder
+)
+
+// levy is a term labelled at every node: a variable when fn is nil and body
+// is nil, an abstraction when body isn't nil, otherwise an application
+type levy struct {
+	label   string
+	name    string
+	body    *levy
+	fn, arg *levy
+}
+
+// toLevy labels every node of exp with a distinct letter, lets becoming the
+// redexes they are
+func toLevy(exp Expression) *levy {
+	next := 0
+	var walk func(exp Expression) *levy
+	walk = func(exp Expression) *levy {
+		label := canonicalName(next)
+		next += 1
+		switch exp := exp.(type) {
+		case binding:
+			abs := &levy{label: canonicalName(next), name: exp.name.identifier}
+			next += 1
+			abs.body = walk(exp.body)
+			return &levy{label: label, fn: abs, arg: walk(exp.value)}
+		case replBinding:
+			return walk(exp.value)
+		case abstraction:
+			return &levy{label: label, name: exp.param.identifier, body: walk(exp.expr)}
+		case application:
+			app := &levy{label: label}
+			app.fn, app.arg = walk(exp.left), walk(exp.right)
+			return app
+		default:
+			name, _ := variableName(exp)
+			return &levy{label: label, name: name}
+		}
+	}
+	return walk(exp)
+}
+
+// labels counts the bytes of the labels of l
+func (l *levy) labels() int {
+	n := len(l.label)
+	if l.body != nil {
+		n += l.body.labels()
+	}
+	if l.fn != nil {
+		n += l.fn.labels() + l.arg.labels()
+	}
+	return n
+}
+
+func (l *levy) relabel(prefix string) *levy {
+	res := *l
+	res.label = prefix + l.label
+	return &res
+}
+
+func (l *levy) expression() Expression {
+	switch {
+	case l.body != nil:
+		return abstraction{variable{l.name}, l.body.expression()}
+	case l.fn != nil:
+		return application{l.fn.expression(), l.arg.expression()}
+	default:
+		return variable{l.name}
+	}
+}
+
+func (l *levy) freeNames(names map[string]bool, bound map[string]bool) {
+	switch {
+	case l.body != nil:
+		inner := map[string]bool{l.name: true}
+		for n := range bound {
+			inner[n] = true
+		}
+		l.body.freeNames(names, inner)
+	case l.fn != nil:
+		l.fn.freeNames(names, bound)
+		l.arg.freeNames(names, bound)
+	case !bound[l.name]:
+		names[l.name] = true
+	}
+}
+
+// substitute replaces the free occurrences of name in l with value, each
+// occurrence's label prefixed to that of value, renaming binders that would
+// capture free names of value
+func (l *levy) substitute(name string, value *levy, free map[string]bool) *levy {
+	switch {
+	case l.body != nil:
+		if l.name == name {
+			return l
+		}
+		abs := *l
+		if free[l.name] {
+			avoid := map[string]bool{name: true}
+			for n := range free {
+				avoid[n] = true
+			}
+			allNames(l.body.expression(), avoid)
+			abs.name = fresh(l.name, avoid)
+			abs.body = l.body.substitute(l.name, &levy{name: abs.name}, map[string]bool{abs.name: true})
+		}
+		abs.body = abs.body.substitute(name, value, free)
+		return &abs
+	case l.fn != nil:
+		return &levy{label: l.label, fn: l.fn.substitute(name, value, free), arg: l.arg.substitute(name, value, free)}
+	case l.name == name:
+		return value.relabel(l.label)
+	default:
+		return l
+	}
+}
+
+// contract steps the redex (𝞴x.M)^α N with label β to M[x := N^⌊α⌋] with
+// label β⌈α⌉, reporting the degree α
+func (l *levy) contract() (*levy, string) {
+	abs, arg := l.fn, l.arg
+	degree := abs.label
+	free := map[string]bool{}
+	arg.freeNames(free, map[string]bool{})
+	// renaming a binder keeps its label, only the fresh variable is unlabelled
+	res := abs.body.substitute(abs.name, arg.relabel("⌊"+degree+"⌋"), free)
+	return res.relabel(l.label + "⌈" + degree + "⌉"), degree
+}
+
+// step contracts the redex strategy picks, reporting its degree, or false when
+// l is in normal form
+func (l *levy) step(strategy Strategy) (*levy, string, bool) {
+	switch {
+	case l.body != nil:
+		body, degree, ok := l.body.step(strategy)
+		if !ok {
+			return l, "", false
+		}
+		abs := *l
+		abs.body = body
+		return &abs, degree, true
+	case l.fn != nil:
+		isRedex := l.fn.body != nil
+		if isRedex && strategy == NormalOrder {
+			res, degree := l.contract()
+			return res, degree, true
+		}
+		if fn, degree, ok := l.fn.step(strategy); ok {
+			return &levy{label: l.label, fn: fn, arg: l.arg}, degree, true
+		}
+		if arg, degree, ok := l.arg.step(strategy); ok {
+			return &levy{label: l.label, fn: l.fn, arg: arg}, degree, true
+		}
+		if isRedex {
+			res, degree := l.contract()
+			return res, degree, true
+		}
+		return l, "", false
+	default:
+		return l, "", false
+	}
+}
+
+// RedexFamily is the redexes of one degree that a reduction contracted
+type RedexFamily struct {
+	Degree string
+	Size   int
+}
+
+// LevyReport measures a reduction: how many steps it took against how many
+// families those steps came from, the least an optimal reduction could take
+type LevyReport struct {
+	Steps      int
+	Families   []RedexFamily
+	NormalForm Expression
+}
+
+// LevyReduce labels e and reduces it with strategy, at most maxSteps times,
+// grouping the contracted redexes by family. Families are ordered by the step
+// that first contracted one of them. It fails with ErrLabelLimit once the
+// labels take more than maxLabels bytes.
+func LevyReduce(e Expression, strategy Strategy, maxSteps int) (LevyReport, error) {
+	report := LevyReport{}
+	term := toLevy(e)
+	first := map[string]int{}
+	sizes := map[string]int{}
+	for {
+		next, degree, ok := term.step(strategy)
+		if !ok {
+			break
+		}
+		if report.Steps == maxSteps {
+			return report, ErrStepLimit
+		}
+		if _, seen := first[degree]; !seen {
+			first[degree] = report.Steps
+		}
+		sizes[degree] += 1
+		report.Steps += 1
+		term = next
+		if term.labels() > maxLabels {
+			return report, fmt.Errorf("%w after %v steps", ErrLabelLimit, report.Steps)
+		}
+	}
+	for degree, size := range sizes {
+		report.Families = append(report.Families, RedexFamily{degree, size})
+	}
+	sort.Slice(report.Families, func(i, j int) bool {
+		return first[report.Families[i].Degree] < first[report.Families[j].Degree]
+	})
+	report.NormalForm = term.expression()
+	return report, nil
+}
+
+func (s Strategy) String() string {
+	switch s {
+	case ApplicativeOrder:
+		return "applicative order"
+	default:
+		return "normal order"
+	}
+}
+
+// parseStrategy reads a strategy by its name as the REPL takes it
+func parseStrategy(name string) (Strategy, error) {
+	switch name {
+	case "normal":
+		return NormalOrder, nil
+	case "applicative":
+		return ApplicativeOrder, nil
+	default:
+		return NormalOrder, fmt.Errorf("unknown strategy %v, expected normal or applicative", name)
+	}
+}
@@ -0,0 +1,49 @@
+package lambda
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLevyReduce(t *testing.T) {
+	levyCases := []struct {
+		program  string
+		strategy Strategy
+		steps    int
+		families int
+	}{
+		// normal order copies the argument's redex before contracting both copies
+		{"(𝞴x.x x) ((𝞴y.y) z)", NormalOrder, 3, 2},
+		{"(𝞴x.x x) ((𝞴y.y) z)", ApplicativeOrder, 2, 2},
+		// applicative order contracts a redex the result doesn't need
+		{"(𝞴x y.y) ((𝞴y.y) z)", NormalOrder, 1, 1},
+		{"(𝞴x y.y) ((𝞴y.y) z)", ApplicativeOrder, 2, 2},
+		// the 𝞴 passed for f is applied at two places, two families of their own
+		{"(𝞴f.f (f a)) (𝞴x.x)", ApplicativeOrder, 3, 3},
+		{"let i = 𝞴x.x in i i", NormalOrder, 2, 2},
+	}
+	for _, tt := range levyCases {
+		t.Run(tt.strategy.String()+" "+tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			report, err := LevyReduce(exp, tt.strategy, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Steps != tt.steps || len(report.Families) != tt.families {
+				t.Errorf("expected %v steps in %v families, but got %+v", tt.steps, tt.families, report)
+			}
+			normal, _ := normalize(exp, 100)
+			if len(Diff(report.NormalForm, normal, true)) != 0 {
+				t.Errorf("expected normal form %v, but got %v", format(normal), format(report.NormalForm))
+			}
+		})
+	}
+	omega, _ := parse("(𝞴x.x x) (𝞴x.x x)")
+	if _, err := LevyReduce(omega, NormalOrder, 10); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+	// the labels of omega double with every step
+	if _, err := LevyReduce(omega, NormalOrder, defaultTraceSteps); !errors.Is(err, ErrLabelLimit) {
+		t.Errorf("expected %v, but got %v", ErrLabelLimit, err)
+	}
+}
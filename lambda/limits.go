@@ -0,0 +1,139 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// defaultBodySize bounds request bodies when the server doesn't say
+const defaultBodySize = 1 << 20
+
+// errBusy is returned when every worker is evaluating and the queue for them
+// is full
+var errBusy = errors.New("the server is busy, try again shortly")
+
+// pool bounds the evaluations running at once, letting a bounded queue of
+// others wait for a worker
+type pool struct {
+	workers chan struct{}
+	// admitted holds a place for every evaluation running or waiting
+	admitted chan struct{}
+}
+
+func newPool(workers, queue int) *pool {
+	return &pool{make(chan struct{}, workers), make(chan struct{}, workers+queue)}
+}
+
+// acquire waits for a worker, failing with errBusy when the queue is full, or
+// with ctx's error when it is done first
+func (p *pool) acquire(ctx context.Context) error {
+	select {
+	case p.admitted <- struct{}{}:
+	default:
+		return errBusy
+	}
+	select {
+	case p.workers <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		<-p.admitted
+		return ctx.Err()
+	}
+}
+
+func (p *pool) release() {
+	<-p.workers
+	<-p.admitted
+}
+
+// clients counts the requests being handled for each address
+type clients struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	// capacity bounds the requests of one address, 0 means unbounded
+	capacity int
+}
+
+// acquire counts a request from addr, unless it already has capacity of them
+func (c *clients) acquire(addr string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.capacity > 0 && c.inFlight[addr] >= c.capacity {
+		return false
+	}
+	c.inFlight[addr]++
+	return true
+}
+
+func (c *clients) release(addr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inFlight[addr]--; c.inFlight[addr] <= 0 {
+		delete(c.inFlight, addr)
+	}
+}
+
+// clientAddr is the host a request came from, without its port
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limit caps the body of every request and refuses those from a client
+// already at its limit
+func (s *Server) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr := clientAddr(r)
+		if !s.clients.acquire(addr) {
+			s.metrics.reject("client")
+			http.Error(w, "too many requests at once", http.StatusTooManyRequests)
+			return
+		}
+		defer s.clients.release(addr)
+		r.Body = http.MaxBytesReader(w, r.Body, s.maxBodySize())
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) maxBodySize() int64 {
+	if s.MaxBodySize <= 0 {
+		return defaultBodySize
+	}
+	return s.MaxBodySize
+}
+
+// evaluate runs eval on a worker, waiting for one no longer than the server's
+// timeout, and writes an error instead when none is free in time
+func (s *Server) evaluate(w http.ResponseWriter, r *http.Request, eval func()) {
+	ctx := r.Context()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	if err := s.pool.acquire(ctx); err != nil {
+		s.metrics.reject("busy")
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, errBusy.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.pool.release()
+	eval()
+}
+
+// bodyError writes the error of reading a request body, telling a body over
+// the size cap from one that is malformed
+func bodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,98 @@
+package lambda
+
+import (
+	"fmt"
+	"sort"
+)
+
+// In the linear lambda calculus every bound variable is used exactly once, so
+// nothing is copied or thrown away. The checker works on the source rather
+// than the AST, using the binders and spans the parser records for editors,
+// so it can say where each offending variable is.
+
+// LinearityError is a variable bound by let or 𝞴 not used exactly once. It
+// points at the binder of a variable never used, or at the second use of one
+// used more than once.
+type LinearityError struct {
+	Name string
+	Uses int
+	// Offset is in runes from the start of the program, Line and Column count
+	// from 1
+	Offset, Line, Column int
+}
+
+func (e LinearityError) Error() string {
+	if e.Uses == 0 {
+		return fmt.Sprintf("%v:%v: %v is never used, but must be used exactly once", e.Line, e.Column, e.Name)
+	}
+	return fmt.Sprintf("%v:%v: %v is used again, %v times in all, but must be used exactly once", e.Line, e.Column, e.Name, e.Uses)
+}
+
+// CheckLinear parses program and reports each variable it binds with let or 𝞴
+// that isn't used exactly once, in the order of the program. Names bound with
+// ' are definitions, not bound variables, and aren't checked.
+func CheckLinear(program string) ([]LinearityError, error) {
+	d := newDocument(program)
+	if d.err != nil {
+		return nil, d.err
+	}
+	uses, _ := d.uses()
+	errs := []LinearityError{}
+	for _, b := range d.parser.binders {
+		if b.isDefinition() {
+			// a ' definition, in scope for the rest of the session
+			continue
+		}
+		n := len(uses[b.start])
+		if n == 1 {
+			continue
+		}
+		offset := b.start
+		if n > 1 {
+			sort.Ints(uses[b.start])
+			offset = uses[b.start][1]
+		}
+		line, column := position(d.text, offset)
+		errs = append(errs, LinearityError{b.name.identifier, n, offset, line, column})
+	}
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Offset < errs[j].Offset
+	})
+	return errs, nil
+}
+
+// uses finds where the variables of the program of d occur, those bound by
+// where their binder starts and the free ones apart
+func (d *document) uses() (bound map[int][]int, free []span) {
+	binders := map[int]bool{}
+	for _, b := range d.parser.binders {
+		binders[b.start] = true
+	}
+	bound = map[int][]int{}
+	for _, s := range d.parser.spans {
+		v, ok := s.exp.(variable)
+		// a parenthesized variable is spanned again along with its parentheses
+		if !ok || binders[s.start] || string(d.text[s.start:s.end]) != v.identifier {
+			continue
+		}
+		if b, ok := d.definition(s.start); ok {
+			bound[b.start] = append(bound[b.start], s.start)
+		} else {
+			free = append(free, s)
+		}
+	}
+	return bound, free
+}
+
+// position finds the line and column, counting from 1, of an offset in text
+func position(text []rune, offset int) (line, column int) {
+	line, column = 1, 1
+	for _, c := range text[:offset] {
+		if c == '\n' {
+			line, column = line+1, 1
+		} else {
+			column += 1
+		}
+	}
+	return line, column
+}
@@ -0,0 +1,34 @@
+package lambda
+
+import "testing"
+
+func TestCheckLinear(t *testing.T) {
+	linearCases := []struct {
+		program string
+		errs    []string
+	}{
+		{"𝞴f x.f x", nil},
+		{"'swap = 𝞴p k.p (𝞴a b.k b a)", nil},
+		{"let i = 𝞴x.x in i (i)", []string{"1:20: i is used again, 2 times in all, but must be used exactly once"}},
+		{"𝞴x y.x", []string{"1:4: y is never used, but must be used exactly once"}},
+		// the inner x shadows the outer one, which is then never used
+		{"let k = 𝞴x.𝞴x.x x in\n  k k", []string{
+			"1:10: x is never used, but must be used exactly once",
+			"1:17: x is used again, 2 times in all, but must be used exactly once",
+			"2:5: k is used again, 2 times in all, but must be used exactly once",
+		}},
+	}
+	for _, tt := range linearCases {
+		t.Run(tt.program, func(t *testing.T) {
+			errs, err := CheckLinear(tt.program)
+			if err != nil || len(errs) != len(tt.errs) {
+				t.Fatalf("expected %v, but got %v %v", tt.errs, errs, err)
+			}
+			for i := range errs {
+				if errs[i].Error() != tt.errs[i] {
+					t.Errorf("expected %v, but got %v", tt.errs[i], errs[i])
+				}
+			}
+		})
+	}
+}
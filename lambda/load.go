@@ -0,0 +1,65 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// :load reads a file of definitions, written as a module is, into the
+// session unqualified. It reads from the network only when the REPL was
+// started with -allow-net, since a course's prelude is trusted while a link
+// pasted into a session may not be.
+
+// maxLoadSize is the most bytes :load reads, from a file or the network
+const maxLoadSize = 1 << 20
+
+// loadTimeout is how long :load waits for a URL
+const loadTimeout = 10 * time.Second
+
+// ErrNetDisabled is returned loading a URL without network access allowed
+var ErrNetDisabled = errors.New("loading from the network is off, start lambda with -allow-net to allow it")
+
+// isURL tells URLs :load fetches from paths it reads
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// fetch reads the text at source, a path or, if allowNet, a URL
+func fetch(source string, allowNet bool) (string, error) {
+	var body io.Reader
+	if isURL(source) {
+		if !allowNet {
+			return "", ErrNetDisabled
+		}
+		client := http.Client{Timeout: loadTimeout}
+		res, err := client.Get(source)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("%v: %v", source, res.Status)
+		}
+		body = res.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		body = f
+	}
+	text, err := io.ReadAll(io.LimitReader(body, maxLoadSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(text) > maxLoadSize {
+		return "", fmt.Errorf("%v is larger than %v bytes", source, maxLoadSize)
+	}
+	return string(text), nil
+}
@@ -0,0 +1,62 @@
+package lambda
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := writeModules(t, map[string]string{"bool": "'true = 𝞴x y.x\n'false = 𝞴x y.y\n"})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/prelude.lam":
+			w.Write([]byte("import bool\n'not = 𝞴b.b bool.false bool.true\n"))
+		case "/big.lam":
+			w.Write([]byte(strings.Repeat(" ", maxLoadSize+1)))
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+	var out strings.Builder
+	r := repl{out: &out, modules: modules{path: []string{dir}}}
+	lines := []string{
+		":load " + server.URL + "/prelude.lam",
+		":load " + dir + "/bool.lam",
+		"not true",
+	}
+	expected := []string{
+		ErrNetDisabled.Error() + "\n",
+		"loaded " + dir + "/bool.lam: true false\n",
+		"(not (𝞴x.(𝞴y.x)))\n",
+	}
+	for i, line := range lines {
+		out.Reset()
+		r.line(line)
+		if out.String() != expected[i] {
+			t.Errorf("%v: expected %q, but got %q", line, expected[i], out.String())
+		}
+	}
+	r.allowNet = true
+	lines = []string{
+		":load " + server.URL + "/prelude.lam",
+		"not true",
+		":load " + server.URL + "/big.lam",
+		":load " + server.URL + "/missing.lam",
+	}
+	expected = []string{
+		"loaded " + server.URL + "/prelude.lam: not\n",
+		"(𝞴x.(𝞴y.y))\n",
+		server.URL + "/big.lam is larger than 1048576 bytes\n",
+		server.URL + "/missing.lam: 404 Not Found\n",
+	}
+	for i, line := range lines {
+		out.Reset()
+		r.line(line)
+		if out.String() != expected[i] {
+			t.Errorf("%v: expected %q, but got %q", line, expected[i], out.String())
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package lambda
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bounds on evaluating the expression under the cursor for hover
+const (
+	hoverMaxSteps = 10000
+	hoverTimeout  = time.Second
+)
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspTextEdit struct {
+	Range   lspRange `json:"range"`
+	NewText string   `json:"newText"`
+}
+
+type lspMarkup struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type lspHover struct {
+	Contents lspMarkup `json:"contents"`
+	Range    lspRange  `json:"range"`
+}
+
+type lspDocumentParams struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	Position       lspPosition `json:"position"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+// toPosition converts a rune offset to a line and UTF-16 column
+func toPosition(text []rune, offset int) lspPosition {
+	pos := lspPosition{}
+	for _, c := range text[:minInt(offset, len(text))] {
+		if c == '\n' {
+			pos.Line += 1
+			pos.Character = 0
+		} else {
+			pos.Character += utf16Length(c)
+		}
+	}
+	return pos
+}
+
+// toOffset converts a line and UTF-16 column to a rune offset
+func toOffset(text []rune, pos lspPosition) int {
+	line, character := 0, 0
+	for i, c := range text {
+		if line == pos.Line && character >= pos.Character {
+			return i
+		}
+		if c == '\n' {
+			if line == pos.Line {
+				return i
+			}
+			line += 1
+			character = 0
+		} else if line == pos.Line {
+			character += utf16Length(c)
+		}
+	}
+	return len(text)
+}
+
+func utf16Length(c rune) int {
+	if c >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func toRange(text []rune, start, end int) lspRange {
+	return lspRange{toPosition(text, start), toPosition(text, end)}
+}
+
+// document is an open file along with the result of parsing it
+type document struct {
+	text   []rune
+	ast    Expression
+	parser Parser
+	err    error
+}
+
+func newDocument(text string) *document {
+	d := &document{text: []rune(text)}
+	scanner := Scanner{Program: d.text}
+	tokens, err := scanner.Scan()
+	if err != nil {
+		d.err = err
+		return d
+	}
+	d.parser = Parser{Tokens: tokens}
+	d.ast, d.err = d.parser.Parse()
+	return d
+}
+
+// spanAt finds the innermost expression containing offset
+func (d *document) spanAt(offset int) (span, bool) {
+	best, found := span{}, false
+	for _, s := range d.parser.spans {
+		if s.start <= offset && offset <= s.end && (!found || s.end-s.start < best.end-best.start) {
+			best, found = s, true
+		}
+	}
+	return best, found
+}
+
+// enclosing lists the binders in scope at offset, outermost first
+func (d *document) enclosing(offset int) []binder {
+	binders := []binder{}
+	for _, b := range d.parser.binders {
+		if b.scopeStart <= offset && offset <= b.scopeEnd {
+			binders = append(binders, b)
+		}
+	}
+	sort.SliceStable(binders, func(i, j int) bool {
+		return binders[i].scopeStart < binders[j].scopeStart
+	})
+	return binders
+}
+
+// definition finds the binder a variable at offset refers to
+func (d *document) definition(offset int) (binder, bool) {
+	for _, b := range d.parser.binders {
+		if b.start <= offset && offset <= b.end {
+			return b, true
+		}
+	}
+	s, ok := d.spanAt(offset)
+	if !ok {
+		return binder{}, false
+	}
+	v, ok := s.exp.(variable)
+	if !ok {
+		return binder{}, false
+	}
+	binders := d.enclosing(offset)
+	for i := len(binders) - 1; i >= 0; i-- {
+		if binders[i].name == v {
+			return binders[i], true
+		}
+	}
+	return binder{}, false
+}
+
+// hover describes the expression at offset: its normal form under the let
+// bindings in scope, and its free variables
+func (d *document) hover(offset int) (lspHover, bool) {
+	s, ok := d.spanAt(offset)
+	if !ok {
+		return lspHover{}, false
+	}
+	// lambda parameters shadow the lets around them
+	lets := []binder{}
+	for _, b := range d.enclosing(s.start) {
+		kept := []binder{}
+		for _, l := range lets {
+			if l.name != b.name {
+				kept = append(kept, l)
+			}
+		}
+		lets = kept
+		if b.value != nil {
+			lets = append(lets, b)
+		}
+	}
+	exp := s.exp
+	for i := len(lets) - 1; i >= 0; i-- {
+		exp = binding{lets[i].name, lets[i].value, exp}
+	}
+	interpreter := Interpreter{Ast: exp, MaxSteps: hoverMaxSteps, Deadline: time.Now().Add(hoverTimeout)}
+	normal := ""
+	if value, err := interpreter.Interpret(Environment{}); err != nil {
+		normal = fmt.Sprintf("unknown (%v)", err)
+	} else {
+		normal = fmt.Sprintf("`%v`", format(value))
+	}
+	free := []string{}
+	for name := range freeVariables(s.exp) {
+		free = append(free, name)
+	}
+	sort.Strings(free)
+	if len(free) == 0 {
+		free = append(free, "none")
+	}
+	contents := fmt.Sprintf("```\n%v\n```\nnormal form: %v\n\nfree variables: %v", format(s.exp), normal, strings.Join(free, ", "))
+	return lspHover{lspMarkup{"markdown", contents}, toRange(d.text, s.start, s.end)}, true
+}
+
+func (d *document) diagnostics() []lspDiagnostic {
+	diagnostics := []lspDiagnostic{}
+	if d.err == nil {
+		// 2 is the warning severity
+		for _, w := range append(d.shadowing(nil), d.unused(true, false)...) {
+			diagnostics = append(diagnostics, lspDiagnostic{toRange(d.text, w.Offset, w.end), 2, "lambda", w.Message})
+		}
+		return diagnostics
+	}
+	offset := 0
+	if e, ok := d.err.(syntaxError); ok {
+		offset = e.offset
+	}
+	// 1 is the error severity
+	return append(diagnostics, lspDiagnostic{toRange(d.text, offset, offset+1), 1, "lambda", d.err.Error()})
+}
+
+func (d *document) formatting() []lspTextEdit {
+	if d.err != nil {
+		return nil
+	}
+	text := format(d.ast)
+	if strings.HasSuffix(string(d.text), "\n") {
+		text += "\n"
+	}
+	return []lspTextEdit{{toRange(d.text, 0, len(d.text)), text}}
+}
+
+type languageServer struct {
+	conn      *rpcConn
+	documents map[string]*document
+}
+
+// ServeLSP speaks the Language Server Protocol over in and out until the client exits
+func ServeLSP(in io.Reader, out io.Writer) error {
+	server := languageServer{newRPCConn(in, out), map[string]*document{}}
+	for {
+		msg, err := server.conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+		result, err := server.handle(msg)
+		if msg.ID == nil {
+			continue
+		}
+		if err := server.conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *languageServer) handle(msg rpcMessage) (interface{}, error) {
+	var params lspDocumentParams
+	if len(msg.Params) > 0 {
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+	}
+	uri := params.TextDocument.URI
+	switch msg.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				// 1 is full document sync
+				"textDocumentSync":           1,
+				"hoverProvider":              true,
+				"definitionProvider":         true,
+				"documentFormattingProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "lambda"},
+		}, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		return nil, s.update(uri, params.TextDocument.Text)
+	case "textDocument/didChange":
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		return nil, s.update(uri, params.ContentChanges[len(params.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		delete(s.documents, uri)
+		return nil, s.publish(uri, []lspDiagnostic{})
+	case "textDocument/hover":
+		d, ok := s.documents[uri]
+		if !ok || d.err != nil {
+			return nil, nil
+		}
+		if hover, ok := d.hover(toOffset(d.text, params.Position)); ok {
+			return hover, nil
+		}
+		return nil, nil
+	case "textDocument/definition":
+		d, ok := s.documents[uri]
+		if !ok || d.err != nil {
+			return nil, nil
+		}
+		if b, ok := d.definition(toOffset(d.text, params.Position)); ok {
+			return lspLocation{uri, toRange(d.text, b.start, b.end)}, nil
+		}
+		return nil, nil
+	case "textDocument/formatting":
+		d, ok := s.documents[uri]
+		if !ok {
+			return nil, nil
+		}
+		return d.formatting(), nil
+	default:
+		if msg.ID == nil {
+			// notifications we don't know about can be ignored
+			return nil, nil
+		}
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("method %v not found", msg.Method)}
+	}
+}
+
+func (s *languageServer) update(uri, text string) error {
+	d := newDocument(text)
+	s.documents[uri] = d
+	return s.publish(uri, d.diagnostics())
+}
+
+func (s *languageServer) publish(uri string, diagnostics []lspDiagnostic) error {
+	return s.conn.notify("textDocument/publishDiagnostics", map[string]interface{}{
+		"uri":         uri,
+		"diagnostics": diagnostics,
+	})
+}
@@ -0,0 +1,75 @@
+package lambda
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func lspRequests(messages ...string) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+	return b.String()
+}
+
+func lspResponses(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	responses := []map[string]interface{}{}
+	conn := newRPCConn(out, nil)
+	for {
+		header, err := conn.in.ReadMIMEHeader()
+		if err != nil {
+			return responses
+		}
+		var length int
+		fmt.Sscan(header.Get("Content-Length"), &length)
+		body := make([]byte, length)
+		conn.in.R.Read(body)
+		var res map[string]interface{}
+		if err := json.Unmarshal(body, &res); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, res)
+	}
+}
+
+func TestLSP(t *testing.T) {
+	program := `let id = 𝞴x.x in\nid  (id y)`
+	in := lspRequests(
+		`{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": {}}`,
+		`{"jsonrpc": "2.0", "method": "textDocument/didOpen", "params": {"textDocument": {"uri": "file:///a.lam", "text": "`+program+`"}}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "textDocument/hover", "params": {"textDocument": {"uri": "file:///a.lam"}, "position": {"line": 1, "character": 4}}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "textDocument/definition", "params": {"textDocument": {"uri": "file:///a.lam"}, "position": {"line": 1, "character": 0}}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "textDocument/formatting", "params": {"textDocument": {"uri": "file:///a.lam"}}}`,
+		`{"jsonrpc": "2.0", "method": "textDocument/didChange", "params": {"textDocument": {"uri": "file:///a.lam"}, "contentChanges": [{"text": "𝞴x.(x"}]}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "shutdown"}`,
+		`{"jsonrpc": "2.0", "method": "exit"}`,
+	)
+	var out bytes.Buffer
+	if err := ServeLSP(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	responses := lspResponses(t, &out)
+	if len(responses) != 7 {
+		t.Fatalf("expected 7 messages, but got %v", len(responses))
+	}
+	hover := responses[2]["result"].(map[string]interface{})["contents"].(map[string]interface{})["value"].(string)
+	if !strings.Contains(hover, "normal form: `y`") || !strings.Contains(hover, "free variables: id, y") {
+		t.Errorf("unexpected hover %v", hover)
+	}
+	definition, _ := json.Marshal(responses[3]["result"].(map[string]interface{})["range"])
+	if string(definition) != `{"end":{"character":6,"line":0},"start":{"character":4,"line":0}}` {
+		t.Errorf("unexpected definition %s", definition)
+	}
+	edits := responses[4]["result"].([]interface{})
+	if text := edits[0].(map[string]interface{})["newText"]; text != "let id = 𝞴x.x in id (id y)" {
+		t.Errorf("unexpected formatting %v", text)
+	}
+	diagnostics := responses[5]["params"].(map[string]interface{})["diagnostics"].([]interface{})
+	if len(diagnostics) != 1 || diagnostics[0].(map[string]interface{})["message"] != "expect rightParen, but got eof" {
+		t.Errorf("unexpected diagnostics %v", diagnostics)
+	}
+}
@@ -0,0 +1,128 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A macro `macro name params = template` is expanded before evaluation
+// wherever name is applied to as many arguments as it has params and isn't
+// bound there, into template with the arguments put in for the params, so
+// users can add sugar of their own. Expansion is hygienic both ways: the
+// binders of the template are renamed when they would capture a variable of
+// an argument, and the definitions the template uses are filled in where the
+// macro is defined, so a binder where it is used can't capture them.
+
+// maxMacroDepth bounds expanding macros within expansions, which may not end
+const maxMacroDepth = 100
+
+type macro struct {
+	params   []string
+	template Expression
+}
+
+// parseMacro reads a macro definition, with the definitions of env filled in
+// for the free variables of its template
+func parseMacro(line string, env Environment) (string, macro, error) {
+	usage := fmt.Errorf("usage: macro name params = template")
+	left, right, ok := strings.Cut(line, "=")
+	fields := strings.Fields(left)
+	if !ok || len(fields) < 2 || fields[0] != "macro" {
+		return "", macro{}, usage
+	}
+	for _, name := range fields[1:] {
+		scanner := Scanner{Program: []rune(name)}
+		if tokens, err := scanner.Scan(); err != nil || len(tokens) != 1 || tokens[0].tokenType != identifier {
+			return "", macro{}, fmt.Errorf("%v is not a name", name)
+		}
+	}
+	template, err := parse(right)
+	if err != nil {
+		return "", macro{}, err
+	}
+	params := fields[2:]
+	// resolve would fill in definitions for the params, as they are free
+	for _, param := range params {
+		env = env.forget(param)
+	}
+	return fields[1], macro{params, resolve(template, env)}, nil
+}
+
+// expand puts args in for the params of m
+func (m macro) expand(args []Expression) Expression {
+	avoid := map[string]bool{}
+	allNames(m.template, avoid)
+	for _, arg := range args {
+		allNames(arg, avoid)
+	}
+	// renaming the params apart first, an argument mentioning a later
+	// param's name doesn't have it substituted in turn
+	res := m.template
+	renamed := make([]string, len(m.params))
+	for i, param := range m.params {
+		renamed[i] = fresh(param, avoid)
+		avoid[renamed[i]] = true
+		res = substitute(res, param, variable{renamed[i]})
+	}
+	for i, arg := range args {
+		res = substitute(res, renamed[i], arg)
+	}
+	return res
+}
+
+// expandMacros expands the macros applied in exp
+func expandMacros(exp Expression, macros map[string]macro) (res Expression, err error) {
+	if len(macros) == 0 {
+		return exp, nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ev, ok := r.(evalError)
+			if !ok {
+				panic(r)
+			}
+			err = ev.err
+		}
+	}()
+	return expandIn(exp, macros, map[string]int{}, 0), nil
+}
+
+func expandIn(exp Expression, macros map[string]macro, bound map[string]int, depth int) Expression {
+	if depth > maxMacroDepth {
+		panic(evalError{fmt.Errorf("macros expanded more than %v times within each other", maxMacroDepth)})
+	}
+	switch exp := exp.(type) {
+	case binding:
+		value := expandIn(exp.value, macros, bound, depth)
+		bound[exp.name.identifier] += 1
+		defer func() { bound[exp.name.identifier] -= 1 }()
+		return binding{exp.name, value, expandIn(exp.body, macros, bound, depth)}
+	case replBinding:
+		return replBinding{exp.name, expandIn(exp.value, macros, bound, depth)}
+	case abstraction:
+		bound[exp.param.identifier] += 1
+		defer func() { bound[exp.param.identifier] -= 1 }()
+		return abstraction{exp.param, expandIn(exp.expr, macros, bound, depth)}
+	case application, variable:
+		head, args := spine(exp)
+		for i := range args {
+			args[i] = expandIn(args[i], macros, bound, depth)
+		}
+		v, isVariable := head.(variable)
+		m, ok := macros[v.identifier]
+		if !isVariable || !ok || bound[v.identifier] > 0 {
+			if !isVariable {
+				head = expandIn(head, macros, bound, depth)
+			}
+			return unspine(head, args)
+		}
+		name := v.identifier
+		if len(args) < len(m.params) {
+			panic(evalError{fmt.Errorf("macro %v takes %v arguments, but got %v", name, len(m.params), len(args))})
+		}
+		expanded := expandIn(m.expand(args[:len(m.params)]), macros, bound, depth+1)
+		return unspine(expanded, args[len(m.params):])
+	default:
+		return exp
+	}
+}
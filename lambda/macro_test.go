@@ -0,0 +1,46 @@
+package lambda
+
+import "testing"
+
+func TestMacros(t *testing.T) {
+	res := runRepl(
+		"'true = 𝞴x y.x",
+		"'false = 𝞴x y.y",
+		"macro when c t = c t false",
+		"when true a",
+		// the template's binder is renamed away from the argument's y
+		"macro const e = 𝞴y.e",
+		"𝞴y.const y",
+		// the definition of false is the one the macro was defined with
+		"𝞴false.when false b",
+		// a bound name isn't a macro
+		"(𝞴when.when a) (𝞴x.x)",
+		"macro twice f x = f (f x)",
+		"twice (𝞴x.when true x) c",
+		"when a",
+		"macro loop = loop",
+		"loop",
+		"macro = x",
+	)
+	expected := []string{
+		"true => (𝞴x.(𝞴y.x))\n",
+		"false => (𝞴x.(𝞴y.y))\n",
+		"when is a macro of 2 arguments\n",
+		"a\n",
+		"const is a macro of 1 arguments\n",
+		"(𝞴y.(𝞴y'.y))\n",
+		"(𝞴false.((false b) (𝞴x.(𝞴y.y))))\n",
+		"a\n",
+		"twice is a macro of 2 arguments\n",
+		"c\n",
+		"macro when takes 2 arguments, but got 1\n",
+		"loop is a macro of 0 arguments\n",
+		"macros expanded more than 100 times within each other\n",
+		"usage: macro name params = template\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package lambda
+
+// With Memo, an Interpreter remembers the normal form of every closed
+// application of an abstraction it evaluates, by the Hash of the application,
+// and when it meets one alpha-equivalent to it again, as numeral arithmetic
+// does over and over, it reuses the normal form rather than reducing it again.
+// Only closed applications are remembered, as what an open one reduces to
+// depends on what its free variables are bound to.
+
+type memoEntry struct {
+	application, normal Expression
+}
+
+// memoized is the normal form of the application of left to right, looked
+// up or evaluated and remembered
+func (i *Interpreter) memoized(left abstraction, right Expression, env Environment) Expression {
+	app := application{left, right}
+	if len(freeVariables(app)) > 0 {
+		i.step(left, right)
+		return i.eval(left.expr, closure(left, env).bind(left.param, right))
+	}
+	if i.memo == nil {
+		i.memo = map[uint64][]memoEntry{}
+	}
+	key := Hash(app)
+	for _, e := range i.memo[key] {
+		if len(Diff(e.application, app, true)) == 0 {
+			i.memoHits++
+			return e.normal
+		}
+	}
+	i.step(left, right)
+	normal := i.eval(left.expr, closure(left, env).bind(left.param, right))
+	i.memo[key] = append(i.memo[key], memoEntry{app, normal})
+	return normal
+}
+
+// MemoHits returns how many applications were found remembered, with Memo
+func (i *Interpreter) MemoHits() int {
+	return i.memoHits
+}
@@ -0,0 +1,28 @@
+package lambda
+
+import "testing"
+
+func TestMemo(t *testing.T) {
+	program := "let two = 𝞴f x.f (f x) in let four = two two in 𝞴g.g (four four) (four four) (𝞴y.four y)"
+	ast, err := parse(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := Interpreter{Ast: ast}
+	expected, err := plain.Interpret(Environment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	memo := Interpreter{Ast: ast, Memo: true}
+	got, err := memo.Interpret(Environment{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(Diff(got, expected, true)) != 0 {
+		t.Errorf("expected %v, but got %v", format(expected), format(got))
+	}
+	// the second four four is remembered, but four y is open
+	if memo.MemoHits() != 1 || memo.Steps() >= plain.Steps() {
+		t.Errorf("expected 1 hit and fewer than %v steps, but got %v hits and %v steps", plain.Steps(), memo.MemoHits(), memo.Steps())
+	}
+}
@@ -0,0 +1,121 @@
+package lambda
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// nodeBytes is roughly what one node of a term costs in memory, for estimating
+// how much a session holds
+const nodeBytes = 48
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets ...float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i] += 1
+		}
+	}
+	h.sum += v
+	h.count += 1
+}
+
+func (h *histogram) write(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v histogram\n", name, help, name)
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%v_bucket{le=\"%v\"} %v\n", name, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%v_bucket{le=\"+Inf\"} %v\n%v_sum %v\n%v_count %v\n", name, h.count, name, h.sum, name, h.count)
+}
+
+// metrics are the server's counters, exposed in the Prometheus text format
+type metrics struct {
+	mu        sync.Mutex
+	requests  map[string]uint64
+	steps     *histogram
+	sizes     *histogram
+	timeouts  uint64
+	stepLimit uint64
+	// rejected counts the requests refused by why: busy when no worker was
+	// free, client when its address had too many in flight
+	rejected map[string]uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests: map[string]uint64{},
+		rejected: map[string]uint64{},
+		steps:    newHistogram(10, 100, 1000, 10000, 100000, 1000000),
+		sizes:    newHistogram(10, 100, 1000, 10000, 100000, 1000000),
+	}
+}
+
+func (m *metrics) record(endpoint string, res EvalResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[endpoint] += 1
+	m.steps.observe(float64(res.Steps))
+	switch res.err {
+	case nil:
+		m.sizes.observe(float64(res.Size))
+	case ErrTimeout:
+		m.timeouts += 1
+	case ErrStepLimit:
+		m.stepLimit += 1
+	}
+}
+
+func (m *metrics) reject(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejected[reason] += 1
+}
+
+func (m *metrics) write(w io.Writer, sessions *sessions) {
+	// the sessions are listed before taking m.mu, as an evaluation in a
+	// session records its metrics, and list waits for the evaluation
+	infos := sessions.list()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintf(w, "# HELP lambda_requests_total Evaluation requests served.\n# TYPE lambda_requests_total counter\n")
+	endpoints := []string{}
+	for endpoint := range m.requests {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(w, "lambda_requests_total{endpoint=%q} %v\n", endpoint, m.requests[endpoint])
+	}
+	fmt.Fprintf(w, "# HELP lambda_timeouts_total Evaluations stopped by their time limit.\n# TYPE lambda_timeouts_total counter\nlambda_timeouts_total %v\n", m.timeouts)
+	fmt.Fprintf(w, "# HELP lambda_step_limits_total Evaluations stopped by their step limit.\n# TYPE lambda_step_limits_total counter\nlambda_step_limits_total %v\n", m.stepLimit)
+	fmt.Fprintf(w, "# HELP lambda_rejected_total Requests refused by the server's limits.\n# TYPE lambda_rejected_total counter\n")
+	for _, reason := range []string{"busy", "client"} {
+		fmt.Fprintf(w, "lambda_rejected_total{reason=%q} %v\n", reason, m.rejected[reason])
+	}
+	m.steps.write(w, "lambda_eval_steps", "Beta reductions per evaluation.")
+	m.sizes.write(w, "lambda_normal_form_size", "Nodes in each normal form.")
+	fmt.Fprintf(w, "# HELP lambda_sessions Sessions currently held.\n# TYPE lambda_sessions gauge\nlambda_sessions %v\n", len(infos))
+	fmt.Fprintf(w, "# HELP lambda_session_memory_bytes Estimated memory held by each session's environment.\n# TYPE lambda_session_memory_bytes gauge\n")
+	for _, info := range infos {
+		fmt.Fprintf(w, "lambda_session_memory_bytes{session=%q} %v\n", info.Name, info.Nodes*nodeBytes)
+	}
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	s.metrics.write(w, s.sessions)
+}
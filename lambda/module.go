@@ -0,0 +1,168 @@
+package lambda
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A module is a file name.lam of ' definitions and assertions, one per line,
+// with comments starting --, which may start by declaring `module name` and importing other modules with
+// `import name`. Importing a module binds each of its definitions qualified
+// by the module's name, as list.map, so modules can use the same names
+// without clashing. Modules are looked for along a search path, each loaded
+// once however often it is imported.
+
+// moduleExtension ends the file names of modules
+const moduleExtension = ".lam"
+
+// module is a loaded module's definitions, by their unqualified names
+type module struct {
+	name        string
+	definitions []envBinding
+	// scope is what the module's definitions see, those of the modules it
+	// imports included
+	scope Environment
+}
+
+// modules loads modules from the directories of path, in order
+type modules struct {
+	path   []string
+	loaded map[string]module
+	// the modules being loaded, each imported by the one before
+	loading []string
+	// cache, if set, keeps the values of definitions between sessions
+	cache *Cache
+}
+
+// defaultModulePath is the directories listed in LAMBDA_PATH, then the
+// working directory
+func defaultModulePath() []string {
+	return append(filepath.SplitList(os.Getenv("LAMBDA_PATH")), ".")
+}
+
+// keywordLine reads the name following keyword in a line such as
+// `import list`
+func keywordLine(line, keyword string) (string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 || fields[0] != keyword {
+		return "", false
+	}
+	return fields[1], true
+}
+
+// isComment tells the comments of a module, which start --
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "--")
+}
+
+// find looks for the file of module name along the search path
+func (m *modules) find(name string) (string, error) {
+	for _, dir := range m.path {
+		file := filepath.Join(dir, name+moduleExtension)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("module %v not found in %v", name, strings.Join(m.path, string(filepath.ListSeparator)))
+}
+
+// load loads module name and the modules it imports, if they haven't been
+func (m *modules) load(name string) (module, error) {
+	if mod, ok := m.loaded[name]; ok {
+		return mod, nil
+	}
+	for i, loading := range m.loading {
+		if loading == name {
+			cycle := append(append([]string{}, m.loading[i:]...), name)
+			return module{}, fmt.Errorf("import cycle: %v", strings.Join(cycle, " imports "))
+		}
+	}
+	file, err := m.find(name)
+	if err != nil {
+		return module{}, err
+	}
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return module{}, err
+	}
+	m.loading = append(m.loading, name)
+	defer func() { m.loading = m.loading[:len(m.loading)-1] }()
+	mod, err := m.read(name, file, string(text))
+	if err != nil {
+		return module{}, err
+	}
+	if m.loaded == nil {
+		m.loaded = map[string]module{}
+	}
+	m.loaded[name] = mod
+	return mod, nil
+}
+
+// read evaluates the definitions of module name, whose text came from file,
+// importing the modules it does and checking its assertions. A module read by
+// :load has no name, and may declare any. Every failed assertion is reported,
+// but any other error stops reading.
+func (m *modules) read(name, file, text string) (module, error) {
+	mod := module{name: name}
+	env := Environment{}
+	failed := []string{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isComment(line) {
+			continue
+		}
+		fail := func(err error) (module, error) {
+			return module{}, fmt.Errorf("%v:%v: %v", file, i+1, err)
+		}
+		if declared, ok := keywordLine(line, "module"); ok {
+			if name != "" && declared != name {
+				return fail(fmt.Errorf("module %v is in %v", declared, filepath.Base(file)))
+			}
+			continue
+		}
+		if imported, ok := keywordLine(line, "import"); ok {
+			dependency, err := m.load(imported)
+			if err != nil {
+				return fail(err)
+			}
+			env = dependency.bind(env)
+			continue
+		}
+		if isAssertion(line) {
+			if column, err := checkAssertion(line, env, defaultGradeSteps); err != nil {
+				failed = append(failed, fmt.Sprintf("%v:%v:%v: %v", file, i+1, column, err))
+			}
+			continue
+		}
+		ast, err := parse(line)
+		if err != nil {
+			return fail(err)
+		}
+		if _, ok := ast.(replBinding); !ok {
+			return fail(fmt.Errorf("a module only defines names with ', but got %v", line))
+		}
+		interpreter := Interpreter{Ast: ast, Cache: m.cache}
+		value, err := interpreter.Interpret(env)
+		if err != nil {
+			return fail(err)
+		}
+		v := value.(replBinding)
+		env = env.define(v.name, v.value, line)
+		mod.definitions = append(mod.definitions, envBinding{v.name, v.value, line})
+	}
+	if len(failed) > 0 {
+		return module{}, fmt.Errorf("%v", strings.Join(failed, "\n"))
+	}
+	mod.scope = env
+	return mod, nil
+}
+
+// bind binds the definitions of mod in env, qualified by its name
+func (mod module) bind(env Environment) Environment {
+	for _, b := range mod.definitions {
+		env = env.define(variable{mod.name + "." + b.name.identifier}, b.value, b.source)
+	}
+	return env
+}
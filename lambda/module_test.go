@@ -0,0 +1,97 @@
+package lambda
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeModules(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name+moduleExtension), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestImport(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"bool": "module bool\n'true = 𝞴x y.x\n'false = 𝞴x y.y\n'not = 𝞴b.b false true\n",
+		"pair": "import bool\n\n'pair = 𝞴a b f.f a b\n'fst = 𝞴p.p bool.true\n",
+	})
+	var out strings.Builder
+	r := repl{out: &out, modules: modules{path: []string{t.TempDir(), dir}}}
+	lines := []string{
+		"import pair",
+		"pair.fst (pair.pair a b)",
+		"bool.true",
+		"import bool",
+		"bool.not bool.true",
+		"import list",
+	}
+	expected := []string{
+		"imported pair: pair fst\n",
+		"a\n",
+		// importing a module doesn't import the modules it does
+		"bool.true\n",
+		"imported bool: true false not\n",
+		"(𝞴x.(𝞴y.y))\n",
+		"module list not found in " + r.modules.path[0] + string(filepath.ListSeparator) + dir + "\n",
+	}
+	for i, line := range lines {
+		out.Reset()
+		r.line(line)
+		if out.String() != expected[i] {
+			t.Errorf("%v: expected %q, but got %q", line, expected[i], out.String())
+		}
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"a":     "import b\n'x = 𝞴x.x\n",
+		"b":     "import a\n",
+		"named": "module other\n",
+		"term":  "'x = 𝞴x.x\nx y\n",
+	})
+	tests := []struct {
+		module   string
+		expected string
+	}{
+		{"a", "DIR/a.lam:1: DIR/b.lam:1: import cycle: a imports b imports a"},
+		{"named", "DIR/named.lam:1: module other is in named.lam"},
+		{"term", "DIR/term.lam:2: a module only defines names with ', but got x y"},
+	}
+	for _, test := range tests {
+		m := modules{path: []string{dir}}
+		_, err := m.load(test.module)
+		expected := strings.ReplaceAll(test.expected, "DIR/", dir+string(filepath.Separator))
+		if err == nil || err.Error() != expected {
+			t.Errorf("%v: expected %v, but got %v", test.module, expected, err)
+		}
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"bool": "'true = 𝞴x y.x\n'false = 𝞴x y.y\n'not = 𝞴b.b false true\nassert not true == false\nassert not false == 𝞴a b.a\nassert 𝞴f.not f == not\n",
+		"bad":  "'id = 𝞴x.x\nassert id == 𝞴x y.x\nassert  id (𝞴x.x x) (𝞴x.x x) == id\nassert id == (id\nassert id\n",
+	})
+	m := modules{path: []string{dir}}
+	if _, err := m.load("bool"); err != nil {
+		t.Errorf("expected the assertions to hold, but got %v", err)
+	}
+	file := filepath.Join(dir, "bad.lam")
+	expected := strings.Join([]string{
+		file + ":2:8: assertion failed: the left side is 𝞴x.x, but the right side is 𝞴x y.x",
+		file + ":3:9: id (𝞴x.x x) (𝞴x.x x) has no normal form within 100000 steps",
+		file + ":4:17: expect rightParen, but got eof",
+		file + ":5:1: usage: assert e1 == e2",
+	}, "\n")
+	if _, err := m.load("bad"); err == nil || err.Error() != expected {
+		t.Errorf("expected %v, but got %v", expected, err)
+	}
+}
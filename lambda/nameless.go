@@ -0,0 +1,215 @@
+package lambda
+
+// A locally nameless term keeps free variables as names but refers to bound
+// variables by how many binders out theirs is, so terms equal up to renaming
+// of bound variables are identical and substitution can't capture anything.
+// Names for binders are only chosen when converting back, from the hints the
+// original binders give, renaming those that would capture a free name.
+
+type lnTerm interface {
+	isLocallyNameless()
+}
+
+// lnBound refers to the binder index binders out, 0 being the innermost
+type lnBound struct {
+	index int
+}
+
+// lnFree is a free variable, forced for those unbound by definition
+type lnFree struct {
+	name   string
+	forced bool
+}
+
+type lnAbs struct {
+	hint string
+	body lnTerm
+}
+
+type lnApp struct {
+	left, right lnTerm
+}
+
+type lnLet struct {
+	hint        string
+	value, body lnTerm
+}
+
+type lnRepl struct {
+	name  variable
+	value lnTerm
+}
+
+func (lnBound) isLocallyNameless() {}
+func (lnFree) isLocallyNameless()  {}
+func (lnAbs) isLocallyNameless()   {}
+func (lnApp) isLocallyNameless()   {}
+func (lnLet) isLocallyNameless()   {}
+func (lnRepl) isLocallyNameless()  {}
+
+// locallyNameless converts exp, whose binders in scope are named by scope,
+// innermost last
+func locallyNameless(exp Expression, scope []string) lnTerm {
+	switch exp := exp.(type) {
+	case binding:
+		return lnLet{exp.name.identifier, locallyNameless(exp.value, scope), locallyNameless(exp.body, append(scope[:len(scope):len(scope)], exp.name.identifier))}
+	case replBinding:
+		return lnRepl{exp.name, locallyNameless(exp.value, scope)}
+	case abstraction:
+		return lnAbs{exp.param.identifier, locallyNameless(exp.expr, append(scope[:len(scope):len(scope)], exp.param.identifier))}
+	case application:
+		return lnApp{locallyNameless(exp.left, scope), locallyNameless(exp.right, scope)}
+	case freeVariable:
+		return lnFree{exp.identifier, true}
+	default:
+		name, _ := variableName(exp)
+		for i := len(scope) - 1; i >= 0; i-- {
+			if scope[i] == name {
+				return lnBound{len(scope) - 1 - i}
+			}
+		}
+		return lnFree{name, false}
+	}
+}
+
+// lnClose turns the free occurrences of name in t into references to a binder
+// just outside it, depth binders out
+func lnClose(t lnTerm, name string, depth int) lnTerm {
+	switch t := t.(type) {
+	case lnFree:
+		if t.name == name && !t.forced {
+			return lnBound{depth}
+		}
+		return t
+	case lnAbs:
+		return lnAbs{t.hint, lnClose(t.body, name, depth+1)}
+	case lnApp:
+		return lnApp{lnClose(t.left, name, depth), lnClose(t.right, name, depth)}
+	case lnLet:
+		return lnLet{t.hint, lnClose(t.value, name, depth), lnClose(t.body, name, depth+1)}
+	case lnRepl:
+		return lnRepl{t.name, lnClose(t.value, name, depth)}
+	default:
+		return t
+	}
+}
+
+// lnNames collects the names t refers to with scope around it: its free
+// variables, forced ones aside, the names in scope it refers to, and whether
+// it refers past scope, to the binder lnOpen fills. With hints, binder names
+// are collected too.
+func lnNames(t lnTerm, scope []string, depth int, hints bool, names map[string]bool) (hole bool) {
+	switch t := t.(type) {
+	case lnBound:
+		i := len(scope) - 1 - (t.index - depth)
+		if t.index < depth {
+			return false
+		}
+		if i < 0 {
+			return true
+		}
+		names[scope[i]] = true
+		return false
+	case lnFree:
+		if !t.forced {
+			names[t.name] = true
+		}
+		return false
+	case lnAbs:
+		if hints {
+			names[t.hint] = true
+		}
+		return lnNames(t.body, scope, depth+1, hints, names)
+	case lnApp:
+		left := lnNames(t.left, scope, depth, hints, names)
+		return lnNames(t.right, scope, depth, hints, names) || left
+	case lnLet:
+		if hints {
+			names[t.hint] = true
+		}
+		value := lnNames(t.value, scope, depth, hints, names)
+		return lnNames(t.body, scope, depth+1, hints, names) || value
+	case lnRepl:
+		return lnNames(t.value, scope, depth, hints, names)
+	default:
+		return false
+	}
+}
+
+// lnOpener converts a term closed over hole back to names, filling the
+// references to hole with value, which is lnOpen on the way out
+type lnOpener struct {
+	hole  string
+	value Expression
+	// free are the names binders above a filled hole must not take, those
+	// free in value
+	free map[string]bool
+	// renamed, if not nil, is told about each binder renamed to avoid capture
+	renamed func(before, after Expression)
+}
+
+// binder names a binder of body from its hint, unless that would capture a
+// name free in body or in the value filling its hole, fresh names being chosen
+// as substitution always has, avoiding every name around
+func (o *lnOpener) binder(hint string, body lnTerm, scope []string) string {
+	conflicts := map[string]bool{}
+	hole := lnNames(body, scope, 1, false, conflicts)
+	if hole && o.value != nil {
+		for name := range o.free {
+			conflicts[name] = true
+		}
+	}
+	if !conflicts[hint] {
+		return hint
+	}
+	lnNames(body, scope, 1, true, conflicts)
+	if hole {
+		conflicts[o.hole] = true
+	}
+	return fresh(hint, conflicts)
+}
+
+// lnOpen converts t, with its binders in scope named by scope, back to names
+func (o *lnOpener) lnOpen(t lnTerm, scope []string) Expression {
+	inner := func(name string) []string {
+		return append(scope[:len(scope):len(scope)], name)
+	}
+	switch t := t.(type) {
+	case lnBound:
+		i := len(scope) - 1 - t.index
+		if i >= 0 {
+			return variable{scope[i]}
+		}
+		if o.value != nil {
+			return o.value
+		}
+		return variable{o.hole}
+	case lnFree:
+		if t.forced {
+			return freeVariable{t.name}
+		}
+		return variable{t.name}
+	case lnAbs:
+		name := o.binder(t.hint, t.body, scope)
+		if name != t.hint && o.renamed != nil {
+			unfilled := lnOpener{hole: o.hole}
+			o.renamed(unfilled.lnOpen(t, scope), abstraction{variable{name}, unfilled.lnOpen(t.body, inner(name))})
+		}
+		return abstraction{variable{name}, o.lnOpen(t.body, inner(name))}
+	case lnApp:
+		return application{o.lnOpen(t.left, scope), o.lnOpen(t.right, scope)}
+	case lnLet:
+		// the value is outside the binder, so renamed hears of it first
+		value := o.lnOpen(t.value, scope)
+		name := o.binder(t.hint, t.body, scope)
+		if name != t.hint && o.renamed != nil {
+			unfilled := lnOpener{hole: o.hole}
+			o.renamed(unfilled.lnOpen(t, scope), binding{variable{name}, unfilled.lnOpen(t.value, scope), unfilled.lnOpen(t.body, inner(name))})
+		}
+		return binding{variable{name}, value, o.lnOpen(t.body, inner(name))}
+	case lnRepl:
+		return replBinding{t.name, o.lnOpen(t.value, scope)}
+	default:
+		return nil
+	}
+}
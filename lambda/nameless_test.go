@@ -0,0 +1,37 @@
+package lambda
+
+import "testing"
+
+func TestLocallyNameless(t *testing.T) {
+	// the inner x and the outer y are both one binder out
+	a, _ := parse("𝞴x y.x (𝞴x.x y) z")
+	want := lnAbs{"x", lnAbs{"y", lnApp{lnApp{lnBound{1}, lnAbs{"x", lnApp{lnBound{0}, lnBound{1}}}}, lnFree{"z", false}}}}
+	if got := locallyNameless(a, nil); got != want {
+		t.Errorf("expected %v, but got %v", want, got)
+	}
+	var opener lnOpener
+	if back := opener.lnOpen(locallyNameless(a, nil), nil); format(back) != format(a) {
+		t.Errorf("expected %v, but got %v", format(a), format(back))
+	}
+
+	substCases := []struct {
+		program, name, value string
+		result               string
+	}{
+		{"x (𝞴x.x)", "x", "y", "y (𝞴x.x)"},
+		// y is renamed since it would capture the free y of the value
+		{"𝞴y.x y", "x", "y", "𝞴y'.y y'"},
+		// no renaming where the value doesn't end up under the binder
+		{"(𝞴y.y) x", "x", "y", "(𝞴y.y) y"},
+		{"let y = x in y x", "x", "y", "let y' = y in y' y"},
+	}
+	for _, tt := range substCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			value, _ := parse(tt.value)
+			if res := substitute(exp, tt.name, value); format(res) != tt.result {
+				t.Errorf("expected %v, but got %v", tt.result, format(res))
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package lambda
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// freeVariables collects the names occurring free in an expression
+func freeVariables(exp Expression) map[string]bool {
+	free := map[string]bool{}
+	// within a quote, its own binders are counted in inQuote, apart from
+	// bound, as they don't bind the names in the terms it unquotes
+	var walk func(exp Expression, bound, inQuote map[string]int)
+	walk = func(exp Expression, bound, inQuote map[string]int) {
+		binders := bound
+		if inQuote != nil {
+			binders = inQuote
+		}
+		switch exp := exp.(type) {
+		case binding:
+			walk(exp.value, bound, inQuote)
+			binders[exp.name.identifier] += 1
+			walk(exp.body, bound, inQuote)
+			binders[exp.name.identifier] -= 1
+		case replBinding:
+			walk(exp.value, bound, inQuote)
+		case abstraction:
+			binders[exp.param.identifier] += 1
+			walk(exp.expr, bound, inQuote)
+			binders[exp.param.identifier] -= 1
+		case application:
+			walk(exp.left, bound, inQuote)
+			walk(exp.right, bound, inQuote)
+		case quoted:
+			if inQuote == nil {
+				inQuote = map[string]int{}
+			}
+			walk(exp.expr, bound, inQuote)
+		case unquoted:
+			walk(exp.expr, bound, nil)
+		case variable:
+			if bound[exp.identifier] == 0 && inQuote[exp.identifier] == 0 {
+				free[exp.identifier] = true
+			}
+		case freeVariable:
+			// unbound by definition, even beneath a binder of the same name
+			free[exp.identifier] = true
+		}
+	}
+	walk(exp, map[string]int{}, nil)
+	return free
+}
+
+// allNames collects every name in an expression, bound or free
+func allNames(exp Expression, names map[string]bool) {
+	switch exp := exp.(type) {
+	case binding:
+		names[exp.name.identifier] = true
+		allNames(exp.value, names)
+		allNames(exp.body, names)
+	case replBinding:
+		names[exp.name.identifier] = true
+		allNames(exp.value, names)
+	case abstraction:
+		names[exp.param.identifier] = true
+		allNames(exp.expr, names)
+	case application:
+		allNames(exp.left, names)
+		allNames(exp.right, names)
+	case quoted:
+		allNames(exp.expr, names)
+	case unquoted:
+		allNames(exp.expr, names)
+	case variable:
+		names[exp.identifier] = true
+	case freeVariable:
+		names[exp.identifier] = true
+	}
+}
+
+// fresh picks a variant of name outside of avoid. Candidates are tried in a fixed
+// order (x', x1, x2, ...) so the same conflicts always produce the same name.
+func fresh(name string, avoid map[string]bool) string {
+	base := strings.TrimRight(name, "'0123456789")
+	if base == "" {
+		base = name
+	}
+	if candidate := base + "'"; !avoid[candidate] {
+		return candidate
+	}
+	for n := 1; ; n++ {
+		if candidate := base + strconv.Itoa(n); !avoid[candidate] {
+			return candidate
+		}
+	}
+}
+
+// rename replaces free occurrences of from with to, which must not occur in exp
+func rename(exp Expression, from, to string) Expression {
+	switch exp := exp.(type) {
+	case binding:
+		value := rename(exp.value, from, to)
+		if exp.name.identifier == from {
+			return binding{exp.name, value, exp.body}
+		}
+		return binding{exp.name, value, rename(exp.body, from, to)}
+	case replBinding:
+		return replBinding{exp.name, rename(exp.value, from, to)}
+	case abstraction:
+		if exp.param.identifier == from {
+			return exp
+		}
+		return abstraction{exp.param, rename(exp.expr, from, to)}
+	case application:
+		return application{rename(exp.left, from, to), rename(exp.right, from, to)}
+	case quoted:
+		return quoted{renameQuoted(exp.expr, from, to, false, true)}
+	case unquoted:
+		return unquoted{rename(exp.expr, from, to)}
+	case variable:
+		if exp.identifier == from {
+			return variable{to}
+		}
+		return exp
+	default:
+		return exp
+	}
+}
+
+// renameQuoted is rename within a quote, whose binders of from shadow it
+// everywhere but in the terms it unquotes, which are evaluated outside it.
+// Those are renamed as well when from is bound outside the quote.
+func renameQuoted(exp Expression, from, to string, shadowed, outside bool) Expression {
+	switch exp := exp.(type) {
+	case binding:
+		value := renameQuoted(exp.value, from, to, shadowed, outside)
+		return binding{exp.name, value, renameQuoted(exp.body, from, to, shadowed || exp.name.identifier == from, outside)}
+	case abstraction:
+		return abstraction{exp.param, renameQuoted(exp.expr, from, to, shadowed || exp.param.identifier == from, outside)}
+	case application:
+		return application{renameQuoted(exp.left, from, to, shadowed, outside), renameQuoted(exp.right, from, to, shadowed, outside)}
+	case quoted:
+		return quoted{renameQuoted(exp.expr, from, to, shadowed, outside)}
+	case unquoted:
+		if outside {
+			return unquoted{rename(exp.expr, from, to)}
+		}
+		return exp
+	case variable:
+		if exp.identifier == from && !shadowed {
+			return variable{to}
+		}
+		return exp
+	default:
+		return exp
+	}
+}
+
+// captured returns the names that substituting env into the body of exp could
+// introduce under its binder
+func captured(exp abstraction, env Environment) map[string]bool {
+	names := map[string]bool{}
+	for name := range freeVariables(exp.expr) {
+		if name == exp.param.identifier {
+			continue
+		}
+		value, ok := env.find(variable{name})
+		if !ok {
+			names[name] = true
+			continue
+		}
+		for inner := range freeVariables(value) {
+			names[inner] = true
+		}
+	}
+	return names
+}
+
+// checkBound reports the first free variable of exp, in name order, that env doesn't bind
+func checkBound(exp Expression, env Environment) error {
+	names := []string{}
+	for name := range freeVariables(exp) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := lookupBuiltin(name); ok {
+			continue
+		}
+		if _, ok := env.find(variable{name}); !ok {
+			return unboundError{name, env.suggest(name)}
+		}
+	}
+	return nil
+}
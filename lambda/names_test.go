@@ -0,0 +1,28 @@
+package lambda
+
+import "testing"
+
+func TestFresh(t *testing.T) {
+	freshCases := []struct {
+		name  string
+		avoid []string
+		fresh string
+	}{
+		{"x", []string{"x"}, "x'"},
+		{"x", []string{"x", "x'"}, "x1"},
+		{"x", []string{"x", "x'", "x1", "x2"}, "x3"},
+		{"x'", []string{"x'"}, "x1"},
+		{"x2", []string{"x2"}, "x'"},
+	}
+	for _, tt := range freshCases {
+		avoid := map[string]bool{}
+		for _, name := range tt.avoid {
+			avoid[name] = true
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			if res := fresh(tt.name, avoid); res != tt.fresh {
+				t.Errorf("expected %v, but got %v", tt.fresh, res)
+			}
+		})
+	}
+}
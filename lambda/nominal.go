@@ -0,0 +1,200 @@
+package lambda
+
+// The nominal approach keeps bound variables as names, like the source syntax,
+// but never renames them one occurrence at a time. Names are only ever
+// swapped: the transposition (a b) exchanges a and b everywhere, binders
+// included, and being a bijection on names it can't capture anything. A name
+// is fresh for a term, written a # t, when it doesn't occur free in it, and
+// 𝞴a.t is the same term as 𝞴b.(a b)·t whenever b # t.
+//
+// Forced free variables can't be bound, so to the nominal operations they are
+// constants rather than names: swapping leaves them alone, and they don't
+// count against freshness.
+
+// Swap applies the transposition (a b) to e, exchanging every occurrence of
+// the names a and b, bound or free
+func Swap(e Expression, a, b string) Expression {
+	swap := func(v variable) variable {
+		switch v.identifier {
+		case a:
+			return variable{b}
+		case b:
+			return variable{a}
+		default:
+			return v
+		}
+	}
+	switch e := e.(type) {
+	case binding:
+		return binding{swap(e.name), Swap(e.value, a, b), Swap(e.body, a, b)}
+	case replBinding:
+		return replBinding{e.name, Swap(e.value, a, b)}
+	case abstraction:
+		return abstraction{swap(e.param), Swap(e.expr, a, b)}
+	case application:
+		return application{Swap(e.left, a, b), Swap(e.right, a, b)}
+	case variable:
+		return swap(e)
+	default:
+		return e
+	}
+}
+
+// Support is the set of names free in e, those a permutation can change it
+// by moving; every other name is fresh for e
+func Support(e Expression) map[string]bool {
+	support := map[string]bool{}
+	var walk func(e Expression, bound map[string]int)
+	walk = func(e Expression, bound map[string]int) {
+		switch e := e.(type) {
+		case binding:
+			walk(e.value, bound)
+			bound[e.name.identifier] += 1
+			walk(e.body, bound)
+			bound[e.name.identifier] -= 1
+		case replBinding:
+			walk(e.value, bound)
+		case abstraction:
+			bound[e.param.identifier] += 1
+			walk(e.expr, bound)
+			bound[e.param.identifier] -= 1
+		case application:
+			walk(e.left, bound)
+			walk(e.right, bound)
+		case variable:
+			if bound[e.identifier] == 0 {
+				support[e.identifier] = true
+			}
+		}
+	}
+	walk(e, map[string]int{})
+	return support
+}
+
+// Fresh reports whether name is fresh for e, name # e
+func Fresh(name string, e Expression) bool {
+	return !Support(e)[name]
+}
+
+// AlphaEqualNominal decides alpha equivalence the nominal way: 𝞴a.s and 𝞴b.t
+// are equal when a = b and s = t, or when a # t and s = (a b)·t
+func AlphaEqualNominal(x, y Expression) bool {
+	switch x := x.(type) {
+	case binding:
+		y, ok := y.(binding)
+		return ok && AlphaEqualNominal(x.value, y.value) && nominalBinder(x.name.identifier, x.body, y.name.identifier, y.body)
+	case replBinding:
+		y, ok := y.(replBinding)
+		return ok && x.name == y.name && AlphaEqualNominal(x.value, y.value)
+	case abstraction:
+		y, ok := y.(abstraction)
+		return ok && nominalBinder(x.param.identifier, x.expr, y.param.identifier, y.expr)
+	case application:
+		y, ok := y.(application)
+		return ok && AlphaEqualNominal(x.left, y.left) && AlphaEqualNominal(x.right, y.right)
+	default:
+		return x == y
+	}
+}
+
+func nominalBinder(a string, s Expression, b string, t Expression) bool {
+	if a == b {
+		return AlphaEqualNominal(s, t)
+	}
+	return Fresh(a, t) && AlphaEqualNominal(s, Swap(t, a, b))
+}
+
+// SubstituteNominal replaces the free occurrences of name in e with value.
+// A binder that would capture a free name of value is first swapped with a
+// name occurring nowhere around, which renames it without looking at its
+// occurrences.
+func SubstituteNominal(e Expression, name string, value Expression) Expression {
+	avoid := Support(value)
+	avoid[name] = true
+	return substituteNominal(e, name, value, avoid)
+}
+
+func substituteNominal(e Expression, name string, value Expression, avoid map[string]bool) Expression {
+	// binder renames a binder a of body with a name fresh for everything around
+	binder := func(a string, body Expression) (string, Expression) {
+		if !avoid[a] || Fresh(name, body) {
+			return a, body
+		}
+		names := map[string]bool{}
+		for n := range avoid {
+			names[n] = true
+		}
+		allNames(body, names)
+		c := fresh(a, names)
+		return c, Swap(body, a, c)
+	}
+	switch e := e.(type) {
+	case binding:
+		v := substituteNominal(e.value, name, value, avoid)
+		if e.name.identifier == name {
+			return binding{e.name, v, e.body}
+		}
+		a, body := binder(e.name.identifier, e.body)
+		return binding{variable{a}, v, substituteNominal(body, name, value, avoid)}
+	case replBinding:
+		return replBinding{e.name, substituteNominal(e.value, name, value, avoid)}
+	case abstraction:
+		if e.param.identifier == name {
+			return e
+		}
+		a, body := binder(e.param.identifier, e.expr)
+		return abstraction{variable{a}, substituteNominal(body, name, value, avoid)}
+	case application:
+		return application{substituteNominal(e.left, name, value, avoid), substituteNominal(e.right, name, value, avoid)}
+	case variable:
+		if e.identifier == name {
+			return value
+		}
+		return e
+	default:
+		return e
+	}
+}
+
+// nominalStep contracts the leftmost outermost redex by SubstituteNominal
+func nominalStep(e Expression) (Expression, bool) {
+	switch e := e.(type) {
+	case binding:
+		return SubstituteNominal(e.body, e.name.identifier, e.value), true
+	case replBinding:
+		value, ok := nominalStep(e.value)
+		return replBinding{e.name, value}, ok
+	case abstraction:
+		body, ok := nominalStep(e.expr)
+		return abstraction{e.param, body}, ok
+	case application:
+		if abs, ok := e.left.(abstraction); ok {
+			return SubstituteNominal(abs.expr, abs.param.identifier, e.right), true
+		}
+		if left, ok := nominalStep(e.left); ok {
+			return application{left, e.right}, true
+		}
+		if right, ok := nominalStep(e.right); ok {
+			return application{e.left, right}, true
+		}
+		return e, false
+	default:
+		return e, false
+	}
+}
+
+// NormalizeNominal finds the normal form of e in normal order, substituting
+// with SubstituteNominal, failing with ErrStepLimit after maxSteps
+// reductions, 0 means no limit
+func NormalizeNominal(e Expression, maxSteps int) (Expression, error) {
+	for steps := 0; ; steps++ {
+		next, ok := nominalStep(e)
+		if !ok {
+			return e, nil
+		}
+		if maxSteps > 0 && steps == maxSteps {
+			return e, ErrStepLimit
+		}
+		e = next
+	}
+}
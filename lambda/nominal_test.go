@@ -0,0 +1,57 @@
+package lambda
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSwap(t *testing.T) {
+	exp, _ := parse("𝞴x.x y (𝞴y.x y)")
+	if res := format(Swap(exp, "x", "y")); res != "𝞴y.y x (𝞴x.y x)" {
+		t.Errorf("expected 𝞴y.y x (𝞴x.y x), but got %v", res)
+	}
+	if !Fresh("x", exp) || Fresh("y", exp) {
+		t.Errorf("expected only x to be fresh for %v", format(exp))
+	}
+}
+
+func TestAlphaEqualNominal(t *testing.T) {
+	alphaCases := []struct {
+		left, right string
+		equal       bool
+	}{
+		{"𝞴x.x y", "𝞴z.z y", true},
+		// y isn't fresh for the right side, so the binders can't be swapped
+		{"𝞴x.x y", "𝞴y.y y", false},
+		{"let x = y in 𝞴y.x y", "let z = y in 𝞴x.z x", true},
+		{"𝞴x y.x", "𝞴x y.y", false},
+	}
+	for _, tt := range alphaCases {
+		t.Run(tt.left, func(t *testing.T) {
+			left, _ := parse(tt.left)
+			right, _ := parse(tt.right)
+			if AlphaEqualNominal(left, right) != tt.equal {
+				t.Errorf("expected %v, but got %v", tt.equal, !tt.equal)
+			}
+		})
+	}
+}
+
+func TestNormalizeNominal(t *testing.T) {
+	exp, _ := parse("(𝞴x y.x) y")
+	if res, err := NormalizeNominal(exp, 0); err != nil || format(res) != "𝞴y'.y" {
+		t.Errorf("expected 𝞴y'.y, but got %v %v", format(res), err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		term := GenerateClosed(2+rng.Intn(12), rng)
+		normal, err := normalize(term, 100)
+		if err != nil {
+			continue
+		}
+		res, err := NormalizeNominal(term, 0)
+		if err != nil || !AlphaEqualNominal(res, normal) || len(Diff(res, normal, true)) != 0 {
+			t.Errorf("%v: expected %v, but got %v %v", format(term), format(normal), format(res), err)
+		}
+	}
+}
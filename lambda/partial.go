@@ -0,0 +1,70 @@
+package lambda
+
+// PartialEval reduces every redex whose argument doesn't depend on the dynamic
+// free variables, leaving a residual term specialized to everything static.
+// Arguments that are just a variable are always substituted, since that copies
+// no work. Like normalization this may not terminate, so it stops with
+// ErrStepLimit after maxSteps reductions, returning the residual so far.
+func PartialEval(e Expression, dynamic []string, maxSteps int) (Expression, error) {
+	isDynamic := map[string]bool{}
+	for _, name := range dynamic {
+		isDynamic[name] = true
+	}
+	for steps := 0; ; steps++ {
+		next, ok := partialStep(e, isDynamic)
+		if !ok {
+			return e, nil
+		}
+		if steps == maxSteps {
+			return e, ErrStepLimit
+		}
+		e = next
+	}
+}
+
+// static reports whether substituting arg copies no dynamic computation
+func static(arg Expression, dynamic map[string]bool) bool {
+	if _, ok := arg.(variable); ok {
+		return true
+	}
+	for name := range freeVariables(arg) {
+		if dynamic[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// partialStep is reduceStep skipping over redexes with dynamic arguments
+func partialStep(exp Expression, dynamic map[string]bool) (Expression, bool) {
+	switch exp := exp.(type) {
+	case binding:
+		if static(exp.value, dynamic) {
+			return substitute(exp.body, exp.name.identifier, exp.value), true
+		}
+		if value, ok := partialStep(exp.value, dynamic); ok {
+			return binding{exp.name, value, exp.body}, true
+		}
+		body, ok := partialStep(exp.body, dynamic)
+		return binding{exp.name, exp.value, body}, ok
+	case replBinding:
+		value, ok := partialStep(exp.value, dynamic)
+		return replBinding{exp.name, value}, ok
+	case abstraction:
+		body, ok := partialStep(exp.expr, dynamic)
+		return abstraction{exp.param, body}, ok
+	case application:
+		if abs, ok := exp.left.(abstraction); ok && static(exp.right, dynamic) {
+			return substitute(abs.expr, abs.param.identifier, exp.right), true
+		}
+		if left, ok := partialStep(exp.left, dynamic); ok {
+			return application{left, exp.right}, true
+		}
+		if right, ok := partialStep(exp.right, dynamic); ok {
+			return application{exp.left, right}, true
+		}
+		return exp, false
+	default:
+		return exp, false
+	}
+}
@@ -0,0 +1,30 @@
+package lambda
+
+import "testing"
+
+func TestPartialEval(t *testing.T) {
+	partialCases := []struct {
+		program  string
+		dynamic  []string
+		residual string
+	}{
+		{"let three = 𝞴f x.f (f (f x)) in let mult = 𝞴m n f.m (n f) in mult three", nil, "𝞴n f x.n f (n f (n f x))"},
+		{"(𝞴m f x.m f (f x)) n", []string{"n"}, "𝞴f x.n f (f x)"},
+		{"(𝞴y.y y) (n a)", []string{"n"}, "(𝞴y.y y) (n a)"},
+		{"(𝞴y.y y) (n ((𝞴z.z) a))", []string{"n"}, "(𝞴y.y y) (n a)"},
+		{"(𝞴y.y y) (m a)", []string{"n"}, "m a (m a)"},
+	}
+	for _, tt := range partialCases {
+		t.Run(tt.program, func(t *testing.T) {
+			ast, _ := parse(tt.program)
+			res, err := PartialEval(ast, tt.dynamic, 1000)
+			if err != nil || format(res) != tt.residual {
+				t.Errorf("expected %v, but got %v %v", tt.residual, format(res), err)
+			}
+		})
+	}
+	omega, _ := parse("(𝞴x.x x) (𝞴x.x x)")
+	if _, err := PartialEval(omega, nil, 100); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+}
@@ -0,0 +1,129 @@
+package lambda
+
+import "errors"
+
+// pathTermLimit bounds how many distinct terms ReductionPaths explores
+const pathTermLimit = 100000
+
+// ErrTooManyTerms is returned when a term reaches more distinct terms than can
+// be explored
+var ErrTooManyTerms = errors.New("too many reachable terms")
+
+// reduct is a term one step reduces to, along with the contraction that got there
+type reduct struct {
+	term Expression
+	c    contraction
+}
+
+// reducts contracts each redex of exp in turn, leftmost outermost first, so the
+// first reduct is the normal order step. Renaming to avoid capture happens
+// within the step, as in reduceStep.
+func reducts(exp Expression) []reduct {
+	res := []reduct{}
+	// within rebuilds exp around the reducts of one of its subterms
+	within := func(step string, inner []reduct, rebuild func(Expression) Expression) {
+		for _, r := range inner {
+			r.c.path = step + r.c.path
+			res = append(res, reduct{rebuild(r.term), r.c})
+		}
+	}
+	switch exp := exp.(type) {
+	case binding:
+		c := contract(exp.name, exp.body, exp.value, true, false)
+		res = append(res, reduct{c.result, c})
+		within("/value", reducts(exp.value), func(value Expression) Expression {
+			return binding{exp.name, value, exp.body}
+		})
+		within("/body", reducts(exp.body), func(body Expression) Expression {
+			return binding{exp.name, exp.value, body}
+		})
+	case replBinding:
+		within("/value", reducts(exp.value), func(value Expression) Expression {
+			return replBinding{exp.name, value}
+		})
+	case abstraction:
+		within("/body", reducts(exp.expr), func(body Expression) Expression {
+			return abstraction{exp.param, body}
+		})
+	case application:
+		if abs, ok := exp.left.(abstraction); ok {
+			c := contract(abs.param, abs.expr, exp.right, false, false)
+			res = append(res, reduct{c.result, c})
+		}
+		within("/fn", reducts(exp.left), func(left Expression) Expression {
+			return application{left, exp.right}
+		})
+		within("/arg", reducts(exp.right), func(right Expression) Expression {
+			return application{exp.left, right}
+		})
+	}
+	return res
+}
+
+// PathReport counts the reduction sequences from a term, choosing any redex at
+// every step. Sequences are maximal: they end in a normal form, or are cut off
+// at the depth bound.
+type PathReport struct {
+	// Paths reach a normal form, Unfinished are cut off
+	Paths      int
+	Unfinished int
+	// NormalForms are the distinct normal forms reached, up to renaming of
+	// bound variables, in the order they were found
+	NormalForms []Expression
+	// Terms is how many distinct terms were reached
+	Terms int
+}
+
+// ReductionPaths explores every reduction sequence of e up to maxDepth steps.
+// Terms are identified up to renaming of bound variables, so sequences meeting
+// at the same term are counted without exploring the rest of them again.
+func ReductionPaths(e Expression, maxDepth int) (PathReport, error) {
+	type counts struct{ finished, unfinished int }
+	type key struct {
+		term  string
+		depth int
+	}
+	report := PathReport{}
+	terms := map[string]bool{}
+	normalForms := map[string]bool{}
+	memo := map[key]counts{}
+	var explore func(exp Expression, depth int) (counts, error)
+	explore = func(exp Expression, depth int) (counts, error) {
+		k := key{format(Canonicalize(exp)), depth}
+		if c, ok := memo[k]; ok {
+			return c, nil
+		}
+		if !terms[k.term] {
+			if len(terms) == pathTermLimit {
+				return counts{}, ErrTooManyTerms
+			}
+			terms[k.term] = true
+		}
+		next := reducts(exp)
+		c := counts{}
+		switch {
+		case len(next) == 0:
+			c.finished = 1
+			if !normalForms[k.term] {
+				normalForms[k.term] = true
+				report.NormalForms = append(report.NormalForms, exp)
+			}
+		case depth == maxDepth:
+			c.unfinished = 1
+		default:
+			for _, r := range next {
+				rc, err := explore(r.term, depth+1)
+				if err != nil {
+					return c, err
+				}
+				c.finished += rc.finished
+				c.unfinished += rc.unfinished
+			}
+		}
+		memo[k] = c
+		return c, nil
+	}
+	c, err := explore(e, 0)
+	report.Paths, report.Unfinished, report.Terms = c.finished, c.unfinished, len(terms)
+	return report, err
+}
@@ -0,0 +1,50 @@
+package lambda
+
+import "testing"
+
+func TestReductionPaths(t *testing.T) {
+	pathCases := []struct {
+		program     string
+		paths       int
+		unfinished  int
+		normalForms int
+	}{
+		{"x", 1, 0, 1},
+		{"(𝞴x.x) ((𝞴y.y) z)", 2, 0, 1},
+		{"(𝞴x.x x) ((𝞴y.y) z)", 3, 0, 1},
+		{"(𝞴x y.y) ((𝞴x.x x) (𝞴x.x x))", 5, 1, 1},
+	}
+	for _, tt := range pathCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			report, err := ReductionPaths(exp, 5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Paths != tt.paths || report.Unfinished != tt.unfinished || len(report.NormalForms) != tt.normalForms {
+				t.Errorf("expected %v %v %v, but got %+v", tt.paths, tt.unfinished, tt.normalForms, report)
+			}
+		})
+	}
+}
+
+func TestReducts(t *testing.T) {
+	exp, _ := parse("(𝞴x.(𝞴y.y) x) ((𝞴z.z) a)")
+	res := []string{}
+	for _, r := range reducts(exp) {
+		res = append(res, r.c.path+" "+format(r.term))
+	}
+	expected := []string{
+		" (𝞴y.y) ((𝞴z.z) a)",
+		"/fn/body (𝞴x.x) ((𝞴z.z) a)",
+		"/arg (𝞴x.(𝞴y.y) x) a",
+	}
+	if len(res) != len(expected) {
+		t.Fatalf("expected %q, but got %q", expected, res)
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package lambda
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// PCF extends the calculus with natural numbers written as numerals, succ and
+// pred on them, ifz n z s choosing z when n is 0 and s otherwise, and fix f
+// reducing to f (fix f), so recursion and arithmetic need no encodings. Terms
+// are evaluated by name to weak head normal form: an abstraction, a numeral,
+// or a constant or free variable applied to arguments, leaving the bodies of
+// abstractions alone since fix would unfold forever beneath them.
+
+// pcfArity is the number of arguments each PCF constant takes
+var pcfArity = map[string]int{"succ": 1, "pred": 1, "ifz": 3, "fix": 1}
+
+// pcf evaluates by name, counting reductions and how deeply it nests
+type pcf struct {
+	maxSteps int
+	steps    int
+	depth    int
+}
+
+func (p *pcf) step() {
+	p.steps += 1
+	if p.maxSteps > 0 && p.steps > p.maxSteps {
+		panic(evalError{ErrStepLimit})
+	}
+}
+
+// numeral reads a variable named by digits as a natural number
+func numeral(exp Expression) (int, bool) {
+	name, ok := variableName(exp)
+	if !ok || name == "" {
+		return 0, false
+	}
+	for _, c := range name {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(name)
+	return n, err == nil
+}
+
+// number evaluates exp, which the constant name needs to be a numeral
+func (p *pcf) number(name string, exp Expression) int {
+	v := p.eval(exp)
+	n, ok := numeral(v)
+	if !ok {
+		panic(evalError{fmt.Errorf("%v expects a natural number, but got %v", name, format(v))})
+	}
+	return n
+}
+
+// eval reduces exp to weak head normal form
+func (p *pcf) eval(exp Expression) Expression {
+	p.depth += 1
+	defer func() { p.depth -= 1 }()
+	if p.depth > defaultMaxDepth {
+		panic(evalError{ErrDepthExceeded})
+	}
+	for {
+		switch e := exp.(type) {
+		case binding:
+			p.step()
+			exp = substitute(e.body, e.name.identifier, e.value)
+			continue
+		case replBinding:
+			return replBinding{e.name, p.eval(e.value)}
+		case application:
+			head, args := spine(e)
+			head = p.eval(head)
+			if abs, ok := head.(abstraction); ok {
+				p.step()
+				exp = unspine(substitute(abs.expr, abs.param.identifier, args[0]), args[1:])
+				continue
+			}
+			name, _ := variableName(head)
+			arity, ok := pcfArity[name]
+			if !ok || len(args) < arity {
+				return unspine(head, args)
+			}
+			var res Expression
+			switch name {
+			case "succ":
+				res = variable{strconv.Itoa(p.number(name, args[0]) + 1)}
+			case "pred":
+				res = variable{strconv.Itoa(maxInt(p.number(name, args[0])-1, 0))}
+			case "ifz":
+				res = args[2]
+				if p.number(name, args[0]) == 0 {
+					res = args[1]
+				}
+			case "fix":
+				res = application{args[0], application{head, args[0]}}
+			}
+			p.step()
+			exp = unspine(res, args[arity:])
+		default:
+			return exp
+		}
+	}
+}
+
+// EvalPCF evaluates e by name to weak head normal form with the PCF
+// constants succ, pred, ifz and fix, wherever their names are free, and
+// numerals. It fails with ErrStepLimit after maxSteps reductions, 0 means no
+// limit.
+func EvalPCF(e Expression, maxSteps int) (value Expression, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ev, ok := r.(evalError)
+			if !ok {
+				panic(r)
+			}
+			err = ev.err
+		}
+	}()
+	p := &pcf{maxSteps: maxSteps}
+	return p.eval(e), nil
+}
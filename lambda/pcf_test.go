@@ -0,0 +1,51 @@
+package lambda
+
+import "testing"
+
+func TestEvalPCF(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"succ (succ 0)", "2"},
+		{"pred 0", "0"},
+		{"pred (succ 41)", "41"},
+		{"ifz 0 yes no", "yes"},
+		{"ifz (succ 0) yes no", "no"},
+		// the branch not taken is never evaluated
+		{"ifz 0 1 ((𝞴x.x x) (𝞴x.x x))", "1"},
+		{"let add = fix (𝞴add m n.ifz m n (succ (add (pred m) n))) in add 3 4", "7"},
+		{"let fact = fix (𝞴f n.let mul = fix (𝞴mul m k.ifz m 0 (let add = fix (𝞴add a b.ifz a b (succ (add (pred a) b))) in add k (mul (pred m) k))) in ifz n 1 (mul n (f (pred n)))) in fact 4", "24"},
+		// evaluation stops at a weak head normal form
+		{"𝞴x.succ 0", "(𝞴x.(succ 0))"},
+		{"ifz", "ifz"},
+		{"f (succ 0)", "(f (succ 0))"},
+		// constants are only constants where free
+		{"(𝞴succ.succ 0) (𝞴n.n)", "0"},
+	}
+	for _, test := range tests {
+		ast, err := parse(test.program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		value, err := EvalPCF(ast, 0)
+		if err != nil {
+			t.Errorf("%v: %v", test.program, err)
+			continue
+		}
+		if value.String() != test.expected {
+			t.Errorf("%v: expected %v, but got %v", test.program, test.expected, value)
+		}
+	}
+}
+
+func TestEvalPCFErrors(t *testing.T) {
+	ast, _ := parse("succ (𝞴x.x)")
+	if _, err := EvalPCF(ast, 0); err == nil || err.Error() != "succ expects a natural number, but got 𝞴x.x" {
+		t.Errorf("expected a type error, but got %v", err)
+	}
+	ast, _ = parse("fix (𝞴x.x)")
+	if _, err := EvalPCF(ast, 100); err != ErrStepLimit {
+		t.Errorf("expected %v, but got %v", ErrStepLimit, err)
+	}
+}
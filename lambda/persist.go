@@ -0,0 +1,75 @@
+package lambda
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// A REPL started with a state file restores the definitions saved there when
+// it last exited, as the values they had rather than evaluating their
+// sources again, which may since mean something else or take long.
+
+// stateVersion is the format of state files, which a REPL only restores if it
+// wrote them in the same format. A state file is in the binary encoding: the
+// version, how many definitions there are, and then the name, source and
+// value of each.
+const stateVersion = 2
+
+// saveState writes the bindings of env to the state file at path, making
+// its directory if need be
+func saveState(path string, env Environment) error {
+	var text bytes.Buffer
+	e := NewEncoder(&text)
+	e.uvarint(stateVersion)
+	e.uvarint(uint64(len(env.bindings)))
+	for _, b := range env.bindings {
+		e.name(b.name.identifier)
+		e.string(b.source)
+		e.term(b.value)
+	}
+	if err := e.flush(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	// write the whole file or none of it, so a failure keeps the last state
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, text.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadState reads the bindings saved in the state file at path, none if
+// there is no file yet
+func loadState(path string) (Environment, error) {
+	text, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Environment{}, nil
+	}
+	if err != nil {
+		return Environment{}, err
+	}
+	d := NewDecoder(bytes.NewReader(text))
+	if err := d.header(); err != nil {
+		return Environment{}, fmt.Errorf("%v: %v", path, err)
+	}
+	env := Environment{}
+	err = d.catch(func() {
+		if version := d.uvarint(); version != stateVersion {
+			d.fail(fmt.Errorf("state of version %v, but expected %v", version, stateVersion))
+		}
+		for n := d.uvarint(); n > 0; n-- {
+			name := d.name()
+			source := d.string()
+			env = env.define(variable{name}, d.term(), source)
+		}
+	})
+	if err != nil {
+		return Environment{}, fmt.Errorf("%v: %v", path, err)
+	}
+	return env, nil
+}
@@ -0,0 +1,59 @@
+package lambda
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPersist(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "lambda", "state")
+	options := ReplOptions{Persist: state}
+	var out strings.Builder
+	RunRepl(strings.NewReader("'id = 𝞴x.x\n'k = 𝞴x y.id x\n"), &out, options)
+	out.Reset()
+	RunRepl(strings.NewReader(":defs\nk a b\n"), &out, options)
+	expected := "restored 2 definitions from " + state + "\n" +
+		"> 'id = 𝞴x.x\n'k = 𝞴x y.id x\n" +
+		"> a\n" +
+		"> EOF\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestPersistVersion(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state")
+	os.WriteFile(state, []byte(`{"version": 0}`), 0644)
+	if _, err := loadState(state); err == nil {
+		t.Errorf("expected a state of another format to be refused")
+	}
+	var text bytes.Buffer
+	e := NewEncoder(&text)
+	e.uvarint(stateVersion + 1)
+	e.flush()
+	os.WriteFile(state, text.Bytes(), 0644)
+	if _, err := loadState(state); err == nil {
+		t.Errorf("expected a state of another version to be refused")
+	}
+}
+
+func TestPersistUnreadable(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state")
+	old := []byte(`{"version": 1, "definitions": ["'id = 𝞴x.x"]}`)
+	os.WriteFile(state, old, 0644)
+	var out strings.Builder
+	RunRepl(strings.NewReader("'k = 𝞴x y.x\n"), &out, ReplOptions{Persist: state})
+	if !strings.Contains(out.String(), "moved it to "+state+".bak\n") {
+		t.Errorf("expected the state to be moved aside, but got %q", out.String())
+	}
+	if kept, _ := os.ReadFile(state + ".bak"); !bytes.Equal(kept, old) {
+		t.Errorf("expected the unreadable state to be kept, but got %q", kept)
+	}
+	env, err := loadState(state)
+	if err != nil || len(env.bindings) != 1 {
+		t.Errorf("expected the new definition to be saved, but got %v, %v", env.names(), err)
+	}
+}
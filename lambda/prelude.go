@@ -0,0 +1,89 @@
+package lambda
+
+import "os"
+
+// A prelude is a module of definitions every session starts with, read and
+// evaluated once, however many sessions or requests there are. Its
+// definitions are never changed, so every Environment built on it refers to
+// the same ones rather than copying them, and only the definitions made on
+// top of it are copied as they are made. Subterms alike in its values are
+// the same in memory, so the numerals and combinators the definitions share
+// are kept once.
+
+// Prelude is definitions shared unchanged by the environments built on it
+type Prelude struct {
+	bindings []envBinding
+	// index finds the binding of a name in bindings
+	index map[string]int
+}
+
+// LoadPrelude reads and evaluates the module in file, whose imports are
+// looked for along path, or the default path when it is nil
+func LoadPrelude(file string, path []string) (*Prelude, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		path = defaultModulePath()
+	}
+	m := modules{path: path}
+	mod, err := m.read("", file, string(text))
+	if err != nil {
+		return nil, err
+	}
+	return newPrelude(mod.scope.all()), nil
+}
+
+func newPrelude(bindings []envBinding) *Prelude {
+	p := &Prelude{index: map[string]int{}}
+	consed := map[uint64][]Expression{}
+	for _, b := range bindings {
+		if _, ok := p.index[b.name.identifier]; ok {
+			// a module may define a name again, and only the last is seen
+			p.bindings[p.index[b.name.identifier]] = envBinding{b.name, hashCons(b.value, consed), b.source}
+			continue
+		}
+		p.index[b.name.identifier] = len(p.bindings)
+		p.bindings = append(p.bindings, envBinding{b.name, hashCons(b.value, consed), b.source})
+	}
+	return p
+}
+
+// hashCons rebuilds exp from the subterms in consed identical to its own,
+// adding those it has that aren't, so identical subterms are stored once
+func hashCons(exp Expression, consed map[uint64][]Expression) Expression {
+	switch e := exp.(type) {
+	case binding:
+		exp = binding{e.name, hashCons(e.value, consed), hashCons(e.body, consed)}
+	case replBinding:
+		exp = replBinding{e.name, hashCons(e.value, consed)}
+	case abstraction:
+		exp = abstraction{e.param, hashCons(e.expr, consed)}
+	case application:
+		exp = application{hashCons(e.left, consed), hashCons(e.right, consed)}
+	}
+	key := Hash(exp)
+	for _, c := range consed[key] {
+		// the hash is the same up to renaming, so the names are compared too
+		if c == exp {
+			return c
+		}
+	}
+	consed[key] = append(consed[key], exp)
+	return exp
+}
+
+// Environment is an environment of the prelude's definitions, to make more
+// on top of, or an empty one for a nil Prelude
+func (p *Prelude) Environment() Environment {
+	return Environment{shared: p}
+}
+
+// Len is how many definitions the prelude has
+func (p *Prelude) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.bindings)
+}
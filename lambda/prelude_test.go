@@ -0,0 +1,68 @@
+package lambda
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrelude(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prelude.lam")
+	os.WriteFile(file, []byte("'id = 𝞴x.x\n'k = 𝞴x y.x\n'two = 𝞴f x.f (f x)\n"), 0644)
+	p, err := LoadPrelude(file, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 3 {
+		t.Errorf("expected 3 definitions, but got %v", p.Len())
+	}
+	// environments built on the prelude don't see each other's definitions
+	a := p.Environment().define(variable{"id"}, variable{"a"}, "'id = a")
+	b := p.Environment().define(variable{"b"}, variable{"b"}, "'b = b")
+	if v, _ := a.find(variable{"id"}); v != (variable{"a"}) {
+		t.Errorf("expected id redefined, but got %v", v)
+	}
+	if v, _ := b.find(variable{"id"}); format(v) != "𝞴x.x" {
+		t.Errorf("expected id from the prelude, but got %v", v)
+	}
+	if names := strings.Join(b.names(), " "); names != "b two k id" {
+		t.Errorf("expected b two k id, but got %v", names)
+	}
+	if _, ok := b.forget("k").find(variable{"k"}); ok {
+		t.Errorf("expected k forgotten")
+	}
+	if _, ok := p.Environment().find(variable{"k"}); !ok {
+		t.Errorf("expected the prelude unchanged by forgetting")
+	}
+	if names := strings.Join(b.forget("k").names(), " "); names != "b two id" {
+		t.Errorf("expected b two id, but got %v", names)
+	}
+
+	var out strings.Builder
+	RunRepl(strings.NewReader("two k\n'c = k\n:undo\n:undo\n"), &out, ReplOptions{Prelude: p})
+	expected := "> (𝞴x.(𝞴y.(𝞴y.x)))\n" +
+		"> c => (𝞴x.(𝞴y.x))\n" +
+		"> c is undefined\n" +
+		"> nothing to undo\n" +
+		"> EOF\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestHashCons(t *testing.T) {
+	consed := map[uint64][]Expression{}
+	a, _ := parse("𝞴f.f (𝞴x.x)")
+	b, _ := parse("𝞴g.g (𝞴x.x)")
+	hashCons(a, consed)
+	hashCons(b, consed)
+	// 𝞴x.x is kept once, and 𝞴g.g (𝞴x.x) apart from 𝞴f.f (𝞴x.x) as its names differ
+	n := 0
+	for _, exps := range consed {
+		n += len(exps)
+	}
+	if n != 8 {
+		t.Errorf("expected 8 distinct subterms, but got %v", n)
+	}
+}
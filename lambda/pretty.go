@@ -0,0 +1,207 @@
+package lambda
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The pretty printer breaks a term too long for a line at its applications
+// and abstractions, indenting what it breaks off, as in Wadler's "A prettier
+// printer": a term becomes a document of text and breaks, whose groups are
+// laid out on one line when they fit and with every break a new line when
+// they don't.
+
+// doc is a document to lay out: docText, docBreak, docNest, docGroup or docs
+type doc interface{}
+
+type docText string
+
+// docBreak is flat on one line, and a new line otherwise
+type docBreak struct {
+	flat string
+}
+
+// docNest indents the lines doc breaks into by indent more
+type docNest struct {
+	indent int
+	doc    doc
+}
+
+// docGroup is laid out flat if it fits on the rest of the line
+type docGroup struct {
+	doc doc
+}
+
+type docs []doc
+
+// prettyIndent is how much what a term breaks off is indented
+const prettyIndent = 2
+
+// defaultWidth is the width of a terminal that doesn't say
+const defaultWidth = 80
+
+// terminalWidth is the width of the terminal, as COLUMNS gives it
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return defaultWidth
+}
+
+// prettyDoc makes exp a document printing as print does
+func (p *printer) prettyDoc(exp Expression) doc {
+	switch exp := exp.(type) {
+	case binding:
+		return docGroup{docs{
+			docText("let " + exp.name.identifier + " ="), docNest{prettyIndent, docs{docBreak{" "}, p.prettyDoc(exp.value)}},
+			docBreak{" "}, docText("in "), p.prettyDoc(exp.body),
+		}}
+	case replBinding:
+		return docGroup{docs{docText("let " + exp.name.identifier + " ="), docNest{prettyIndent, docs{docBreak{" "}, p.prettyDoc(exp.value)}}}}
+	case abstraction:
+		return docGroup{docs{
+			docText("(" + p.lambda() + exp.param.identifier + "."), docNest{prettyIndent, docs{docBreak{""}, p.prettyDoc(exp.expr)}}, docText(")"),
+		}}
+	case application:
+		return docGroup{docs{
+			docText("("), p.prettyDoc(exp.left), docNest{prettyIndent, docs{docBreak{" "}, p.prettyDoc(exp.right)}}, docText(")"),
+		}}
+	case strictApplication:
+		return p.prettyDoc(exp.application)
+	case quoted:
+		return docs{docText("(quote "), p.prettyDoc(exp.expr), docText(")")}
+	case unquoted:
+		return docs{docText("~"), p.prettyDoc(exp.expr)}
+	default:
+		return docText(exp.String())
+	}
+}
+
+// formatDoc makes exp a document printing as format does
+func (p *printer) formatDoc(exp Expression) doc {
+	switch exp := exp.(type) {
+	case binding:
+		return docGroup{docs{
+			docText("let " + exp.name.identifier + " ="), docNest{prettyIndent, docs{docBreak{" "}, p.valueDoc(exp.value)}},
+			docBreak{" "}, docText("in "), p.formatDoc(exp.body),
+		}}
+	case replBinding:
+		return docGroup{docs{docText("'" + exp.name.identifier + " ="), docNest{prettyIndent, docs{docBreak{" "}, p.valueDoc(exp.value)}}}}
+	case abstraction:
+		params := []string{exp.param.identifier}
+		body := exp.expr
+		for abs, ok := body.(abstraction); ok; abs, ok = body.(abstraction) {
+			params = append(params, abs.param.identifier)
+			body = abs.expr
+		}
+		return docGroup{docs{
+			docText(p.lambda() + strings.Join(params, " ") + "."), docNest{prettyIndent, docs{docBreak{""}, p.formatDoc(body)}},
+		}}
+	case application:
+		head, args := spine(exp)
+		rest := docs{}
+		for _, arg := range args {
+			rest = append(rest, docBreak{" "}, p.atomDoc(arg))
+		}
+		return docGroup{docs{p.atomDoc(head), docNest{prettyIndent, rest}}}
+	case quoted:
+		return docs{docText("quote "), p.atomDoc(exp.expr)}
+	case unquoted:
+		return docs{docText("~"), p.atomDoc(exp.expr)}
+	default:
+		return p.prettyDoc(exp)
+	}
+}
+
+func (p *printer) valueDoc(exp Expression) doc {
+	switch exp.(type) {
+	case binding, replBinding:
+		return docs{docText("("), p.formatDoc(exp), docText(")")}
+	default:
+		return p.formatDoc(exp)
+	}
+}
+
+func (p *printer) atomDoc(exp Expression) doc {
+	switch exp.(type) {
+	case variable, freeVariable, unquoted, hole:
+		return p.formatDoc(exp)
+	default:
+		return docs{docText("("), p.formatDoc(exp), docText(")")}
+	}
+}
+
+// layoutItem is a document left to lay out, at indent, flat or not
+type layoutItem struct {
+	indent int
+	flat   bool
+	doc    doc
+}
+
+// layout writes d in lines of at most width runes where it can
+func (p *printer) layout(d doc, width int) {
+	stack := []layoutItem{{0, false, d}}
+	column := 0
+	for len(stack) > 0 && !p.full() {
+		item := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch d := item.doc.(type) {
+		case docText:
+			p.write(string(d))
+			column += utf8.RuneCountInString(string(d))
+		case docBreak:
+			if item.flat {
+				p.write(d.flat)
+				column += utf8.RuneCountInString(d.flat)
+			} else {
+				p.write("\n" + strings.Repeat(" ", item.indent))
+				column = item.indent
+			}
+		case docNest:
+			stack = append(stack, layoutItem{item.indent + d.indent, item.flat, d.doc})
+		case docGroup:
+			flat := item.flat || fits(width-column, layoutItem{item.indent, true, d.doc}, stack)
+			stack = append(stack, layoutItem{item.indent, flat, d.doc})
+		case docs:
+			for i := len(d) - 1; i >= 0; i-- {
+				stack = append(stack, layoutItem{item.indent, item.flat, d[i]})
+			}
+		}
+	}
+}
+
+// fits reports whether next, and then the documents of rest, the last
+// first, reach the end of their line in at most width runes
+func fits(width int, next layoutItem, rest []layoutItem) bool {
+	pending := []layoutItem{next}
+	for width >= 0 {
+		if len(pending) == 0 {
+			if len(rest) == 0 {
+				return true
+			}
+			pending, rest = append(pending, rest[len(rest)-1]), rest[:len(rest)-1]
+		}
+		item := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		switch d := item.doc.(type) {
+		case docText:
+			width -= utf8.RuneCountInString(string(d))
+		case docBreak:
+			if !item.flat {
+				return true
+			}
+			width -= utf8.RuneCountInString(d.flat)
+		case docNest:
+			pending = append(pending, layoutItem{item.indent, item.flat, d.doc})
+		case docGroup:
+			pending = append(pending, layoutItem{item.indent, item.flat, d.doc})
+		case docs:
+			for i := len(d) - 1; i >= 0; i-- {
+				pending = append(pending, layoutItem{item.indent, item.flat, d[i]})
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPretty(t *testing.T) {
+	exp, _ := parse("let pair = 𝞴a b s.s a b in pair (𝞴f x.f (f (f x))) (𝞴f x.f x)")
+	tests := []struct {
+		width    int
+		source   bool
+		expected string
+	}{
+		{80, true, "let pair = 𝞴a b s.s a b in pair (𝞴f x.f (f (f x))) (𝞴f x.f x)"},
+		// a let breaks before its value and its body both
+		{40, true, "let pair =\n  𝞴a b s.s a b\nin pair (𝞴f x.f (f (f x))) (𝞴f x.f x)"},
+		{20, true, "let pair =\n  𝞴a b s.s a b\nin pair\n  (𝞴f x.f (f (f x)))\n  (𝞴f x.f x)"},
+		{30, false, "let pair =\n  (𝞴a.(𝞴b.(𝞴s.((s a) b))))\nin ((pair\n  (𝞴f.(𝞴x.(f (f (f x))))))\n  (𝞴f.(𝞴x.(f x))))"},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		p := printer{out: &out}
+		if test.source {
+			p.layout(p.formatDoc(exp), test.width)
+		} else {
+			p.layout(p.prettyDoc(exp), test.width)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%v: expected\n%v\nbut got\n%v", test.width, test.expected, out.String())
+		}
+		// the lines read back as the term
+		if back, err := parse(out.String()); err != nil || len(Diff(back, exp, false)) != 0 {
+			t.Errorf("%v: expected to read back %v, but got %v, %v", test.width, exp, back, err)
+		}
+	}
+}
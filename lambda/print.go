@@ -0,0 +1,206 @@
+package lambda
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// printer writes terms straight to out, as their String methods do or as
+// source as format does, so a term of millions of nodes is written out
+// without building strings of its parts first. It stops after max runes
+// when max isn't 0, so a normal form too large to read is cut short.
+type printer struct {
+	out io.Writer
+	max int
+	// glyph is written for 𝞴, which it is when empty
+	glyph string
+	// runes and nodes count what has been written, and cut is whether
+	// anything was left out
+	runes int
+	nodes int
+	cut   bool
+	err   error
+}
+
+// full reports whether the printer has written as much as it may
+func (p *printer) full() bool {
+	return p.err != nil || p.max > 0 && p.runes >= p.max
+}
+
+func (p *printer) write(s string) {
+	if p.full() {
+		p.cut = p.cut || s != ""
+		return
+	}
+	if p.max > 0 {
+		if runes := []rune(s); p.runes+len(runes) > p.max {
+			s, p.cut = string(runes[:p.max-p.runes]), true
+		}
+	}
+	p.runes += len([]rune(s))
+	_, p.err = io.WriteString(p.out, s)
+}
+
+// print writes exp as its String method does, every abstraction and
+// application in parentheses
+func (p *printer) print(exp Expression) {
+	if p.full() {
+		p.cut = true
+		return
+	}
+	p.nodes += 1
+	switch exp := exp.(type) {
+	case binding:
+		p.write("let " + exp.name.identifier + " = ")
+		p.print(exp.value)
+		p.write(" in ")
+		p.print(exp.body)
+	case replBinding:
+		p.write("let " + exp.name.identifier + " = ")
+		p.print(exp.value)
+	case abstraction:
+		p.write("(" + p.lambda() + exp.param.identifier + ".")
+		p.print(exp.expr)
+		p.write(")")
+	case application:
+		p.write("(")
+		p.print(exp.left)
+		p.write(" ")
+		p.print(exp.right)
+		p.write(")")
+	case strictApplication:
+		p.nodes -= 1
+		p.print(exp.application)
+	case quoted:
+		p.write("(quote ")
+		p.print(exp.expr)
+		p.write(")")
+	case unquoted:
+		p.write("~")
+		p.print(exp.expr)
+	case variable:
+		p.write(exp.identifier)
+	case freeVariable:
+		p.write(exp.identifier)
+	default:
+		p.write(exp.String())
+	}
+}
+
+// format writes exp as source, with only the parentheses the parser needs
+func (p *printer) format(exp Expression) {
+	if p.full() {
+		p.cut = true
+		return
+	}
+	p.nodes += 1
+	switch exp := exp.(type) {
+	case binding:
+		p.write("let " + exp.name.identifier + " = ")
+		p.value(exp.value)
+		p.write(" in ")
+		p.format(exp.body)
+	case replBinding:
+		p.write("'" + exp.name.identifier + " = ")
+		p.value(exp.value)
+	case abstraction:
+		// the binders of nested abstractions merge, as in 𝞴x y.x
+		p.write(p.lambda() + exp.param.identifier)
+		body := exp.expr
+		for abs, ok := body.(abstraction); ok; abs, ok = body.(abstraction) {
+			p.nodes += 1
+			p.write(" " + abs.param.identifier)
+			body = abs.expr
+		}
+		p.write(".")
+		p.format(body)
+	case application:
+		head, args := spine(exp)
+		p.nodes += len(args) - 1
+		p.atom(head)
+		for _, arg := range args {
+			p.write(" ")
+			p.atom(arg)
+		}
+	case quoted:
+		p.write("quote ")
+		p.atom(exp.expr)
+	case unquoted:
+		p.write("~")
+		p.atom(exp.expr)
+	default:
+		p.nodes -= 1
+		p.print(exp)
+	}
+}
+
+// value writes the value of a binding, which can't be a let without
+// parentheses
+func (p *printer) value(exp Expression) {
+	switch exp.(type) {
+	case binding, replBinding:
+		p.write("(")
+		p.format(exp)
+		p.write(")")
+	default:
+		p.format(exp)
+	}
+}
+
+// atom writes exp as an atom, in parentheses unless it is one already
+func (p *printer) atom(exp Expression) {
+	switch exp.(type) {
+	case variable, freeVariable, unquoted, hole:
+		p.format(exp)
+	default:
+		p.write("(")
+		p.format(exp)
+		p.write(")")
+	}
+}
+
+// printString prints exp with print or format, for the many callers wanting
+// a string
+func printString(exp Expression, write func(*printer, Expression)) string {
+	var b strings.Builder
+	p := printer{out: &b}
+	write(&p, exp)
+	return b.String()
+}
+
+// WriteFormat writes exp to w as Format does, without holding all of it in
+// memory
+func WriteFormat(w io.Writer, exp Expression) error {
+	p := printer{out: w}
+	p.format(exp)
+	return p.err
+}
+
+// lambda is the glyph written for 𝞴
+func (p *printer) lambda() string {
+	if p.glyph == "" {
+		return "𝞴"
+	}
+	return p.glyph
+}
+
+// glyphs are the ways 𝞴 may be written, all of which the parser reads
+var glyphs = []string{"𝞴", "λ", "\\"}
+
+// omitted counts the nodes of exp, printed last, that were cut off
+func (p *printer) omitted(exp Expression) int {
+	return size(exp) - p.nodes
+}
+
+// shortCount writes a count as 950, 12.3K or 1.2M
+func shortCount(n int) string {
+	switch {
+	case n < 1000:
+		return fmt.Sprint(n)
+	case n < 1000000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1000), ".0") + "K"
+	default:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1000000), ".0") + "M"
+	}
+}
@@ -0,0 +1,55 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrinterLimit(t *testing.T) {
+	exp, _ := parse("𝞴f x.f (f (f x))")
+	tests := []struct {
+		max      int
+		expected string
+		cut      bool
+		omitted  int
+	}{
+		{0, "(𝞴f.(𝞴x.(f (f (f x)))))", false, 0},
+		{100, "(𝞴f.(𝞴x.(f (f (f x)))))", false, 0},
+		{11, "(𝞴f.(𝞴x.(f ", true, 5},
+		// every node printed, but not the parentheses closing them
+		{20, "(𝞴f.(𝞴x.(f (f (f x))", true, 0},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		p := printer{out: &out, max: test.max}
+		p.print(exp)
+		if out.String() != test.expected || p.cut != test.cut || p.cut && p.omitted(exp) != test.omitted {
+			t.Errorf("%v: expected %v, %v, %v, but got %v, %v, %v", test.max, test.expected, test.cut, test.omitted, out.String(), p.cut, p.omitted(exp))
+		}
+	}
+	for n, expected := range map[int]string{950: "950", 12345: "12.3K", 1000: "1K", 1234567: "1.2M"} {
+		if res := shortCount(n); res != expected {
+			t.Errorf("%v: expected %v, but got %v", n, expected, res)
+		}
+	}
+}
+
+func TestWriteFormat(t *testing.T) {
+	exp, _ := parse("let id = (let x = y in x) in 𝞴f g.f (quote (id ~f)) (g _)")
+	var out strings.Builder
+	expected := "let id = (let x = y in x) in 𝞴f g.f (quote (id ~f)) (g _)"
+	if err := WriteFormat(&out, exp); err != nil || out.String() != expected {
+		t.Errorf("expected %v, but got %v, %v", expected, out.String(), err)
+	}
+	// a numeral of many nodes, printed whole
+	body := Expression(variable{"x"})
+	for i := 0; i < 10000; i++ {
+		body = application{variable{"f"}, body}
+	}
+	out.Reset()
+	WriteFormat(&out, abstraction{variable{"f"}, abstraction{variable{"x"}, body}})
+	expected = "𝞴f x." + strings.Repeat("f (", 9999) + "f x" + strings.Repeat(")", 9999)
+	if out.String() != expected {
+		t.Errorf("expected the numeral 10000, but got %.40v", out.String())
+	}
+}
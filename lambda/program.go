@@ -0,0 +1,165 @@
+package lambda
+
+import "time"
+
+// defaultTraceSteps bounds traces when no step limit is given, since every step is kept
+const defaultTraceSteps = 1000
+
+// EvalOptions are the limits a caller asks for when evaluating a program
+type EvalOptions struct {
+	Strict bool `json:"strict"`
+	Trace  bool `json:"trace"`
+	// Explain traces, narrating each step in plain words
+	Explain bool `json:"explain"`
+	// ShowAlpha makes renaming to avoid capture a trace step of its own
+	ShowAlpha bool `json:"showAlpha"`
+	// Highlight locates the redex and contractum of every step
+	Highlight bool `json:"highlight"`
+	MaxSteps  int  `json:"maxSteps"`
+	TimeoutMs int  `json:"timeoutMs"`
+}
+
+// Highlight locates the redex a step contracted in the term before it and the
+// contractum that replaced it in the term after, as start and end rune offsets
+type Highlight struct {
+	Redex      [2]int `json:"redex"`
+	Contractum [2]int `json:"contractum"`
+}
+
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// EvalResult is the outcome of evaluating a program, ready to be sent as JSON
+type EvalResult struct {
+	NormalForm string   `json:"normalForm,omitempty"`
+	Size       int      `json:"size,omitempty"`
+	Steps      int      `json:"steps"`
+	Trace      []string `json:"trace,omitempty"`
+	// Explanations[i] says how Trace[i+1] came from Trace[i]
+	Explanations []string `json:"explanations,omitempty"`
+	// Highlights[i] marks the redex in Trace[i] and its contractum in Trace[i+1]
+	Highlights  []Highlight  `json:"highlights,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	err         error
+}
+
+func (r *EvalResult) fail(err error) EvalResult {
+	r.err = err
+	r.Diagnostics = append(r.Diagnostics, Diagnostic{"error", err.Error()})
+	return *r
+}
+
+func (o EvalOptions) deadline() time.Time {
+	if o.TimeoutMs <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(time.Duration(o.TimeoutMs) * time.Millisecond)
+}
+
+// Eval evaluates expr, as Parse gives it, in env. When expr is a '
+// definition the value is the one it defines, and the environment returned
+// is env with it bound; otherwise env is returned as it was.
+func Eval(expr Expression, env Environment) (Expression, Environment, error) {
+	interpreter := Interpreter{Ast: expr}
+	value, err := interpreter.Interpret(env)
+	if err != nil {
+		return nil, env, err
+	}
+	if v, ok := value.(replBinding); ok {
+		return v.value, env.define(v.name, v.value, format(expr)), nil
+	}
+	return value, env, nil
+}
+
+// EvalProgram evaluates a program in an empty environment
+func EvalProgram(program string, options EvalOptions) EvalResult {
+	res, _ := evalProgram(program, options, Environment{})
+	return res
+}
+
+// TraceProgram reduces a program one normal order step at a time, keeping every term
+func TraceProgram(program string, options EvalOptions) EvalResult {
+	res, _ := traceProgram(program, options, Environment{})
+	return res
+}
+
+// evalProgram evaluates a program in env, returning env extended by any ' binding
+func evalProgram(program string, options EvalOptions, env Environment) (EvalResult, Environment) {
+	if options.Trace || options.Explain {
+		return traceProgram(program, options, env)
+	}
+	res := EvalResult{Diagnostics: []Diagnostic{}}
+	ast, err := parse(program)
+	if err != nil {
+		return res.fail(err), env
+	}
+	interpreter := Interpreter{
+		Ast:      ast,
+		Strict:   options.Strict,
+		MaxSteps: options.MaxSteps,
+		Deadline: options.deadline(),
+	}
+	value, err := interpreter.Interpret(env)
+	res.Steps = interpreter.Steps()
+	if err != nil {
+		return res.fail(err), env
+	}
+	res.NormalForm = format(value)
+	res.Size = size(value)
+	if v, ok := value.(replBinding); ok {
+		env = env.define(v.name, v.value, program)
+	}
+	return res, env
+}
+
+func traceProgram(program string, options EvalOptions, env Environment) (EvalResult, Environment) {
+	res := EvalResult{Diagnostics: []Diagnostic{}}
+	ast, err := parse(program)
+	if err != nil {
+		return res.fail(err), env
+	}
+	if options.Strict {
+		if err := checkBound(ast, env); err != nil {
+			return res.fail(err), env
+		}
+	}
+	ast = resolve(ast, env)
+	maxSteps := options.MaxSteps
+	if maxSteps <= 0 {
+		maxSteps = defaultTraceSteps
+	}
+	deadline := options.deadline()
+	res.Trace = []string{format(ast)}
+	for {
+		next, c, ok := contractStep(ast, options.ShowAlpha)
+		if !ok {
+			break
+		}
+		if !c.alpha && res.Steps == maxSteps {
+			return res.fail(ErrStepLimit), env
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return res.fail(ErrTimeout), env
+		}
+		if options.Highlight {
+			res.Highlights = append(res.Highlights, Highlight{markedSpan(ast, c.path), markedSpan(next, c.path)})
+		}
+		ast = next
+		// renaming steps don't count as reductions
+		if !c.alpha {
+			res.Steps += 1
+		}
+		res.Trace = append(res.Trace, format(ast))
+		if options.Explain {
+			res.Explanations = append(res.Explanations, c.explain())
+		}
+	}
+	res.NormalForm = format(ast)
+	res.Size = size(ast)
+	if v, ok := ast.(replBinding); ok {
+		env = env.define(v.name, v.value, program)
+	}
+	return res, env
+}
@@ -0,0 +1,107 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTraceProgram(t *testing.T) {
+	traceCases := []struct {
+		program    string
+		trace      string
+		diagnostic string
+	}{
+		{"(𝞴x.x x) y", "(𝞴x.x x) y | y y", ""},
+		{"(𝞴x y.x) y", "(𝞴x y.x) y | 𝞴y'.y", ""},
+		{"let id = 𝞴x.x in id id", "let id = 𝞴x.x in id id | (𝞴x.x) (𝞴x.x) | 𝞴x.x", ""},
+		{"(𝞴x.x x) (𝞴x.x x)", "", "step limit reached"},
+	}
+	for _, tt := range traceCases {
+		t.Run(tt.program, func(t *testing.T) {
+			res := TraceProgram(tt.program, EvalOptions{MaxSteps: 10})
+			if tt.diagnostic == "" {
+				if trace := strings.Join(res.Trace, " | "); trace != tt.trace {
+					t.Errorf("expected %v, but got %v", tt.trace, trace)
+				}
+				return
+			}
+			if len(res.Diagnostics) != 1 || res.Diagnostics[0].Message != tt.diagnostic {
+				t.Errorf("expected %v, but got %v", tt.diagnostic, res.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	explainCases := []struct {
+		program      string
+		explanations string
+	}{
+		{"(𝞴x.x x) y", "apply 𝞴x.x x to y: substitute y for x in (x x), giving (y y)"},
+		{"(𝞴x y.x) y", "apply 𝞴x y.x to y: substitute y for x in (𝞴y.x), renaming y to y' so the free y of y isn't captured, giving (𝞴y'.y)"},
+		{"let id = 𝞴x.x in id a", "let id = 𝞴x.x: substitute (𝞴x.x) for id in (id a), giving ((𝞴x.x) a) | apply 𝞴x.x to a: substitute a for x in x, giving a"},
+	}
+	for _, tt := range explainCases {
+		t.Run(tt.program, func(t *testing.T) {
+			res := TraceProgram(tt.program, EvalOptions{Explain: true})
+			if explanations := strings.Join(res.Explanations, " | "); explanations != tt.explanations {
+				t.Errorf("expected %v, but got %v", tt.explanations, explanations)
+			}
+		})
+	}
+}
+
+func TestShowAlpha(t *testing.T) {
+	res := TraceProgram("(𝞴x y.x y) y", EvalOptions{Explain: true, ShowAlpha: true})
+	trace := "(𝞴x y.x y) y | (𝞴x y'.x y') y | 𝞴y'.y y'"
+	explanations := "α: 𝞴y.x y ⇒ 𝞴y'.x y' | apply 𝞴x y'.x y' to y: substitute y for x in (𝞴y'.x y'), giving (𝞴y'.y y')"
+	if res := strings.Join(res.Trace, " | "); res != trace {
+		t.Errorf("expected %v, but got %v", trace, res)
+	}
+	if res := strings.Join(res.Explanations, " | "); res != explanations {
+		t.Errorf("expected %v, but got %v", explanations, res)
+	}
+	if res.Steps != 1 {
+		t.Errorf("expected %v, but got %v", 1, res.Steps)
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	res := TraceProgram("𝞴f.f ((𝞴x.x x) y)", EvalOptions{Highlight: true})
+	if len(res.Highlights) != 1 {
+		t.Fatalf("expected one highlight, but got %v", res.Highlights)
+	}
+	span := func(term string, s [2]int) string {
+		return string([]rune(term)[s[0]:s[1]])
+	}
+	h := res.Highlights[0]
+	if redex := span(res.Trace[0], h.Redex); redex != "(𝞴x.x x) y" {
+		t.Errorf("expected redex (𝞴x.x x) y, but got %v", redex)
+	}
+	if contractum := span(res.Trace[1], h.Contractum); contractum != "y y" {
+		t.Errorf("expected contractum y y, but got %v", contractum)
+	}
+}
+
+func TestEval(t *testing.T) {
+	env := Environment{}
+	for _, test := range []struct {
+		program  string
+		expected string
+		names    int
+	}{
+		{"'id = 𝞴x.x", "(𝞴x.x)", 1},
+		{"'k = 𝞴x y.id x", "(𝞴x.(𝞴y.x))", 2},
+		{"k a b", "a", 2},
+	} {
+		expr, err := Parse(test.program)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var value Expression
+		value, env, err = Eval(expr, env)
+		if err != nil || value.String() != test.expected || len(env.Names()) != test.names {
+			t.Errorf("%v: expected %v with %v names, but got %v with %v, %v", test.program, test.expected, test.names, value, env.Names(), err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package lambda
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// quizAttempts bounds how many random terms are tried for each question
+const quizAttempts = 10000
+
+// QuizQuestion is a term to reduce, with its normal form and how many steps it takes
+type QuizQuestion struct {
+	Term   Expression
+	Answer Expression
+	Steps  int
+}
+
+// Quiz generates count distinct closed terms of size nodes that take between one
+// and maxSteps normal order steps to reach their normal forms. An rng seeded
+// alike gives the same quiz on every platform.
+func Quiz(rng *rand.Rand, count, size, maxSteps int) ([]QuizQuestion, error) {
+	questions := []QuizQuestion{}
+	seen := map[string]bool{}
+	for len(questions) < count {
+		found := false
+		for attempt := 0; attempt < quizAttempts && !found; attempt++ {
+			term := GenerateClosed(size, rng)
+			if term == nil || seen[format(term)] {
+				continue
+			}
+			steps, normal := 0, term
+			for steps <= maxSteps {
+				next, ok := reduceStep(normal)
+				if !ok {
+					break
+				}
+				normal = next
+				steps += 1
+			}
+			if steps == 0 || steps > maxSteps {
+				continue
+			}
+			seen[format(term)] = true
+			questions = append(questions, QuizQuestion{term, normal, steps})
+			found = true
+		}
+		if !found {
+			return questions, fmt.Errorf("found no more terms of size %v reducing in at most %v steps", size, maxSteps)
+		}
+	}
+	return questions, nil
+}
@@ -0,0 +1,25 @@
+package lambda
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestQuiz(t *testing.T) {
+	questions, err := Quiz(rand.New(rand.NewSource(1)), 5, 7, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range questions {
+		if size(q.Term) != 7 || len(freeVariables(q.Term)) != 0 {
+			t.Errorf("expected a closed term of size 7, but got %v", format(q.Term))
+		}
+		normal, err := normalize(q.Term, q.Steps)
+		if err != nil || format(normal) != format(q.Answer) || q.Steps < 1 || q.Steps > 3 {
+			t.Errorf("expected %v in %v steps, but got %v", format(q.Answer), q.Steps, format(normal))
+		}
+	}
+	if _, err := Quiz(rand.New(rand.NewSource(1)), 1, 2, 3); err == nil {
+		t.Errorf("expected an error for terms too small to reduce, but got none")
+	}
+}
@@ -0,0 +1,145 @@
+package lambda
+
+import "fmt"
+
+// quote e is e as data: a value however reducible e is, until eval reduces
+// it. Within the quoted term ~e is replaced by the value of e, spliced in as
+// code when it is quoted itself, so quoted terms can be put together as
+// programs are staged.
+
+// quoted is a term quoted as data
+type quoted struct {
+	expr Expression
+}
+
+func (quoted) isExpression() {}
+func (q quoted) String() string {
+	return printString(q, (*printer).print)
+}
+
+// unquoted is a term within a quote whose value is spliced in
+type unquoted struct {
+	expr Expression
+}
+
+func (unquoted) isExpression() {}
+func (u unquoted) String() string {
+	return printString(u, (*printer).print)
+}
+
+// splice fills in the quoted term exp: the values of the terms it unquotes,
+// and those env binds for the variables it doesn't bind itself, so eval
+// reduces it with the meaning it had where it was quoted
+func (i *Interpreter) splice(exp Expression, env Environment, bound map[string]int) Expression {
+	switch exp := exp.(type) {
+	case binding:
+		value := i.splice(exp.value, env, bound)
+		name, body := spliceBinder(exp.name, exp.body, env, bound)
+		bound[name.identifier] += 1
+		defer func() { bound[name.identifier] -= 1 }()
+		return binding{name, value, i.splice(body, env, bound)}
+	case abstraction:
+		param, body := spliceBinder(exp.param, exp.expr, env, bound)
+		bound[param.identifier] += 1
+		defer func() { bound[param.identifier] -= 1 }()
+		return abstraction{param, i.splice(body, env, bound)}
+	case application:
+		return application{i.splice(exp.left, env, bound), i.splice(exp.right, env, bound)}
+	case quoted:
+		return quoted{i.splice(exp.expr, env, bound)}
+	case unquoted:
+		value := i.eval(exp.expr, env)
+		if q, ok := value.(quoted); ok {
+			return q.expr
+		}
+		// beneath a 𝞴, a value depending on its parameter may yet be quoted
+		for name := range freeVariables(value) {
+			if v, ok := env.find(variable{name}); ok && v == (variable{name}) {
+				return unquoted{value}
+			}
+		}
+		return value
+	case variable:
+		if bound[exp.identifier] == 0 {
+			if value, ok := env.find(exp); ok {
+				return value
+			}
+		}
+		return exp
+	default:
+		return exp
+	}
+}
+
+// spliceBinder renames name, bound over body within a quote, when a value
+// splice fills body in with would have a free variable it captures, as eval
+// renames a 𝞴
+func spliceBinder(name variable, body Expression, env Environment, bound map[string]int) (variable, Expression) {
+	bound[name.identifier] += 1
+	avoid := spliced(body, env, bound)
+	bound[name.identifier] -= 1
+	if !avoid[name.identifier] {
+		return name, body
+	}
+	allNames(body, avoid)
+	renamed := variable{fresh(name.identifier, avoid)}
+	return renamed, renameQuoted(body, name.identifier, renamed.identifier, false, false)
+}
+
+// spliced collects the names free in the values splice fills the quoted term
+// exp in with, which for an unquoted term are those of the values of its names
+func spliced(exp Expression, env Environment, bound map[string]int) map[string]bool {
+	names := map[string]bool{}
+	fill := func(name string) {
+		value, ok := env.find(variable{name})
+		if !ok {
+			names[name] = true
+			return
+		}
+		for inner := range freeVariables(value) {
+			names[inner] = true
+		}
+	}
+	var walk func(exp Expression)
+	walk = func(exp Expression) {
+		switch exp := exp.(type) {
+		case binding:
+			walk(exp.value)
+			bound[exp.name.identifier] += 1
+			walk(exp.body)
+			bound[exp.name.identifier] -= 1
+		case abstraction:
+			bound[exp.param.identifier] += 1
+			walk(exp.expr)
+			bound[exp.param.identifier] -= 1
+		case application:
+			walk(exp.left)
+			walk(exp.right)
+		case quoted:
+			walk(exp.expr)
+		case unquoted:
+			for name := range freeVariables(exp.expr) {
+				fill(name)
+			}
+		case variable:
+			if bound[exp.identifier] == 0 {
+				if _, ok := env.find(exp); ok {
+					fill(exp.identifier)
+				}
+			}
+		}
+	}
+	walk(exp)
+	return names
+}
+
+func init() {
+	// eval reduces a quoted term
+	RegisterBuiltin("eval", 1, func(args []Expression) (Expression, error) {
+		q, ok := args[0].(quoted)
+		if !ok {
+			return nil, fmt.Errorf("expected a quoted term, but got %v", format(args[0]))
+		}
+		return q.expr, nil
+	})
+}
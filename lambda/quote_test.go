@@ -0,0 +1,61 @@
+package lambda
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	res := runRepl(
+		"quote ((𝞴x.x) y)",
+		"eval (quote ((𝞴x.x) y))",
+		"'id = 𝞴x.x",
+		"'q = quote (id a)",
+		"eval q",
+		"(𝞴c.quote (f ~c ~(id c))) (quote (g z))",
+		"(𝞴c.quote (𝞴x.~c x)) y",
+		"(𝞴x.quote x) y",
+		"(𝞴y.quote (𝞴x.y)) x",
+		"(𝞴y.quote (𝞴x.~y x)) x",
+		"~x",
+		"eval x",
+	)
+	expected := []string{
+		"(quote ((𝞴x.x) y))\n",
+		"y\n",
+		"id => (𝞴x.x)\n",
+		// definitions are filled in, so the term means what it did where quoted
+		"q => (quote ((𝞴x.x) a))\n",
+		"a\n",
+		"(quote ((f (g z)) (g z)))\n",
+		"(quote (𝞴x.(y x)))\n",
+		"(quote y)\n",
+		// the x put in stays free, as it does unquoted
+		"(quote (𝞴x'.x))\n",
+		"(quote (𝞴x'.(x x')))\n",
+		"~ splices into a quote, but is outside any\n",
+		"eval: expected a quoted term, but got x\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+	for _, tt := range []struct {
+		program string
+		err     string
+	}{
+		{"quote (f x)", ""},
+		// ~ needs a quote around it
+		{"f (quote x) ~y", "~ splices into a quote, but is outside any"},
+		{"quote (𝞴x.~(g x))", ""},
+	} {
+		exp, err := parse(tt.program)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%v: expected %v, but got %v", tt.program, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || format(exp) != tt.program {
+			t.Errorf("expected %v formatted as it was, but got %v %v", tt.program, format(exp), err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package lambda
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// A script is a recorded session: each input follows a prompt "> ", and the
+// lines up to the next prompt are what it printed. A script with no output at
+// all, recorded with --inputs-only or written by hand, is replayed without
+// checking what its inputs print.
+
+// scriptPrompt starts the inputs of a script
+const scriptPrompt = "> "
+
+// terminalOnly matches what the REPL prints for a terminal alone: colors, and
+// the progress of long reductions, which it erases once done
+var terminalOnly = regexp.MustCompile("\033\\[[0-9;]*m|\r\033\\[K(reducing\\.\\.\\.[^\r]*)?")
+
+// record writes an input of the session and what it printed to the script
+// being recorded
+func (r *repl) record(input, output string) {
+	if r.recordInputsOnly {
+		output = ""
+	}
+	fmt.Fprintf(r.recording, "%v%v\n%v", scriptPrompt, input, terminalOnly.ReplaceAllString(output, ""))
+}
+
+// stopRecording closes the script being recorded, if any
+func (r *repl) stopRecording() error {
+	if r.recording == nil {
+		return nil
+	}
+	err := r.recording.Close()
+	r.recording = nil
+	return err
+}
+
+// ReplayMismatch is an input of a script that printed something other than
+// was recorded
+type ReplayMismatch struct {
+	// Line is where the input is in the script, counting from 1
+	Line     int
+	Input    string
+	Expected string
+	Got      string
+}
+
+func (e ReplayMismatch) Error() string {
+	return fmt.Sprintf("line %v: %v%v\nexpected:\n%vbut got:\n%v", e.Line, scriptPrompt, e.Input, e.Expected, e.Got)
+}
+
+// scriptEntry is an input of a script and what it printed
+type scriptEntry struct {
+	line   int
+	input  string
+	output string
+}
+
+// readScript reads the inputs of a script and their outputs
+func readScript(script io.Reader) ([]scriptEntry, error) {
+	entries := []scriptEntry{}
+	scanner := bufio.NewScanner(script)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, scriptPrompt) {
+			entries = append(entries, scriptEntry{n, strings.TrimPrefix(line, scriptPrompt), ""})
+			continue
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("line %v: expected an input starting with %q, but got %v", n, scriptPrompt, line)
+		}
+		entries[len(entries)-1].output += line + "\n"
+	}
+	return entries, scanner.Err()
+}
+
+// ReplayScript runs the inputs of script in a new REPL set up by options,
+// writing what they print to out, and stops at the first printing other than
+// it did when recorded, with a ReplayMismatch. It returns the number of
+// inputs run.
+func ReplayScript(script io.Reader, out io.Writer, options ReplOptions) (int, error) {
+	entries, err := readScript(script)
+	if err != nil {
+		return 0, err
+	}
+	check := false
+	for _, entry := range entries {
+		check = check || entry.output != ""
+	}
+	var output strings.Builder
+	r := newRepl(strings.NewReader(""), &output, options)
+	r.color = false
+	for i, entry := range entries {
+		output.Reset()
+		r.line(entry.input)
+		fmt.Fprintf(out, "%v%v\n%v", scriptPrompt, entry.input, output.String())
+		got := terminalOnly.ReplaceAllString(output.String(), "")
+		if check && got != entry.output {
+			return i, ReplayMismatch{entry.line, entry.input, entry.output, got}
+		}
+	}
+	return len(entries), nil
+}
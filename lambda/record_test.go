@@ -0,0 +1,104 @@
+package lambda
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "session.script")
+	in := ":record " + script + "\n'id = 𝞴x.x\nid y\n:foo\n:record off\nid z\n"
+	var out strings.Builder
+	RunRepl(strings.NewReader(in), &out, ReplOptions{})
+	text, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "> 'id = 𝞴x.x\nid => (𝞴x.x)\n> id y\ny\n> :foo\nunknown command :foo\n"
+	if string(text) != expected {
+		t.Fatalf("expected %q, but got %q", expected, text)
+	}
+	out.Reset()
+	n, err := ReplayScript(strings.NewReader(string(text)), &out, ReplOptions{})
+	if n != 3 || err != nil || out.String() != expected {
+		t.Errorf("expected 3 inputs replayed, but got %v, %v, %q", n, err, out.String())
+	}
+	changed := strings.Replace(string(text), "\ny\n", "\nx\n", 1)
+	n, err = ReplayScript(strings.NewReader(changed), &out, ReplOptions{})
+	mismatch, ok := err.(ReplayMismatch)
+	if n != 1 || !ok || mismatch.Line != 3 || mismatch.Expected != "x\n" || mismatch.Got != "y\n" {
+		t.Errorf("expected a mismatch at line 3, but got %v, %v", n, err)
+	}
+}
+
+func TestRecordInputsOnly(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "session.script")
+	in := ":record --inputs-only " + script + "\n'id = 𝞴x.x\nid y\n"
+	var out strings.Builder
+	RunRepl(strings.NewReader(in), &out, ReplOptions{})
+	text, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(text) != "> 'id = 𝞴x.x\n> id y\n" {
+		t.Fatalf("unexpected script %q", text)
+	}
+	out.Reset()
+	if n, err := ReplayScript(strings.NewReader(string(text)), &out, ReplOptions{}); n != 2 || err != nil {
+		t.Errorf("expected 2 inputs replayed, but got %v, %v", n, err)
+	}
+	if _, err := ReplayScript(strings.NewReader("id\n> id"), &out, ReplOptions{}); err == nil {
+		t.Errorf("expected a script starting with output to be rejected")
+	}
+}
+
+func TestRecordPaste(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "session.script")
+	in := ":record " + script + "\n:paste\n'id = 𝞴x.\n  x\n.\nid y\n"
+	var out strings.Builder
+	RunRepl(strings.NewReader(in), &out, ReplOptions{})
+	text, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// what was pasted is recorded in place of :paste, as replaying can't paste
+	expected := "> 'id = 𝞴x. x\nid => (𝞴x.x)\n> id y\ny\n"
+	if string(text) != expected {
+		t.Fatalf("expected %q, but got %q", expected, text)
+	}
+	out.Reset()
+	if n, err := ReplayScript(strings.NewReader(string(text)), &out, ReplOptions{}); n != 2 || err != nil {
+		t.Errorf("expected 2 inputs replayed, but got %v, %v", n, err)
+	}
+}
+
+func TestRecordEdit(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "session.script")
+	f, err := os.Create(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	r := repl{out: &out, recording: f, editor: func(file string) error {
+		return os.WriteFile(file, []byte("'id = 𝞴x.\n  x\n"), 0o644)
+	}}
+	r.line("'id = 𝞴x.y")
+	r.line(":edit id")
+	r.line("id z")
+	f.Close()
+	text, err := os.ReadFile(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the program as edited is recorded in place of :edit, which needs an editor
+	expected := "> 'id = 𝞴x.y\nid => (𝞴x.y)\n> 'id = 𝞴x. x\nid is redefined, definitions using it keep its old value\nid => (𝞴x.x)\n> id z\nz\n"
+	if string(text) != expected {
+		t.Fatalf("expected %q, but got %q", expected, text)
+	}
+	out.Reset()
+	if n, err := ReplayScript(strings.NewReader(string(text)), &out, ReplOptions{}); n != 3 || err != nil {
+		t.Errorf("expected 3 inputs replayed, but got %v, %v", n, err)
+	}
+}
@@ -0,0 +1,69 @@
+package lambda
+
+// With :set names on, the REPL prints the parts of a value that are a
+// definition's value as its name, as plus 2 rather than all plus and 2 reduce
+// to, so values built from definitions read as they were written.
+
+// recoverNames replaces the subterms of exp alpha-equivalent to the value of
+// a definition in env with the definition's name, but for the definition
+// named skip. A subterm is only replaced when none of its free variables are
+// bound around it and the name isn't either, so the meaning stays the same.
+func recoverNames(exp Expression, env Environment, skip string) Expression {
+	names := map[string]string{}
+	// sizes rules out most subterms without canonicalizing them
+	sizes := map[int]bool{}
+	for _, b := range env.definitions() {
+		switch b.value.(type) {
+		case variable, freeVariable:
+			// a name for a name recovers nothing
+			continue
+		}
+		if b.name.identifier == skip {
+			continue
+		}
+		key := format(Canonicalize(b.value))
+		if _, ok := names[key]; !ok {
+			names[key] = b.name.identifier
+			sizes[size(b.value)] = true
+		}
+	}
+	if len(names) == 0 {
+		return exp
+	}
+	var walk func(exp Expression, bound map[string]int) Expression
+	walk = func(exp Expression, bound map[string]int) Expression {
+		if sizes[size(exp)] {
+			if name, ok := names[format(Canonicalize(exp))]; ok && bound[name] == 0 && !capturedBy(exp, bound) {
+				return variable{name}
+			}
+		}
+		switch exp := exp.(type) {
+		case binding:
+			value := walk(exp.value, bound)
+			bound[exp.name.identifier] += 1
+			defer func() { bound[exp.name.identifier] -= 1 }()
+			return binding{exp.name, value, walk(exp.body, bound)}
+		case replBinding:
+			return replBinding{exp.name, walk(exp.value, bound)}
+		case abstraction:
+			bound[exp.param.identifier] += 1
+			defer func() { bound[exp.param.identifier] -= 1 }()
+			return abstraction{exp.param, walk(exp.expr, bound)}
+		case application:
+			return application{walk(exp.left, bound), walk(exp.right, bound)}
+		default:
+			return exp
+		}
+	}
+	return walk(exp, map[string]int{})
+}
+
+// capturedBy reports whether a free variable of exp is bound around it
+func capturedBy(exp Expression, bound map[string]int) bool {
+	for name := range freeVariables(exp) {
+		if bound[name] > 0 {
+			return true
+		}
+	}
+	return false
+}
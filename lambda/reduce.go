@@ -0,0 +1,175 @@
+package lambda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The tree-walking interpreter only produces final values. Tracing needs every
+// intermediate term, so this is a small-step reducer doing one normal order
+// (leftmost outermost) reduction at a time by capture-avoiding substitution.
+
+// substitute replaces the free occurrences of name in exp with value
+func substitute(exp Expression, name string, value Expression) Expression {
+	return substituteFree(exp, name, value, freeVariables(value), nil)
+}
+
+// substituteFree substitutes value, whose free variables are free, calling
+// renamed if not nil with each binder before and after renaming it to avoid
+// capture. The work happens in the locally nameless core: closing name turns
+// it into a hole, and opening fills the hole, picking readable names for the
+// binders again only where a free variable of value would be captured.
+func substituteFree(exp Expression, name string, value Expression, free map[string]bool, renamed func(before, after Expression)) Expression {
+	o := lnOpener{hole: name, value: value, free: free, renamed: renamed}
+	return o.lnOpen(lnClose(locallyNameless(exp, nil), name, 0), nil)
+}
+
+// resolve substitutes the values env binds for the free variables of exp
+func resolve(exp Expression, env Environment) Expression {
+	names := []string{}
+	for name := range freeVariables(exp) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value, ok := env.find(variable{name}); ok {
+			exp = substitute(exp, name, value)
+		}
+	}
+	return exp
+}
+
+// contraction records what a reduction step did, so it can be explained
+type contraction struct {
+	// the redex replaced param with arg in body, either applying 𝞴param.body
+	// or, with let, binding param
+	param  variable
+	body   Expression
+	arg    Expression
+	let    bool
+	result Expression
+	// binders renamed to avoid capture, before and after
+	renames [][2]Expression
+	// alpha steps only rename, result is body with its binders renamed
+	alpha bool
+	// where the redex was, as a Diff path; the result replaced it there
+	path string
+}
+
+func contract(param variable, body, arg Expression, let, alpha bool) contraction {
+	c := contraction{param: param, body: body, arg: arg, let: let}
+	free := freeVariables(arg)
+	c.result = substituteFree(body, param.identifier, arg, free, func(before, after Expression) {
+		c.renames = append(c.renames, [2]Expression{before, after})
+	})
+	if alpha && len(c.renames) > 0 {
+		// substituting param for itself renames the same binders and nothing else
+		c.alpha = true
+		c.result = substituteFree(body, param.identifier, param, free, nil)
+	}
+	return c
+}
+
+// binderName is the variable an abstraction or let binds
+func binderName(exp Expression) variable {
+	switch exp := exp.(type) {
+	case binding:
+		return exp.name
+	case abstraction:
+		return exp.param
+	default:
+		return variable{}
+	}
+}
+
+// explain narrates a step for beginners, as in "apply 𝞴x.x x to y: substitute
+// y for x in (x x), giving (y y)"
+func (c contraction) explain() string {
+	var b strings.Builder
+	if c.alpha {
+		renames := []string{}
+		for _, r := range c.renames {
+			renames = append(renames, fmt.Sprintf("%v ⇒ %v", format(r[0]), format(r[1])))
+		}
+		return "α: " + strings.Join(renames, ", ")
+	}
+	if c.let {
+		fmt.Fprintf(&b, "let %v = %v", c.param, formatValue(c.arg))
+	} else {
+		fmt.Fprintf(&b, "apply %v to %v", format(abstraction{c.param, c.body}), formatAtom(c.arg))
+	}
+	fmt.Fprintf(&b, ": substitute %v for %v in %v", formatAtom(c.arg), c.param, formatAtom(c.body))
+	for _, r := range c.renames {
+		before, after := binderName(r[0]), binderName(r[1])
+		fmt.Fprintf(&b, ", renaming %v to %v so the free %v of %v isn't captured", before, after, before, formatAtom(c.arg))
+	}
+	fmt.Fprintf(&b, ", giving %v", formatAtom(c.result))
+	return b.String()
+}
+
+// rule names the kind of step: beta, let, or alpha for renaming alone
+func (c contraction) rule() string {
+	switch {
+	case c.alpha:
+		return "alpha"
+	case c.let:
+		return "let"
+	default:
+		return "beta"
+	}
+}
+
+// reduceStep contracts the leftmost outermost redex, reporting false when exp is
+// already in normal form
+func reduceStep(exp Expression) (Expression, bool) {
+	next, _, ok := contractStep(exp, false)
+	return next, ok
+}
+
+// contractStep is reduceStep, also saying which redex it contracted. With alpha,
+// a redex needing capture avoidance only has its binders renamed, as a step of
+// its own, and is contracted by the next step.
+func contractStep(exp Expression, alpha bool) (Expression, contraction, bool) {
+	switch exp := exp.(type) {
+	case binding:
+		c := contract(exp.name, exp.body, exp.value, true, alpha)
+		if c.alpha {
+			return binding{exp.name, exp.value, c.result}, c, true
+		}
+		return c.result, c, true
+	case replBinding:
+		value, c, ok := contractStep(exp.value, alpha)
+		c.path = "/value" + c.path
+		return replBinding{exp.name, value}, c, ok
+	case abstraction:
+		body, c, ok := contractStep(exp.expr, alpha)
+		c.path = "/body" + c.path
+		return abstraction{exp.param, body}, c, ok
+	case application:
+		// the head first, then the arguments from the left
+		head, args := spine(exp)
+		if abs, ok := head.(abstraction); ok {
+			c := contract(abs.param, abs.expr, args[0], false, alpha)
+			c.path = strings.Repeat("/fn", len(args)-1)
+			if c.alpha {
+				return unspine(abstraction{abs.param, c.result}, args), c, true
+			}
+			return unspine(c.result, args[1:]), c, true
+		}
+		if head, c, ok := contractStep(head, alpha); ok {
+			c.path = strings.Repeat("/fn", len(args)) + c.path
+			return unspine(head, args), c, true
+		}
+		for i, arg := range args {
+			if arg, c, ok := contractStep(arg, alpha); ok {
+				c.path = strings.Repeat("/fn", len(args)-1-i) + "/arg" + c.path
+				reduced := append(args[:i:i], arg)
+				return unspine(head, append(reduced, args[i+1:]...)), c, true
+			}
+		}
+		return exp, contraction{}, false
+	default:
+		return exp, contraction{}, false
+	}
+}
@@ -0,0 +1,1315 @@
+package lambda
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type repl struct {
+	in        *bufio.Reader
+	out       io.Writer
+	env       Environment
+	strict    bool
+	canonical bool
+	showAlpha bool
+	// sugar shows programs in the core calculus and sugared again
+	sugar bool
+	// names prints the parts of values that are definitions by their names
+	names bool
+	// memo reuses the normal forms of closed applications met again
+	memo bool
+	// share prints large subterms of values occurring more than once let bound
+	share bool
+	// color highlights :trace steps with ANSI escapes
+	color bool
+	// linear rejects programs not using each bound variable exactly once
+	linear bool
+	// dependent reads programs in the dependently typed mode, whose
+	// definitions are kept apart from the untyped ones
+	dependent   bool
+	definitions Dependent
+	// modules are the modules imported so far and where to look for more
+	modules modules
+	// allowNet lets :load read from URLs
+	allowNet bool
+	// warnShadow warns of binders hiding a variable or definition
+	warnShadow bool
+	// warnUnused warns of let bindings never used, and warnUnusedParams of
+	// 𝞴 parameters
+	warnUnused       bool
+	warnUnusedParams bool
+	// debug prints every expression the interpreter evaluates
+	debug bool
+	// time prints how long each evaluation took, its steps and the largest
+	// term it met
+	time bool
+	// macros are expanded in programs before they are run, by name
+	macros map[string]macro
+	// pcf evaluates programs by name with numerals, succ, pred, ifz and fix
+	pcf bool
+	// recording, if not nil, is where :record writes the inputs and, unless
+	// recordInputsOnly, outputs of the session
+	recording        io.WriteCloser
+	recordInputsOnly bool
+	// screen, while recording, is where what is shown but never recorded
+	// goes, r.out before the script was added to it
+	screen io.Writer
+	// maxOutput, if not 0, is the most runes of a value printed, and last is
+	// the value printed last, which :export --value writes whole
+	maxOutput int
+	last      Expression
+	// values are printed in syntax, fully parenthesized when it is empty,
+	// and with glyph for 𝞴
+	syntax string
+	glyph  string
+	// width, if not 0, is how wide the lines values are broken into are
+	width int
+	// cache, if set, keeps the normal forms of closed terms between sessions
+	cache *Cache
+	// editor runs the user's editor on a file for :edit, editFile if nil
+	editor func(file string) error
+	// next is a program a command has left to run after it, as :edit and
+	// :paste do
+	next string
+	// programs entered so far, for :export
+	history       []replEntry
+	progressShown bool
+}
+
+// ReplOptions set up a REPL from the start, as :set would
+type ReplOptions struct {
+	// Linear rejects programs binding a variable they don't use exactly once
+	Linear bool
+	// Path is the directories to look for imported modules in, by default
+	// those in LAMBDA_PATH and then the working directory
+	Path []string
+	// AllowNet lets :load read definitions from http and https URLs
+	AllowNet bool
+	// Debug prints every expression evaluated, as :set debug on does
+	Debug bool
+	// Persist is a state file the definitions are restored from on start and
+	// saved to on exit, if not empty
+	Persist string
+	// MaxOutput is the most runes of a value printed, 0 for no limit
+	MaxOutput int
+	// Syntax and Glyph set how values are printed, as :set syntax and :set
+	// glyph do
+	Syntax string
+	Glyph  string
+	// Width is how wide the lines values are broken into are, by default
+	// the terminal's width on a terminal and one line otherwise
+	Width int
+	// Prelude, if set, is the definitions the REPL starts with, shared with
+	// any other REPL or server started with it
+	Prelude *Prelude
+	// CacheDir, if not empty, is a directory the normal forms of closed
+	// terms are kept in, so evaluating them again, even in another session,
+	// only reads them back
+	CacheDir string
+}
+
+// Repl reads programs line by line from in and prints their values to out
+func Repl(in io.Reader, out io.Writer) {
+	RunRepl(in, out, ReplOptions{})
+}
+
+// RunRepl is Repl with options
+func RunRepl(in io.Reader, out io.Writer, options ReplOptions) {
+	r := newRepl(in, out, options)
+	defer r.stopRecording()
+	if options.Persist != "" {
+		env, err := loadState(options.Persist)
+		save := true
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			// a state that can't be read, as one of an older version, is
+			// kept aside rather than overwritten on exit
+			aside := options.Persist + ".bak"
+			if err := os.Rename(options.Persist, aside); err != nil {
+				fmt.Fprintf(r.out, "%v, so nothing will be saved\n", err)
+				save = false
+			} else {
+				fmt.Fprintf(r.out, "moved it to %v\n", aside)
+			}
+		} else if len(env.bindings) > 0 {
+			env.shared = r.env.shared
+			r.env = env
+			fmt.Fprintf(r.out, "restored %v definitions from %v\n", len(env.definitions()), options.Persist)
+		}
+		defer func() {
+			if !save {
+				return
+			}
+			if err := saveState(options.Persist, r.env); err != nil {
+				fmt.Fprintln(r.out, err)
+			}
+		}()
+	}
+	fmt.Fprint(r.out, "> ")
+	// lines are read on as one program while it has parentheses left open
+	var pending strings.Builder
+	for {
+		text, err := r.in.ReadString('\n')
+		if err != nil {
+			// what is left open is run, to be told what it lacks
+			if program := joinLines(pending.String()); program != "" {
+				r.line(program)
+			}
+			fmt.Fprintln(r.out, err)
+			break
+		}
+		pending.WriteString(text)
+		program := joinLines(pending.String())
+		// a command is run as it is, :tokens and :ast being for looking at
+		// programs left open too
+		if depth := openParens(program); depth > 0 && !strings.HasPrefix(program, ":") {
+			fmt.Fprintf(r.out, "..%v> ", depth)
+			continue
+		}
+		pending.Reset()
+		if program != "" {
+			r.line(program)
+		}
+		fmt.Fprint(r.out, "> ")
+	}
+}
+
+// openParens is how many more parentheses text opens than it closes
+func openParens(text string) int {
+	return strings.Count(text, "(") - strings.Count(text, ")")
+}
+
+func newRepl(in io.Reader, out io.Writer, options ReplOptions) *repl {
+	r := &repl{in: bufio.NewReader(in), out: out, color: isTerminal(out), linear: options.Linear, allowNet: options.AllowNet, warnShadow: true, warnUnused: true, debug: options.Debug, maxOutput: options.MaxOutput}
+	if options.Prelude != nil {
+		r.env = options.Prelude.Environment()
+	}
+	r.modules.path = options.Path
+	if r.modules.path == nil {
+		r.modules.path = defaultModulePath()
+	}
+	r.width = options.Width
+	if r.width == 0 && isTerminal(out) {
+		r.width = terminalWidth()
+	}
+	if options.CacheDir != "" {
+		cache, err := NewCache(options.CacheDir)
+		if err != nil {
+			fmt.Fprintln(out, err)
+		}
+		r.cache, r.modules.cache = cache, cache
+	}
+	for name, value := range map[string]string{"syntax": options.Syntax, "glyph": options.Glyph} {
+		if value == "" {
+			continue
+		}
+		if err := replSettings[name](r, value); err != nil {
+			fmt.Fprintln(out, err)
+		}
+	}
+	return r
+}
+
+// unrecorded are the commands never recorded: :record itself, and those
+// running a program they read from elsewhere, which is recorded in their place
+var unrecorded = map[string]bool{":record": true, ":edit": true, ":paste": true}
+
+func (r *repl) line(text string) {
+	if fields := strings.Fields(text); r.recording != nil && (len(fields) == 0 || !unrecorded[fields[0]]) {
+		var output strings.Builder
+		out := r.out
+		r.out, r.screen = io.MultiWriter(out, &output), out
+		defer func() {
+			r.out, r.screen = out, nil
+			r.record(text, output.String())
+		}()
+	}
+	if strings.HasPrefix(text, ":") {
+		if err := r.command(strings.Fields(text)); err != nil {
+			fmt.Fprintln(r.out, err)
+		}
+		if program := r.next; program != "" {
+			r.next = ""
+			r.line(program)
+		}
+		return
+	}
+	if name, ok := keywordLine(text, "import"); ok {
+		r.importModule(name)
+		return
+	}
+	if fields := strings.Fields(text); len(fields) > 0 && fields[0] == "macro" {
+		r.defineMacro(text)
+		return
+	}
+	if r.dependent {
+		r.dependentLine(text)
+		return
+	}
+	r.warn(text)
+	if r.pcf {
+		r.pcfLine(text)
+		return
+	}
+	r.history = append(r.history, replEntry{text, r.env})
+	ast, err := r.parse(text)
+	if err != nil {
+		r.echo(text)
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	if r.sugar {
+		// a definition's String is a let, which is what the core has none of
+		if v, ok := desugar(ast).(replBinding); ok {
+			fmt.Fprintf(r.out, "core: '%v = %v\n", v.name, v.value)
+		} else {
+			fmt.Fprintf(r.out, "core: %v\n", desugar(ast))
+		}
+		fmt.Fprintf(r.out, "sugared: %v\n", format(resugar(ast)))
+	}
+	if r.linear {
+		errs, _ := CheckLinear(text)
+		for _, e := range errs {
+			fmt.Fprintln(r.out, e)
+		}
+		if len(errs) > 0 {
+			return
+		}
+	}
+	interpreter := Interpreter{
+		Ast:              ast,
+		Strict:           r.strict,
+		Progress:         r.showProgress,
+		ProgressInterval: 200 * time.Millisecond,
+		Cache:            r.cache,
+		Memo:             r.memo,
+	}
+	if r.debug {
+		interpreter.Debug = r.out
+	}
+	interpreter.TrackPeak = r.time
+	start := time.Now()
+	value, err := interpreter.Interpret(r.env)
+	elapsed := time.Since(start)
+	r.clearProgress()
+	if err != nil {
+		r.echo(text)
+		fmt.Fprintln(r.out, err)
+		r.showTime(elapsed, &interpreter, nil)
+		return
+	}
+	defer r.showTime(elapsed, &interpreter, value)
+	if v, ok := value.(replBinding); ok {
+		r.define(v, text)
+	}
+	if r.canonical {
+		value = Canonicalize(value)
+	}
+	if r.names {
+		skip := ""
+		if v, ok := value.(replBinding); ok {
+			skip = v.name.identifier
+		}
+		value = recoverNames(value, r.env, skip)
+	}
+	if r.share {
+		if v, ok := value.(replBinding); ok {
+			value = replBinding{v.name, shareRepeats(v.value)}
+		} else {
+			value = shareRepeats(value)
+		}
+	}
+	switch v := value.(type) {
+	case replBinding:
+		fmt.Fprintf(r.out, "%v => ", v.name)
+		r.show(v.value)
+	default:
+		r.show(value)
+	}
+}
+
+// showTime prints how long an evaluation took, with :time on, and how many
+// steps it took. Its peak size is the largest redex contracted or the value,
+// nil if it failed, whichever is larger.
+func (r *repl) showTime(elapsed time.Duration, interpreter *Interpreter, value Expression) {
+	if !r.time {
+		return
+	}
+	peak := interpreter.PeakSize()
+	if v, ok := value.(replBinding); ok {
+		value = v.value
+	}
+	if value != nil {
+		peak = maxInt(peak, size(value))
+	}
+	fmt.Fprintf(r.out, "time %v, %v steps, peak size %v\n", elapsed.Round(time.Microsecond), interpreter.Steps(), peak)
+}
+
+// show prints a value, cut short after maxOutput runes, so a huge normal
+// form doesn't hold up the session printing it
+func (r *repl) show(value Expression) {
+	r.last = value
+	p := printer{out: r.out, max: r.maxOutput}
+	if r.render(&p, value) && p.cut {
+		fmt.Fprintf(r.out, "… (+ %v more nodes, :export --value file writes all of it)", shortCount(p.omitted(value)))
+	} else if p.cut {
+		fmt.Fprint(r.out, "… (:export --value file writes all of it)")
+	}
+	fmt.Fprintln(r.out)
+}
+
+// render writes a value with p in the syntax, glyph and width values are
+// printed in, telling whether p counted its nodes, as it doesn't writing
+// another tool's syntax or breaking lines
+func (r *repl) render(p *printer, value Expression) bool {
+	p.glyph = r.glyph
+	switch {
+	case r.width > 0 && r.syntax == "":
+		p.layout(p.prettyDoc(value), r.width)
+		return false
+	case r.width > 0 && r.syntax == "lambda":
+		p.layout(p.formatDoc(value), r.width)
+		return false
+	}
+	switch r.syntax {
+	case "":
+		p.print(value)
+	case "lambda":
+		p.format(value)
+	default:
+		text, _ := FormatSyntax(value, r.syntax)
+		p.write(text)
+		return false
+	}
+	return true
+}
+
+// echo prints the program text back highlighted, in color only, so the
+// input an error or trace is about is easier to read. The echo isn't
+// recorded, a script already having the input.
+func (r *repl) echo(text string) {
+	if !r.color {
+		return
+	}
+	out := r.out
+	if r.screen != nil {
+		out = r.screen
+	}
+	fmt.Fprintln(out, highlightInput(text))
+}
+
+// parse parses a program, expanding the macros defined so far
+func (r *repl) parse(text string) (Expression, error) {
+	ast, err := parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return expandMacros(ast, r.macros)
+}
+
+// defineMacro defines the macro of a line such as macro name params = template
+func (r *repl) defineMacro(text string) {
+	name, m, err := parseMacro(text, r.env)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	if r.macros == nil {
+		r.macros = map[string]macro{}
+	}
+	r.macros[name] = m
+	fmt.Fprintf(r.out, "%v is a macro of %v arguments\n", name, len(m.params))
+}
+
+// importModule binds the definitions of module name, qualified by its name
+func (r *repl) importModule(name string) {
+	mod, err := r.modules.load(name)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	r.env = mod.bind(r.env)
+	names := []string{}
+	for _, b := range mod.definitions {
+		names = append(names, b.name.identifier)
+	}
+	fmt.Fprintf(r.out, "imported %v: %v\n", name, strings.Join(names, " "))
+}
+
+// define binds a definition entered as text, noticing when it shadows an
+// earlier one, whose value the definitions made since keep
+func (r *repl) define(v replBinding, text string) {
+	if _, ok := r.env.find(v.name); ok {
+		fmt.Fprintf(r.out, "%v is redefined, definitions using it keep its old value\n", v.name)
+	}
+	r.env = r.env.define(v.name, v.value, text)
+}
+
+// dependentLine checks a program in the dependently typed mode, printing
+// the type of a definition, or the normal form and type of a term
+func (r *repl) dependentLine(text string) {
+	name, t, err := parseDependentLine(text)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	if name != "" {
+		typ, err := r.definitions.Define(name, t)
+		if err != nil {
+			fmt.Fprintln(r.out, err)
+			return
+		}
+		fmt.Fprintf(r.out, "%v : %v\n", name, typ)
+		return
+	}
+	typ, normal, err := r.definitions.Check(t)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	fmt.Fprintf(r.out, "%v : %v\n", normal, typ)
+}
+
+// warn prints the warnings about a program that are turned on, leaving any
+// syntax error to be reported as the program is run
+func (r *repl) warn(text string) {
+	if r.warnShadow {
+		warnings, _ := CheckShadowing(text, r.env.names())
+		for _, w := range warnings {
+			fmt.Fprintln(r.out, w)
+		}
+	}
+	warnings, _ := CheckUnused(text, r.warnUnused, r.warnUnusedParams)
+	for _, w := range warnings {
+		fmt.Fprintln(r.out, w)
+	}
+}
+
+// pcfLine evaluates a program in PCF mode to weak head normal form,
+// binding the value of a definition as the pure mode does
+func (r *repl) pcfLine(text string) {
+	ast, err := r.parse(text)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	value, err := EvalPCF(resolve(ast, r.env), defaultGradeSteps)
+	if err != nil {
+		fmt.Fprintln(r.out, err)
+		return
+	}
+	switch v := value.(type) {
+	case replBinding:
+		r.define(v, text)
+		fmt.Fprintf(r.out, "%v => %v\n", v.name, v.value)
+	default:
+		fmt.Fprintln(r.out, value)
+	}
+}
+
+// replCommands are the colon commands, each given the words following its name
+var replCommands = map[string]func(r *repl, args []string) error{
+	":set": func(r *repl, args []string) error {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: :set option value")
+		}
+		set, ok := replSettings[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown option %v", args[0])
+		}
+		return set(r, args[1])
+	},
+	":eta-expand": func(r *repl, args []string) error {
+		n := 1
+		if len(args) > 1 {
+			if count, err := strconv.Atoi(args[0]); err == nil {
+				n, args = count, args[1:]
+			}
+		}
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, format(EtaExpand(ast, n)))
+		return nil
+	},
+	// names the environment doesn't bind are unknown, so they are the dynamic ones
+	":specialize": func(r *repl, args []string) error {
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		ast = resolve(ast, r.env)
+		dynamic := []string{}
+		for name := range freeVariables(ast) {
+			dynamic = append(dynamic, name)
+		}
+		res, err := PartialEval(ast, dynamic, defaultTraceSteps)
+		fmt.Fprintln(r.out, format(res))
+		return err
+	},
+	// :trace shows each step beside the next, the redex and its contractum
+	// highlighted, or with --table as a table of steps
+	":trace": func(r *repl, args []string) error {
+		table := len(args) > 0 && args[0] == "--table"
+		if table {
+			args = args[1:]
+		}
+		r.echo(strings.Join(args, " "))
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		if r.strict {
+			if err := checkBound(ast, r.env); err != nil {
+				return err
+			}
+		}
+		ast = resolve(ast, r.env)
+		width := 0
+		terms := []Expression{ast}
+		contractions := []contraction{}
+		for len(contractions) < defaultTraceSteps {
+			next, c, ok := contractStep(ast, r.showAlpha)
+			if !ok {
+				break
+			}
+			if n := len([]rune(format(ast))); n > width {
+				width = n
+			}
+			ast = next
+			terms = append(terms, ast)
+			contractions = append(contractions, c)
+		}
+		if table {
+			traceTable(r.out, terms, contractions)
+		} else {
+			for i, c := range contractions {
+				fmt.Fprintln(r.out, sideBySide(terms[i], terms[i+1], c, width, r.color))
+			}
+		}
+		if _, _, ok := contractStep(ast, false); ok {
+			return ErrStepLimit
+		}
+		fmt.Fprintf(r.out, "normal form: %v\n", format(ast))
+		return nil
+	},
+	// :explain narrates how a program reduces, step by step
+	":explain": func(r *repl, args []string) error {
+		res, _ := traceProgram(strings.Join(args, " "), EvalOptions{Strict: r.strict, Explain: true, ShowAlpha: r.showAlpha}, r.env)
+		for i, explanation := range res.Explanations {
+			fmt.Fprintf(r.out, "%v. %v\n   => %v\n", i+1, explanation, res.Trace[i+1])
+		}
+		if res.err != nil {
+			return res.err
+		}
+		fmt.Fprintf(r.out, "normal form after %v steps: %v\n", res.Steps, res.NormalForm)
+		return nil
+	},
+	// :equiv e1 e2 takes its operands as an application, so terms that aren't
+	// variables need parentheses
+	":equiv": func(r *repl, args []string) error {
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		app, ok := ast.(application)
+		if !ok {
+			return fmt.Errorf("usage: :equiv e1 e2")
+		}
+		a, b := resolve(app.left, r.env), resolve(app.right, r.env)
+		verdict, differences := Prove(a, b, defaultGradeSteps)
+		switch verdict {
+		case Distinct:
+			d := differences[0]
+			fmt.Fprintf(r.out, "distinct (differ at %v: %v, %v)\n", d.Path, format(d.Left), format(d.Right))
+		case Unknown:
+			fmt.Fprintln(r.out, "unknown (limit reached)")
+		default:
+			fmt.Fprintln(r.out, verdict)
+		}
+		return nil
+	},
+	// :paths [depth] term follows every choice of redex, 10 steps deep by default
+	":paths": func(r *repl, args []string) error {
+		depth := 10
+		if len(args) > 1 {
+			if n, err := strconv.Atoi(args[0]); err == nil {
+				depth, args = n, args[1:]
+			}
+		}
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		report, err := ReductionPaths(resolve(ast, r.env), depth)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "%v paths reach a normal form, %v cut off after %v steps, %v terms reached\n",
+			report.Paths, report.Unfinished, depth, report.Terms)
+		for _, normal := range report.NormalForms {
+			fmt.Fprintf(r.out, "normal form: %v\n", format(normal))
+		}
+		return nil
+	},
+	// :confluent term --bound n joins every two one-step reducts of term
+	// within n steps of each, 10 by default
+	":confluent": func(r *repl, args []string) error {
+		bound, args, err := intOption(args, "--bound", 10, 0)
+		if err != nil {
+			return fmt.Errorf("usage: :confluent term --bound n")
+		}
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		fork, err := CheckConfluence(resolve(ast, r.env), bound)
+		if err != nil {
+			return err
+		}
+		if fork == nil {
+			fmt.Fprintf(r.out, "confluent: every two one-step reducts join within %v steps\n", bound)
+			return nil
+		}
+		fmt.Fprintf(r.out, "not joined within %v steps:\n  at %v: %v\n  at %v: %v\n",
+			bound, rootPath(fork.LeftPath), format(fork.Left), rootPath(fork.RightPath), format(fork.Right))
+		return nil
+	},
+	// :standardize term ; path ... reorders the reduction contracting the
+	// redexes at those paths into the standard one
+	":standardize": func(r *repl, args []string) error {
+		text, reduction, ok := strings.Cut(strings.Join(args, " "), ";")
+		if !ok {
+			return fmt.Errorf("usage: :standardize term ; path ...")
+		}
+		ast, err := parse(text)
+		if err != nil {
+			return err
+		}
+		ast = resolve(ast, r.env)
+		steps, err := Standardize(ast, strings.Fields(reduction))
+		if err != nil {
+			return err
+		}
+		terms, err := Replay(ast, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, format(ast))
+		for i, path := range steps {
+			fmt.Fprintf(r.out, "  at %v => %v\n", path, format(terms[i+1]))
+		}
+		return nil
+	},
+	// :residuals term [; path ...] labels the redexes of term and follows them
+	// through the reduction contracting those paths, normal order by default
+	":residuals": func(r *repl, args []string) error {
+		text, given, hasPaths := strings.Cut(strings.Join(args, " "), ";")
+		ast, err := parse(text)
+		if err != nil {
+			return err
+		}
+		ast = resolve(ast, r.env)
+		reduction := strings.Fields(given)
+		if !hasPaths {
+			for term := ast; len(reduction) < defaultTraceSteps; {
+				next, c, ok := contractStep(term, false)
+				if !ok {
+					break
+				}
+				term = next
+				reduction = append(reduction, rootPath(c.path))
+			}
+		}
+		steps, err := TrackResiduals(ast, reduction)
+		for i, step := range steps {
+			if i == 0 {
+				fmt.Fprintln(r.out, format(step.Term))
+			} else {
+				fmt.Fprintf(r.out, "%v. contract %v at %v => %v\n", i, step.Contracted, step.Path, format(step.Term))
+			}
+			redexes := []string{}
+			for _, redex := range step.Redexes {
+				desc := fmt.Sprintf("%v at %v", redex.Label, rootPath(redex.Path))
+				if redex.Created > 0 {
+					desc += fmt.Sprintf(" (created by step %v)", redex.Created)
+				}
+				redexes = append(redexes, desc)
+			}
+			if len(redexes) > 0 {
+				fmt.Fprintf(r.out, "   redexes: %v\n", strings.Join(redexes, ", "))
+			}
+		}
+		return err
+	},
+	// :levy [normal|applicative] term counts the steps a strategy takes against
+	// the redex families they contract
+	":levy": func(r *repl, args []string) error {
+		strategy := NormalOrder
+		if len(args) > 1 {
+			if s, err := parseStrategy(args[0]); err == nil {
+				strategy, args = s, args[1:]
+			}
+		}
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		report, err := LevyReduce(resolve(ast, r.env), strategy, defaultTraceSteps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "%v: %v steps in %v families, normal form %v\n",
+			strategy, report.Steps, len(report.Families), format(report.NormalForm))
+		for _, family := range report.Families {
+			fmt.Fprintf(r.out, "  %v: %v\n", family.Degree, family.Size)
+		}
+		return nil
+	},
+	// :cbpv [cbv|cbn] term translates term into call-by-push-value under both
+	// embeddings, or the one given, and runs the translation
+	":cbpv": func(r *repl, args []string) error {
+		embeddings := []Embedding{CallByValue, CallByName}
+		if len(args) > 1 {
+			if e, err := parseEmbedding(args[0]); err == nil {
+				embeddings, args = []Embedding{e}, args[1:]
+			}
+		}
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		ast = resolve(ast, r.env)
+		for _, e := range embeddings {
+			fmt.Fprint(r.out, formatCBPV(ast, e, defaultTraceSteps))
+		}
+		return nil
+	},
+	// :usage term says how many times each variable term binds is used, and
+	// whether term is linear or affine
+	":usage": func(r *repl, args []string) error {
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		linear, affine := true, true
+		for _, u := range Usage(ast) {
+			fmt.Fprintf(r.out, "%v at %v: %v\n", u.Name, u.Path, u.Uses)
+			linear = linear && u.Uses == Once
+			affine = affine && u.Uses != Many
+		}
+		switch {
+		case linear:
+			fmt.Fprintln(r.out, "linear")
+		case affine:
+			fmt.Fprintln(r.out, "affine")
+		default:
+			fmt.Fprintln(r.out, "neither linear nor affine")
+		}
+		return nil
+	},
+	// :strictness f says which arguments f, an abstraction, needs
+	":strictness": func(r *repl, args []string) error {
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		params := Strictness(resolve(ast, r.env), defaultTraceSteps)
+		if len(params) == 0 {
+			return fmt.Errorf("%v takes no arguments", format(ast))
+		}
+		for _, p := range params {
+			if p.Needed {
+				fmt.Fprintf(r.out, "%v: needed\n", p.Name)
+			} else {
+				fmt.Fprintf(r.out, "%v: not known to be needed\n", p.Name)
+			}
+		}
+		return nil
+	},
+	// :time on prints how long each evaluation takes, how many steps, and
+	// the size of the largest term met, after its value
+	":time": func(r *repl, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: :time on|off")
+		}
+		return setFlag(&r.time, args[0])
+	},
+	// :defs lists the definitions in scope as they were entered
+	":defs": func(r *repl, args []string) error {
+		for _, b := range r.env.definitions() {
+			fmt.Fprintln(r.out, b.source)
+		}
+		return nil
+	},
+	// :show name shows the definition of name as it was entered, and its value
+	":show": func(r *repl, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: :show name")
+		}
+		b, ok := r.env.lookup(args[0])
+		if !ok {
+			return unboundError{args[0], r.env.suggest(args[0])}
+		}
+		fmt.Fprintln(r.out, b.source)
+		fmt.Fprintf(r.out, "%v => %v\n", b.name, b.value)
+		return nil
+	},
+	// :load source binds the definitions in source, a file or a URL, as
+	// they are named there
+	":load": func(r *repl, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: :load file-or-url")
+		}
+		text, err := fetch(args[0], r.allowNet)
+		if err != nil {
+			return err
+		}
+		mod, err := r.modules.read("", args[0], text)
+		if err != nil {
+			return err
+		}
+		names := []string{}
+		for _, b := range mod.definitions {
+			r.env = r.env.define(b.name, b.value, b.source)
+			names = append(names, b.name.identifier)
+		}
+		fmt.Fprintf(r.out, "loaded %v: %v\n", args[0], strings.Join(names, " "))
+		return nil
+	},
+	// :undo removes the most recent definition, bringing back any it shadowed
+	":undo": func(r *repl, args []string) error {
+		n := len(r.env.bindings)
+		if n == 0 {
+			return fmt.Errorf("nothing to undo")
+		}
+		name := r.env.bindings[n-1].name
+		r.env = Environment{shared: r.env.shared, bindings: r.env.bindings[:n-1]}
+		if b, ok := r.env.lookup(name.identifier); ok {
+			fmt.Fprintf(r.out, "%v => %v again\n", name, b.value)
+		} else {
+			fmt.Fprintf(r.out, "%v is undefined\n", name)
+		}
+		return nil
+	},
+	// :forget name drops every definition of name
+	":forget": func(r *repl, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: :forget name")
+		}
+		if _, ok := r.env.lookup(args[0]); !ok {
+			return unboundError{args[0], r.env.suggest(args[0])}
+		}
+		r.env = r.env.forget(args[0])
+		fmt.Fprintf(r.out, "%v is undefined\n", args[0])
+		return nil
+	},
+	// :typecheck term checks term in the dependently typed mode, whichever
+	// mode the REPL is in
+	":typecheck": func(r *repl, args []string) error {
+		t, err := ParseDependent(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		typ, _, err := r.definitions.Check(t)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "%v : %v\n", t, typ)
+		return nil
+	},
+	// :tree term draws term as a tree, with the definitions it uses filled in
+	":tree": func(r *repl, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: :tree term")
+		}
+		ast, err := r.parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		writeTree(r.out, resolve(ast, r.env))
+		return nil
+	},
+	// :tokens program lists the tokens program is scanned into, with the
+	// rune offsets each spans, for seeing how it is read
+	":tokens": func(r *repl, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: :tokens program")
+		}
+		scanner := Scanner{Program: []rune(strings.Join(args, " "))}
+		tokens, err := scanner.Scan()
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			fmt.Fprintf(r.out, "%v-%v %v %q\n", t.start, t.end, t.tokenType, t.lexeme)
+		}
+		return nil
+	},
+	// :ast program shows what program parses to, fully parenthesized, its
+	// macros expanded and its lets desugared, without evaluating it
+	":ast": func(r *repl, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: :ast program")
+		}
+		ast, err := r.parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		// a definition's String is a let, which is what the core has none of
+		if v, ok := desugar(ast).(replBinding); ok {
+			fmt.Fprintf(r.out, "'%v = %v\n", v.name, v.value)
+		} else {
+			fmt.Fprintln(r.out, desugar(ast))
+		}
+		return nil
+	},
+	// :holes term lists the holes of term, with their types in the
+	// dependently typed mode
+	":holes": func(r *repl, args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("usage: :holes term")
+		}
+		text := strings.Join(args, " ")
+		var holes []Hole
+		if r.dependent {
+			t, err := ParseDependent(text)
+			if err != nil {
+				return err
+			}
+			if holes, err = r.definitions.Holes(t); err != nil {
+				return err
+			}
+		} else {
+			exp, err := parse(text)
+			if err != nil {
+				return err
+			}
+			holes = Holes(exp)
+		}
+		if len(holes) == 0 {
+			fmt.Fprintln(r.out, "no holes")
+		}
+		for _, h := range holes {
+			fmt.Fprintln(r.out, h)
+		}
+		return nil
+	},
+	// :hnf term --bound n head-reduces term at most n times, 1000 by default
+	":hnf": func(r *repl, args []string) error {
+		bound, args, err := intOption(args, "--bound", defaultTraceSteps, 0)
+		if err != nil {
+			return fmt.Errorf("usage: :hnf term --bound n")
+		}
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		h, err := HeadNormalize(resolve(ast, r.env), bound)
+		if err == ErrStepLimit {
+			fmt.Fprintf(r.out, "no head normal form within %v steps, possibly unsolvable\n", bound)
+			return nil
+		}
+		fmt.Fprintf(r.out, "solvable, head normal form after %v steps: %v\n", h.Steps, format(h.Expression()))
+		scope := "free"
+		if h.HeadBound() {
+			scope = "bound"
+		}
+		fmt.Fprintf(r.out, "head variable %v (%v), %v arguments\n", h.Head, scope, len(h.Args))
+		for i, arg := range h.Args {
+			fmt.Fprintf(r.out, "  %v. %v\n", i+1, format(arg))
+		}
+		return nil
+	},
+	// :bohm term --depth n prints the Böhm tree of term n levels deep, 4 by default
+	":bohm": func(r *repl, args []string) error {
+		depth, args, err := intOption(args, "--depth", 4, 1)
+		if err != nil {
+			return fmt.Errorf("usage: :bohm term --depth n")
+		}
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(r.out, NewBohmTree(resolve(ast, r.env), defaultTraceSteps).Format(depth))
+		return nil
+	},
+	// :separate e1 e2 takes its operands as :equiv does, printing a context
+	// sending one to true and the other to false
+	":separate": func(r *repl, args []string) error {
+		ast, err := parse(strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		app, ok := ast.(application)
+		if !ok {
+			return fmt.Errorf("usage: :separate e1 e2")
+		}
+		context, err := BohmOut(resolve(app.left, r.env), resolve(app.right, r.env), defaultGradeSteps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, FormatContext(context))
+		return nil
+	},
+	// :record [--inputs-only] file.script records the session from here on
+	// for lambda replay, and :record off stops
+	":record": func(r *repl, args []string) error {
+		if len(args) == 1 && args[0] == "off" {
+			if r.recording == nil {
+				return fmt.Errorf("not recording")
+			}
+			return r.stopRecording()
+		}
+		inputsOnly := len(args) == 2 && args[0] == "--inputs-only"
+		if inputsOnly {
+			args = args[1:]
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("usage: :record [--inputs-only] file.script, or :record off")
+		}
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		r.stopRecording()
+		r.recording, r.recordInputsOnly = f, inputsOnly
+		fmt.Fprintf(r.out, "recording to %v\n", args[0])
+		return nil
+	},
+	// :cache stats says how the cache of normal forms is used, and :cache
+	// clear empties it
+	":cache": func(r *repl, args []string) error {
+		if len(args) != 1 || args[0] != "stats" && args[0] != "clear" {
+			return fmt.Errorf("usage: :cache stats, or :cache clear")
+		}
+		if r.cache == nil {
+			return fmt.Errorf("no cache, start lambda with -cache dir to keep one")
+		}
+		if args[0] == "clear" {
+			if err := r.cache.Clear(); err != nil {
+				return err
+			}
+			fmt.Fprintf(r.out, "cleared %v\n", r.cache.Dir)
+			return nil
+		}
+		stats, err := r.cache.Stats()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.out, stats)
+		return nil
+	},
+	// :export-env file.lam writes the definitions in scope for :load
+	":export-env": func(r *repl, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: :export-env file.lam")
+		}
+		if err := os.WriteFile(args[0], []byte(r.env.prelude()), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "exported %v definitions to %v\n", len(r.env.definitions()), args[0])
+		return nil
+	},
+	":export": func(r *repl, args []string) error {
+		if len(args) == 2 && args[0] == "--value" {
+			return r.exportValue(args[1])
+		}
+		if len(args) != 1 {
+			return fmt.Errorf("usage: :export file.md, or :export --value file")
+		}
+		if err := os.WriteFile(args[0], []byte(r.markdown()), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(r.out, "exported %v programs to %v\n", len(r.history), args[0])
+		return nil
+	},
+	// :edit name opens the definition of name in $EDITOR and defines it
+	// again as saved, and :edit the last program, which it runs again
+	":edit": func(r *repl, args []string) error {
+		return r.edit(args)
+	},
+	// :paste reads lines until a lone . or the end of input, and runs them
+	// as one program
+	":paste": func(r *repl, args []string) error {
+		if len(args) != 0 {
+			return fmt.Errorf("usage: :paste, then the program and a lone .")
+		}
+		return r.paste()
+	},
+}
+
+// replSettings are the options :set can change
+var replSettings = map[string]func(r *repl, value string) error{
+	"strict": func(r *repl, value string) error {
+		return setFlag(&r.strict, value)
+	},
+	"canonical": func(r *repl, value string) error {
+		return setFlag(&r.canonical, value)
+	},
+	// print the parts of values that are the values of definitions by name
+	"names": func(r *repl, value string) error {
+		return setFlag(&r.names, value)
+	},
+	// show each program in the core calculus, and sugared again
+	"sugar": func(r *repl, value string) error {
+		return setFlag(&r.sugar, value)
+	},
+	// :explain shows renaming to avoid capture as steps of their own
+	"alpha": func(r *repl, value string) error {
+		return setFlag(&r.showAlpha, value)
+	},
+	// :trace highlights in ANSI color, and the input is echoed in color with
+	// errors and traces, on by default on a terminal
+	"color": func(r *repl, value string) error {
+		return setFlag(&r.color, value)
+	},
+	// programs are dependently typed terms, checked before they are normalized
+	"dependent": func(r *repl, value string) error {
+		return setFlag(&r.dependent, value)
+	},
+	// large subterms occurring more than once in a value are printed let bound
+	"share": func(r *repl, value string) error {
+		return setFlag(&r.share, value)
+	},
+	// closed applications met again in an evaluation reuse their normal form
+	"memo": func(r *repl, value string) error {
+		return setFlag(&r.memo, value)
+	},
+	// programs are evaluated by name with the PCF constants and numerals
+	"pcf": func(r *repl, value string) error {
+		return setFlag(&r.pcf, value)
+	},
+	// warn of binders hiding a variable bound around them or a definition
+	"warn-shadow": func(r *repl, value string) error {
+		return setFlag(&r.warnShadow, value)
+	},
+	// warn of let bindings never used
+	"warn-unused": func(r *repl, value string) error {
+		return setFlag(&r.warnUnused, value)
+	},
+	// warn of 𝞴 parameters never used, as the second of 𝞴x y.x
+	"warn-unused-params": func(r *repl, value string) error {
+		return setFlag(&r.warnUnusedParams, value)
+	},
+	// the syntax values are printed in, lambda, haskell or python, or
+	// default for lambda fully parenthesized
+	"syntax": func(r *repl, value string) error {
+		if value == "default" {
+			r.syntax = ""
+			return nil
+		}
+		if _, err := lookupSyntax(value); err != nil {
+			return err
+		}
+		r.syntax = value
+		return nil
+	},
+	// the glyph values are printed with for 𝞴: 𝞴, λ or \
+	"glyph": func(r *repl, value string) error {
+		for _, glyph := range glyphs {
+			if value == glyph {
+				r.glyph = value
+				return nil
+			}
+		}
+		return fmt.Errorf("glyph is one of %v, but got %v", strings.Join(glyphs, " "), value)
+	},
+	// the width of the lines values are broken into, 0 for one line
+	"width": func(r *repl, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("width is a number of runes, but got %v", value)
+		}
+		r.width = n
+		return nil
+	},
+	// the most runes of a value printed, 0 for no limit
+	"max-output": func(r *repl, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("max-output is a number of runes, but got %v", value)
+		}
+		r.maxOutput = n
+		return nil
+	},
+	// print every expression evaluated, with the names bound there
+	"debug": func(r *repl, value string) error {
+		return setFlag(&r.debug, value)
+	},
+	// programs must use each variable they bind exactly once
+	"linear": func(r *repl, value string) error {
+		return setFlag(&r.linear, value)
+	},
+}
+
+// intOption takes an option such as --bound n out of args, defaulting to
+// value, failing when n is less than least
+func intOption(args []string, name string, value, least int) (int, []string, error) {
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == name {
+			n, err := strconv.Atoi(args[i+1])
+			if err == nil && n < least {
+				err = fmt.Errorf("%v is at least %v, but got %v", name, least, n)
+			}
+			return n, append(args[:i:i], args[i+2:]...), err
+		}
+	}
+	return value, args, nil
+}
+
+// rootPath shows a contraction's path, which is empty at the root, as Diff does
+func rootPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func setFlag(flag *bool, value string) error {
+	switch value {
+	case "on":
+		*flag = true
+	case "off":
+		*flag = false
+	default:
+		return fmt.Errorf("expected on or off, got %v", value)
+	}
+	return nil
+}
+
+func (r *repl) command(fields []string) error {
+	command, ok := replCommands[fields[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %v", strings.Join(fields, " "))
+	}
+	return command(r, fields[1:])
+}
+
+// isTerminal reports whether out is a terminal, which can show ANSI color
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (r *repl) showProgress(p Progress) {
+	r.progressShown = true
+	fmt.Fprintf(r.out, "\r\033[Kreducing... %v steps, redex size %v", p.Steps, p.Size)
+}
+
+func (r *repl) clearProgress() {
+	if r.progressShown {
+		r.progressShown = false
+		fmt.Fprint(r.out, "\r\033[K")
+	}
+}
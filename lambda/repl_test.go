@@ -0,0 +1,822 @@
+package lambda
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// runRepl feeds lines to a REPL and returns what it printed for each
+func runRepl(lines ...string) []string {
+	var out strings.Builder
+	r := repl{out: &out}
+	res := []string{}
+	for _, line := range lines {
+		out.Reset()
+		r.line(line)
+		res = append(res, out.String())
+	}
+	return res
+}
+
+func TestRepl(t *testing.T) {
+	res := runRepl(
+		"'id = 𝞴x.x",
+		"id y",
+		":set strict on",
+		"ib y",
+		":set strict maybe",
+		":unknown",
+	)
+	expected := []string{
+		"id => (𝞴x.x)\n",
+		"y\n",
+		"",
+		"unbound variable ib, did you mean id?\n",
+		"expected on or off, got maybe\n",
+		"unknown command :unknown\n",
+	}
+	if len(res) != len(expected) {
+		t.Fatalf("expected %q, but got %q", expected, res)
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplEtaExpand(t *testing.T) {
+	res := runRepl(":eta-expand f", ":eta-expand 2 𝞴a.a")
+	if res[0] != "𝞴x.f x\n" || res[1] != "𝞴a x.a x\n" {
+		t.Errorf("unexpected expansions %q", res)
+	}
+}
+
+func TestReplSpecialize(t *testing.T) {
+	res := runRepl(
+		"'plus = 𝞴m n f x.m f (n f x)",
+		"'two = 𝞴f x.f (f x)",
+		":specialize plus two",
+		":specialize plus n two",
+	)
+	if res[2] != "𝞴n f x.f (f (n f x))\n" || res[3] != "𝞴f x.n f (f (f x))\n" {
+		t.Errorf("unexpected specializations %q", res[2:])
+	}
+}
+
+func TestReplCanonical(t *testing.T) {
+	res := runRepl(":set canonical on", "'k = 𝞴u v.u", "k x")
+	if res[1] != "k => (𝞴a.(𝞴b.a))\n" || res[2] != "(𝞴a.x)\n" {
+		t.Errorf("unexpected canonical output %q", res[1:])
+	}
+}
+
+func TestReplExplain(t *testing.T) {
+	res := runRepl("'id = 𝞴x.x", ":explain id a")
+	expected := "1. apply 𝞴x.x to a: substitute a for x in x, giving a\n   => a\nnormal form after 1 steps: a\n"
+	if res[1] != expected {
+		t.Errorf("expected %q, but got %q", expected, res[1])
+	}
+}
+
+func TestReplTrace(t *testing.T) {
+	res := runRepl("'id = 𝞴x.x", ":trace 𝞴f.f (id (id a))")
+	expected := "𝞴f.f (⟨(𝞴x.x) ((𝞴x.x) a)⟩) │ 𝞴f.f (⟨(𝞴x.x) a⟩)\n" +
+		"𝞴f.f (⟨(𝞴x.x) a⟩)        │ 𝞴f.f ⟨a⟩\n" +
+		"normal form: 𝞴f.f a\n"
+	if res[1] != expected {
+		t.Errorf("expected %q, but got %q", expected, res[1])
+	}
+	res = runRepl("'id = 𝞴x.x", ":trace --table let y = b in 𝞴f.f (id (id y))")
+	expected = "step  rule  path       size\n" +
+		"0     -     -            12\n" +
+		"    let y = b in 𝞴f.f ((𝞴x.x) ((𝞴x.x) y))\n" +
+		"1     let   /            10\n" +
+		"    𝞴f.f ((𝞴x.x) ((𝞴x.x) b))\n" +
+		"2     beta  /body/arg     7\n" +
+		"    𝞴f.f ((𝞴x.x) b)\n" +
+		"3     beta  /body/arg     4\n" +
+		"    𝞴f.f b\n" +
+		"normal form: 𝞴f.f b\n"
+	if res[1] != expected {
+		t.Errorf("expected %q, but got %q", expected, res[1])
+	}
+}
+
+func TestReplEquiv(t *testing.T) {
+	res := runRepl(
+		"'two = 𝞴f x.f (f x)",
+		":equiv (two two) (𝞴f x.f (f (f (f x))))",
+		":equiv two (𝞴f x.f x)",
+		":equiv two",
+	)
+	expected := []string{
+		"equivalent\n",
+		"distinct (differ at /body: 𝞴x.f (f x), f)\n",
+		"usage: :equiv e1 e2\n",
+	}
+	for i := range expected {
+		if res[i+1] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i+1])
+		}
+	}
+}
+
+func TestReplPaths(t *testing.T) {
+	res := runRepl("'id = 𝞴x.x", ":paths 3 (𝞴x.x x) (id z)")
+	expected := "3 paths reach a normal form, 0 cut off after 3 steps, 6 terms reached\nnormal form: z z\n"
+	if res[1] != expected {
+		t.Errorf("expected %q, but got %q", expected, res[1])
+	}
+}
+
+func TestReplConfluent(t *testing.T) {
+	res := runRepl(":confluent (𝞴x.x x x) ((𝞴y.y) z) --bound 1", ":confluent (𝞴x.x x x) ((𝞴y.y) z)", ":confluent (𝞴x.x) a --bound -2")
+	expected := []string{
+		"not joined within 1 steps:\n  at /: (𝞴y.y) z ((𝞴y.y) z) ((𝞴y.y) z)\n  at /arg: (𝞴x.x x x) z\n",
+		"confluent: every two one-step reducts join within 10 steps\n",
+		"usage: :confluent term --bound n\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplStandardize(t *testing.T) {
+	res := runRepl(":standardize (𝞴x.x x) ((𝞴y.y) z) ; /arg /", ":standardize (𝞴x.x) a")
+	expected := []string{
+		"(𝞴x.x x) ((𝞴y.y) z)\n  at / => (𝞴y.y) z ((𝞴y.y) z)\n  at /fn => z ((𝞴y.y) z)\n  at /arg => z z\n",
+		"usage: :standardize term ; path ...\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplResiduals(t *testing.T) {
+	res := runRepl(":residuals (𝞴x.(𝞴y.y) x) (𝞴z.z) a")
+	expected := "(𝞴x.(𝞴y.y) x) (𝞴z.z) a\n" +
+		"   redexes: 1 at /fn, 2 at /fn/fn/body\n" +
+		"1. contract 1 at /fn => (𝞴y.y) (𝞴z.z) a\n" +
+		"   redexes: 2 at /fn\n" +
+		"2. contract 2 at /fn => (𝞴z.z) a\n" +
+		"   redexes: 3 at / (created by step 2)\n" +
+		"3. contract 3 at / => a\n"
+	if res[0] != expected {
+		t.Errorf("expected %q, but got %q", expected, res[0])
+	}
+}
+
+func TestReplLevy(t *testing.T) {
+	res := runRepl(":levy (𝞴x.x x) ((𝞴y.y) z)", ":levy applicative (𝞴x.x x) ((𝞴y.y) z)")
+	expected := []string{
+		"normal order: 3 steps in 2 families, normal form z z\n  b: 1\n  g: 2\n",
+		"applicative order: 2 steps in 2 families, normal form z z\n  g: 1\n  b: 1\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplHnf(t *testing.T) {
+	res := runRepl(":hnf (𝞴x.𝞴f.f x x) a", ":hnf (𝞴x.x x) (𝞴x.x x) --bound 5", ":hnf a --bound b", ":hnf (𝞴x.x x) (𝞴x.x x) --bound -1", ":hnf (𝞴x.x) a --bound 0")
+	expected := []string{
+		"solvable, head normal form after 1 steps: 𝞴f.f a a\nhead variable f (bound), 2 arguments\n  1. a\n  2. a\n",
+		"no head normal form within 5 steps, possibly unsolvable\n",
+		"usage: :hnf term --bound n\n",
+		"usage: :hnf term --bound n\n",
+		"no head normal form within 0 steps, possibly unsolvable\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplBohm(t *testing.T) {
+	res := runRepl(":bohm 𝞴f.(𝞴x.f (x x)) (𝞴x.f (x x)) --depth 2", ":bohm 𝞴f.f --depth -1", ":bohm 𝞴f.f --depth 0")
+	expected := []string{"𝞴f.f\n  f\n    …\n", "usage: :bohm term --depth n\n", "usage: :bohm term --depth n\n"}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplSeparate(t *testing.T) {
+	res := runRepl(
+		"'true = 𝞴x y.x",
+		":separate (𝞴x.x) (𝞴x.x x)",
+		":separate true (𝞴a b.a)",
+	)
+	expected := []string{
+		"[] (𝞴z1 z2.z2) (𝞴t1 x y.y) (𝞴x y.x)\n",
+		"terms are not separable: they are beta-eta equal\n",
+	}
+	for i := range expected {
+		if res[i+1] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i+1])
+		}
+	}
+}
+
+func TestReplCBPV(t *testing.T) {
+	res := runRepl("'id = 𝞴x.x", ":cbpv cbn id z", ":cbpv f id")
+	expected := []string{
+		"id => (𝞴x.x)\n",
+		"call-by-name: (𝞴x.force x) (thunk (force z))\n  ⇓ force z in 2 steps\n",
+		"call-by-value: return f to f'. return (thunk (𝞴x.return x)) to a. (force f') a\n  ⇓ (force f) (thunk (𝞴x.return x)) in 2 steps\n" +
+			"call-by-name: (force f) (thunk (𝞴x.force x))\n  ⇓ (force f) (thunk (𝞴x.force x)) in 0 steps\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplLinear(t *testing.T) {
+	res := runRepl(":set linear on", "(𝞴x.x x) y", "(𝞴x.x) y")
+	expected := []string{"", "1:7: x is used again, 2 times in all, but must be used exactly once\n", "y\n"}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplUsage(t *testing.T) {
+	res := runRepl(":usage 𝞴f x.f x", ":usage 𝞴x y.x", ":usage 𝞴x.x x")
+	expected := []string{
+		"f at /: once\nx at /body: once\nlinear\n",
+		"x at /: once\ny at /body: unused\naffine\n",
+		"x at /: many times\nneither linear nor affine\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplStrictness(t *testing.T) {
+	res := runRepl("'k = 𝞴x y.x", ":strictness k", ":strictness z")
+	expected := []string{"k => (𝞴x.(𝞴y.x))\n", "x: needed\ny: not known to be needed\n", "z takes no arguments\n"}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplDependent(t *testing.T) {
+	res := runRepl(
+		":typecheck 𝞴(A : Type) (x : A).x",
+		":set dependent on",
+		"'id : Π(A : Type).A → A = 𝞴A x.x",
+		"𝞴(B : Type).id (B → B)",
+		"id Type",
+		":set dependent off",
+		"id y",
+	)
+	expected := []string{
+		"𝞴(A : Type).𝞴(x : A).x : Π(A : Type).A → A\n",
+		"",
+		"id : Π(A : Type).A → A\n",
+		"𝞴B.𝞴x.x : Π(B : Type).(B → B) → B → B\n",
+		"Type has type Type1, but expected Type\n",
+		"",
+		// the untyped definitions are apart from the typed ones
+		"(id y)\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplPCF(t *testing.T) {
+	res := runRepl(
+		":set pcf on",
+		"'double = fix (𝞴d n.ifz n 0 (succ (succ (d (pred n)))))",
+		"double 21",
+		"pred",
+	)
+	expected := []string{
+		"",
+		"double => (𝞴n.(((ifz n) 0) (succ (succ ((fix (𝞴d.(𝞴n.(((ifz n) 0) (succ (succ (d (pred n)))))))) (pred n))))))\n",
+		"42\n",
+		"pred\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplDefs(t *testing.T) {
+	res := runRepl(
+		"'id = 𝞴x.x",
+		"'k = 𝞴x y.(id x)",
+		"'id = 𝞴y.y",
+		":defs",
+		":show k",
+		":show kk",
+	)
+	expected := []string{
+		"id => (𝞴x.x)\n",
+		"k => (𝞴x.(𝞴y.x))\n",
+		"id is redefined, definitions using it keep its old value\nid => (𝞴y.y)\n",
+		// a redefinition replaces the definition it shadows
+		"'k = 𝞴x y.(id x)\n'id = 𝞴y.y\n",
+		"'k = 𝞴x y.(id x)\nk => (𝞴x.(𝞴y.x))\n",
+		"unbound variable kk, did you mean k?\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplEdit(t *testing.T) {
+	var out strings.Builder
+	// the editor replaces the text it is given with the next of saves
+	saves := []string{
+		"-- two applies f twice\n'two = 𝞴f x.\n  f (f x)\n",
+		"'two = 𝞴f x. f (f x)\n",
+		"two two\n",
+		"",
+	}
+	edited := []string{}
+	r := repl{out: &out, editor: func(file string) error {
+		text, _ := os.ReadFile(file)
+		edited = append(edited, string(text))
+		save := saves[0]
+		saves = saves[1:]
+		return os.WriteFile(file, []byte(save), 0o644)
+	}}
+	res := []string{}
+	for _, line := range []string{":edit", "'two = 𝞴f x.f x", ":edit two", ":edit two", ":edit", ":edit", ":edit tw"} {
+		out.Reset()
+		r.line(line)
+		res = append(res, out.String())
+	}
+	expected := []string{
+		"nothing to edit\n",
+		"two => (𝞴f.(𝞴x.(f x)))\n",
+		"two is redefined, definitions using it keep its old value\ntwo => (𝞴f.(𝞴x.(f (f x))))\n",
+		"unchanged\n",
+		"(𝞴x.(𝞴x'.(x (x (x (x x'))))))\n",
+		"nothing to run, the program was deleted\n",
+		"unbound variable tw, did you mean two?\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+	if len(edited) != 4 || edited[0] != "'two = 𝞴f x.f x\n" || edited[3] != "two two\n" {
+		t.Errorf("expected the definition and then the last program to be edited, but got %q", edited)
+	}
+}
+
+func TestReplPaste(t *testing.T) {
+	var out strings.Builder
+	r := repl{out: &out, in: bufio.NewReader(strings.NewReader(`'pair = 𝞴a b.
+  -- a pair is a function of what to do with both
+  𝞴f.
+    f a b
+.
+(𝞴x.
+  x)
+  y`))}
+	r.line(":paste")
+	r.line(":paste")
+	r.line(":paste")
+	expected := `pasting, end with a lone . or Ctrl-D
+pair => (𝞴a.(𝞴b.(𝞴f.((f a) b))))
+pasting, end with a lone . or Ctrl-D
+y
+pasting, end with a lone . or Ctrl-D
+nothing was pasted
+`
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestReplContinuation(t *testing.T) {
+	var out strings.Builder
+	RunRepl(strings.NewReader("(𝞴x.\n  (𝞴y.\n  -- y is dropped\n  x)\n) a b\n:ast (x\n(𝞴x.x\n"), &out, ReplOptions{})
+	expected := "> ..1> ..2> ..2> ..1> a\n> expect rightParen, but got eof\n> ..1> expect rightParen, but got eof\nEOF\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, but got %q", expected, out.String())
+	}
+}
+
+func TestReplTime(t *testing.T) {
+	res := runRepl(
+		":time on",
+		"'two = 𝞴f x.f (f x)",
+		"two two",
+		":time off",
+		"two",
+		":time",
+	)
+	expected := []string{
+		"",
+		`two => \(𝞴f.\(𝞴x.\(f \(f x\)\)\)\)\ntime [0-9.]+[µm]?s, 0 steps, peak size 7\n`,
+		`\(𝞴x.\(𝞴x'.\(x \(x \(x \(x x'\)\)\)\)\)\)\ntime [0-9.]+[µm]?s, 5 steps, peak size 15\n`,
+		"",
+		`\(𝞴f.\(𝞴x.\(f \(f x\)\)\)\)\n`,
+		"usage: :time on\\|off\n",
+	}
+	for i := range expected {
+		if !regexp.MustCompile("^" + expected[i] + "$").MatchString(res[i]) {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplTokensAst(t *testing.T) {
+	res := runRepl(
+		":tokens λx.x -> y",
+		":tokens $",
+		":ast let id = 𝞴x.x in id a b",
+		":ast 'k = \\x y.x",
+		"macro twice f = f f",
+		":ast twice a",
+		":ast (",
+	)
+	expected := []string{
+		`0-1 lambda "𝞴"
+1-2 identifier "x"
+2-3 dot "."
+3-4 identifier "x"
+4-5 whiteSpace " "
+5-7 arrow "→"
+7-8 whiteSpace " "
+8-9 identifier "y"
+`,
+		"$ cannot be used in identifier\n",
+		"((𝞴id.((id a) b)) (𝞴x.x))\n",
+		"'k = (𝞴x.(𝞴y.x))\n",
+		"twice is a macro of 1 arguments\n",
+		"(a a)\n",
+		"unexpected eof\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplUndo(t *testing.T) {
+	res := runRepl(
+		"'id = 𝞴x.x",
+		"'k = 𝞴x y.x",
+		"'id = 𝞴y.y",
+		":undo",
+		"id",
+		":forget id",
+		"id",
+		":forget id",
+		":undo",
+		":undo",
+	)
+	expected := []string{
+		"id => (𝞴x.x)\n",
+		"k => (𝞴x.(𝞴y.x))\n",
+		"id is redefined, definitions using it keep its old value\nid => (𝞴y.y)\n",
+		"id => (𝞴x.x) again\n",
+		"(𝞴x.x)\n",
+		"id is undefined\n",
+		"id\n",
+		"unbound variable id\n",
+		"k is undefined\n",
+		"nothing to undo\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplWarnShadow(t *testing.T) {
+	res := runRepl(
+		":set warn-shadow on",
+		"'k = 𝞴x y.x",
+		"𝞴k.k k",
+		":set warn-shadow off",
+		"𝞴k.k",
+	)
+	expected := []string{
+		"",
+		"k => (𝞴x.(𝞴y.x))\n",
+		"1:2: warning: k shadows the definition of k\n(𝞴k.(k k))\n",
+		"",
+		"(𝞴k.k)\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplWarnUnused(t *testing.T) {
+	res := runRepl(
+		":set warn-unused on",
+		"let flase = 𝞴x y.y in flase",
+		"let flase = 𝞴x y.y in false",
+		":set warn-unused-params on",
+		"𝞴x y.x",
+	)
+	expected := []string{
+		"",
+		"(𝞴x.(𝞴y.y))\n",
+		"1:5: warning: flase is never used, did you mean it for false?\nfalse\n",
+		"",
+		"1:4: warning: y is never used\n(𝞴x.(𝞴y.x))\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplDefinitionSyntax(t *testing.T) {
+	res := runRepl(
+		"def id = 𝞴x.x",
+		"let k = 𝞴x y.x",
+		"'two = 𝞴f x.f (f x)",
+		"k (id two) z",
+		"let i = 𝞴x.x in i",
+	)
+	expected := []string{
+		"id => (𝞴x.x)\n",
+		"k => (𝞴x.(𝞴y.x))\n",
+		"two => (𝞴f.(𝞴x.(f (f x))))\n",
+		"(𝞴f.(𝞴x.(f (f x))))\n",
+		"(𝞴x.x)\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplEcho(t *testing.T) {
+	res := runRepl(
+		"(x",
+		":set color on",
+		"(x",
+		"x",
+	)
+	expected := []string{
+		"expect rightParen, but got eof\n",
+		"",
+		ansiParens[0] + "(" + ansiReset + "x\nexpect rightParen, but got eof\n",
+		"x\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplMaxOutput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "value")
+	res := runRepl(
+		":export --value "+file,
+		":set max-output 8",
+		"'two = 𝞴f x.f (f x)",
+		"two",
+		":export --value "+file,
+		":set max-output 0",
+		"two",
+	)
+	expected := []string{
+		"no value to export yet\n",
+		"",
+		"two => (𝞴f.(𝞴x.… (+ 5 more nodes, :export --value file writes all of it)\n",
+		"(𝞴f.(𝞴x.… (+ 5 more nodes, :export --value file writes all of it)\n",
+		"exported a value of 7 nodes to " + file + "\n",
+		"",
+		"(𝞴f.(𝞴x.(f (f x))))\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+	if text, err := os.ReadFile(file); err != nil || string(text) != "(𝞴f.(𝞴x.(f (f x))))\n" {
+		t.Errorf("expected the value exported whole, but got %q, %v", text, err)
+	}
+}
+
+func TestReplGlyphSyntax(t *testing.T) {
+	res := runRepl(
+		"𝞴x y.x",
+		":set glyph λ",
+		"𝞴x y.x",
+		":set syntax lambda",
+		":set glyph \\",
+		"𝞴x y.x",
+		":set syntax haskell",
+		"𝞴x y.x",
+		":set syntax default",
+		"𝞴x y.x",
+		":set glyph L",
+		":set syntax ocaml",
+	)
+	expected := []string{
+		"(𝞴x.(𝞴y.x))\n",
+		"",
+		"(λx.(λy.x))\n",
+		"",
+		"",
+		"\\x y.x\n",
+		"",
+		"\\x y -> x\n",
+		"",
+		"(\\x.(\\y.x))\n",
+		"glyph is one of 𝞴 λ \\, but got L\n",
+		"unknown syntax ocaml, expected one of haskell, lambda, python\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplSugar(t *testing.T) {
+	res := runRepl(
+		":set sugar on",
+		"let id = 𝞴x.x in (𝞴y.id y) z",
+		"'k = 𝞴x y.x",
+	)
+	expected := []string{
+		"",
+		"core: ((𝞴id.((𝞴y.(id y)) z)) (𝞴x.x))\nsugared: let id = 𝞴x.x in let y = z in id y\nz\n",
+		"core: 'k = (𝞴x.(𝞴y.x))\nsugared: 'k = 𝞴x y.x\nk => (𝞴x.(𝞴y.x))\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplNames(t *testing.T) {
+	res := runRepl(
+		":set names on",
+		"'succ = 𝞴n f x.f (n f x)",
+		"'two = 𝞴f x.f (f x)",
+		"'pair = 𝞴a b s.s a b",
+		"pair two (𝞴g y.g (g y))",
+		"pair (𝞴n f x.f (n f x)) (𝞴f.two)",
+		// the two beneath a binder of two is another one
+		"𝞴two.two (𝞴f x.f (f x))",
+		"𝞴f.𝞴x.f (f x)",
+		// a subterm whose variables are bound around it is no definition
+		"𝞴f.f (𝞴x.f (f x))",
+	)
+	expected := []string{
+		"",
+		"succ => (𝞴n.(𝞴f.(𝞴x.(f ((n f) x)))))\n",
+		"two => (𝞴f.(𝞴x.(f (f x))))\n",
+		"pair => (𝞴a.(𝞴b.(𝞴s.((s a) b))))\n",
+		"(𝞴s.((s two) two))\n",
+		"(𝞴s.((s succ) (𝞴f.two)))\n",
+		"(𝞴two.(two (𝞴f.(𝞴x.(f (f x))))))\n",
+		"two\n",
+		"(𝞴f.(f (𝞴x.(f (f x)))))\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplWidth(t *testing.T) {
+	res := runRepl(
+		":set width 12",
+		"𝞴f x.f (f x) y",
+		":set width 0",
+		"𝞴f x.f (f x) y",
+	)
+	expected := []string{
+		"",
+		"(𝞴f.\n  (𝞴x.\n    ((f\n      (f x))\n      y)))\n",
+		"",
+		"(𝞴f.(𝞴x.((f (f x)) y)))\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
+
+func TestReplTree(t *testing.T) {
+	res := runRepl("'id = 𝞴x.x", ":tree let y = id in 𝞴f.f y (g f)", ":tree")
+	expected := []string{
+		"",
+		`let y
+├── 𝞴x
+│   └── x
+└── 𝞴f
+    └── @
+        ├── @
+        │   ├── f
+        │   └── y
+        └── @
+            ├── g
+            └── f
+`,
+		"usage: :tree term\n",
+	}
+	for i := range expected[1:] {
+		if res[i+1] != expected[i+1] {
+			t.Errorf("expected %q, but got %q", expected[i+1], res[i+1])
+		}
+	}
+}
+
+func TestReplCache(t *testing.T) {
+	var out strings.Builder
+	r := newRepl(strings.NewReader(""), &out, ReplOptions{CacheDir: t.TempDir()})
+	lines := []string{"(𝞴x.x) (𝞴y.y)", "(𝞴a.a) (𝞴b.b)", "f", ":cache stats", ":cache clear", ":cache stats", ":cache"}
+	res := []string{}
+	for _, line := range lines {
+		out.Reset()
+		r.line(line)
+		res = append(res, out.String())
+	}
+	expected := []string{
+		"(𝞴y.y)\n",
+		// read back as it was first evaluated, up to renaming
+		"(𝞴y.y)\n",
+		"f\n",
+		"1 hits, 1 misses, 1 entries, 23 bytes\n",
+		"cleared " + r.cache.Dir + "\n",
+		"1 hits, 1 misses, 0 entries, 0 bytes\n",
+		"usage: :cache stats, or :cache clear\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+	if res := runRepl(":cache stats"); res[0] != "no cache, start lambda with -cache dir to keep one\n" {
+		t.Errorf("expected no cache, but got %q", res[0])
+	}
+}
+
+func TestReplShare(t *testing.T) {
+	res := runRepl(
+		":set share on",
+		"'four = 𝞴f x.f (f (f (f x)))",
+		"𝞴p.p four four",
+		"g (g four four) (g four four)",
+		"𝞴y.p (y four) (y four)",
+		"𝞴p.p (𝞴x.x) (𝞴x.x)",
+	)
+	expected := []string{
+		"",
+		"four => (𝞴f.(𝞴x.(f (f (f (f x))))))\n",
+		"let s1 = (𝞴f.(𝞴x.(f (f (f (f x)))))) in (𝞴p.((p s1) s1))\n",
+		// the larger repeat is shared first, and four within it after
+		"let s2 = (𝞴f.(𝞴x.(f (f (f (f x)))))) in let s1 = ((g s2) s2) in ((g s1) s1)\n",
+		// y four is bound by y, so only four is shared
+		"let s1 = (𝞴f.(𝞴x.(f (f (f (f x)))))) in (𝞴y.((p (y s1)) (y s1)))\n",
+		"(𝞴p.((p (𝞴x.x)) (𝞴x.x)))\n",
+	}
+	for i := range expected {
+		if res[i] != expected[i] {
+			t.Errorf("expected %q, but got %q", expected[i], res[i])
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelledRedex is a redex of a term named by the label of the redex it is a
+// residual of, Created is the step that created that one, 0 for the original term
+type LabelledRedex struct {
+	Label   int
+	Created int
+	Path    string
+}
+
+// ResidualStep is a term of a reduction with its labelled redexes. Contracted
+// is the label of the redex whose contraction, at Path, led to Term, 0 for the
+// first term.
+type ResidualStep struct {
+	Term       Expression
+	Path       string
+	Contracted int
+	Redexes    []LabelledRedex
+}
+
+// freeOccurrences lists the paths of the free occurrences of name in exp
+func freeOccurrences(exp Expression, name string) []string {
+	switch exp := exp.(type) {
+	case binding:
+		res := prefixPaths("/value", freeOccurrences(exp.value, name))
+		if exp.name.identifier != name {
+			res = append(res, prefixPaths("/body", freeOccurrences(exp.body, name))...)
+		}
+		return res
+	case replBinding:
+		return prefixPaths("/value", freeOccurrences(exp.value, name))
+	case abstraction:
+		if exp.param.identifier == name {
+			return nil
+		}
+		return prefixPaths("/body", freeOccurrences(exp.expr, name))
+	case application:
+		return append(prefixPaths("/fn", freeOccurrences(exp.left, name)), prefixPaths("/arg", freeOccurrences(exp.right, name))...)
+	case variable:
+		if exp.identifier == name {
+			return []string{""}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+func prefixPaths(prefix string, paths []string) []string {
+	for i, p := range paths {
+		paths[i] = prefix + p
+	}
+	return paths
+}
+
+// residualPaths says where the redex at path goes when the redex at contracted,
+// in term, is contracted: nowhere for the contracted redex itself, into the
+// contractum for redexes in its body, to every substituted copy for redexes in
+// its argument, and nowhere else for the rest
+func residualPaths(term Expression, contracted, path string) []string {
+	if path == contracted {
+		return nil
+	}
+	if !strings.HasPrefix(path, contracted+"/") {
+		return []string{path}
+	}
+	var param string
+	var body Expression
+	var bodyStep, argStep string
+	switch redex := subterm(term, contracted).(type) {
+	case binding:
+		param, body, bodyStep, argStep = redex.name.identifier, redex.body, "/body", "/value"
+	case application:
+		abs := redex.left.(abstraction)
+		param, body, bodyStep, argStep = abs.param.identifier, abs.expr, "/fn/body", "/arg"
+	}
+	rest := path[len(contracted):]
+	if rest == bodyStep || strings.HasPrefix(rest, bodyStep+"/") {
+		return []string{contracted + rest[len(bodyStep):]}
+	}
+	if strings.HasPrefix(rest, argStep+"/") || rest == argStep {
+		res := []string{}
+		for _, o := range freeOccurrences(body, param) {
+			res = append(res, contracted+o+rest[len(argStep):])
+		}
+		return res
+	}
+	// the 𝞴 of the redex is an abstraction, not a redex
+	return nil
+}
+
+// subterm is the subterm of exp at path
+func subterm(exp Expression, path string) Expression {
+	for path != "" {
+		step := path[1:]
+		if i := strings.Index(step, "/"); i >= 0 {
+			step, path = step[:i], step[i:]
+		} else {
+			path = ""
+		}
+		switch e := exp.(type) {
+		case binding:
+			if step == "value" {
+				exp = e.value
+			} else {
+				exp = e.body
+			}
+		case replBinding:
+			exp = e.value
+		case abstraction:
+			exp = e.expr
+		case application:
+			if step == "fn" {
+				exp = e.left
+			} else {
+				exp = e.right
+			}
+		}
+	}
+	return exp
+}
+
+// TrackResiduals follows a reduction of e, given as the paths of the redexes
+// it contracts, labelling the redexes of e 1, 2, ... from left to right and
+// every later redex with the label of the one it is a residual of. Redexes a
+// step creates get the next free labels.
+func TrackResiduals(e Expression, reduction []string) ([]ResidualStep, error) {
+	labels := map[string]LabelledRedex{}
+	next := 1
+	label := func(term Expression, step int) []LabelledRedex {
+		res := []LabelledRedex{}
+		for _, r := range reducts(term) {
+			l, ok := labels[r.c.path]
+			if !ok {
+				l = LabelledRedex{next, step, r.c.path}
+				next += 1
+			}
+			res = append(res, l)
+		}
+		return res
+	}
+	steps := []ResidualStep{{Term: e, Redexes: label(e, 0)}}
+	for i, path := range reduction {
+		path = strings.TrimSuffix(path, "/")
+		term := steps[i].Term
+		after, _, ok := contractAt(term, path)
+		if !ok {
+			return steps, fmt.Errorf("step %v: no redex at %v in %v", i+1, rootPath(path), format(term))
+		}
+		contracted := 0
+		labels = map[string]LabelledRedex{}
+		for _, r := range steps[i].Redexes {
+			if r.Path == path {
+				contracted = r.Label
+			}
+			for _, p := range residualPaths(term, path, r.Path) {
+				labels[p] = LabelledRedex{r.Label, r.Created, p}
+			}
+		}
+		steps = append(steps, ResidualStep{after, rootPath(path), contracted, label(after, i+1)})
+	}
+	return steps, nil
+}
@@ -0,0 +1,41 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTrackResiduals(t *testing.T) {
+	residualCases := []struct {
+		program   string
+		reduction string
+		labels    string
+	}{
+		// the argument's redex is copied, each copy a residual of it
+		{"(𝞴x.x x) ((𝞴y.y) z)", "/ /fn", "1@/ 2@/arg | 2@/fn 2@/arg | 2@/arg"},
+		// the body's redex stays, contracting it creates a new one
+		{"(𝞴x.(𝞴y.y) x) (𝞴z.z) a", "/fn /fn", "1@/fn 2@/fn/fn/body | 2@/fn | 3@/"},
+		{"let i = 𝞴x.x in i ((𝞴y.y) a)", "/", "1@/ 2@/body/arg | 3@/ 2@/arg"},
+	}
+	for _, tt := range residualCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			steps, err := TrackResiduals(exp, strings.Fields(tt.reduction))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res := []string{}
+			for _, step := range steps {
+				redexes := []string{}
+				for _, r := range step.Redexes {
+					redexes = append(redexes, fmt.Sprintf("%v@%v", r.Label, rootPath(r.Path)))
+				}
+				res = append(res, strings.Join(redexes, " "))
+			}
+			if labels := strings.Join(res, " | "); labels != tt.labels {
+				t.Errorf("expected %v, but got %v", tt.labels, labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package lambda
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// rpcSession is an interpreter driven over JSON-RPC, keeping its environment
+// between calls like the REPL does
+type rpcSession struct {
+	env Environment
+}
+
+type rpcProgramParams struct {
+	Program string      `json:"program"`
+	Options EvalOptions `json:"options"`
+}
+
+type rpcBinding struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// ServeRPC answers JSON-RPC 2.0 requests over in and out, framed as in LSP, with
+// the methods parse, eval, step and env
+func ServeRPC(in io.Reader, out io.Writer) error {
+	conn := newRPCConn(in, out)
+	session := rpcSession{}
+	for {
+		msg, err := conn.read()
+		if err == io.EOF {
+			return nil
+		}
+		if e, ok := err.(*rpcError); ok {
+			// the framing survived, so the stream can go on
+			if err := conn.reply(json.RawMessage("null"), nil, e); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		result, err := session.handle(msg)
+		if msg.ID == nil {
+			continue
+		}
+		if err := conn.reply(msg.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *rpcSession) handle(msg rpcMessage) (interface{}, error) {
+	if msg.Jsonrpc != "2.0" {
+		return nil, &rpcError{rpcInvalidRequest, "jsonrpc must be 2.0"}
+	}
+	var params rpcProgramParams
+	if len(msg.Params) > 0 {
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+	}
+	switch msg.Method {
+	case "parse":
+		ast, err := parse(params.Program)
+		if err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		return map[string]string{"expression": format(ast), "parenthesized": ast.String()}, nil
+	case "eval":
+		var res EvalResult
+		res, s.env = evalProgram(params.Program, params.Options, s.env)
+		return res, nil
+	case "step":
+		ast, err := parse(params.Program)
+		if err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		next, ok := reduceStep(resolve(ast, s.env))
+		return map[string]interface{}{"expression": format(next), "normal": !ok}, nil
+	case "env":
+		bindings := []rpcBinding{}
+		for _, name := range s.env.names() {
+			value, _ := s.env.find(variable{name})
+			bindings = append(bindings, rpcBinding{name, format(value)})
+		}
+		return bindings, nil
+	default:
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("method %v not found", msg.Method)}
+	}
+}
@@ -0,0 +1,44 @@
+package lambda
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRPC(t *testing.T) {
+	in := lspRequests(
+		`{"jsonrpc": "2.0", "id": 1, "method": "parse", "params": {"program": "𝞴x y.x"}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "eval", "params": {"program": "'k = 𝞴x y.x"}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "step", "params": {"program": "k a"}}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "env"}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "parse", "params": {"program": "(x"}}`,
+		`{"jsonrpc": "2.0", "id": 6, "method": "nope"}`,
+	)
+	var out bytes.Buffer
+	if err := ServeRPC(strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`{"expression":"𝞴x y.x","parenthesized":"(𝞴x.(𝞴y.x))"}`,
+		`{"diagnostics":[],"normalForm":"'k = 𝞴x y.x","size":4,"steps":0}`,
+		`{"expression":"𝞴y.a","normal":false}`,
+		`[{"name":"k","value":"𝞴x y.x"}]`,
+		`{"code":-32602,"message":"expect rightParen, but got eof"}`,
+		`{"code":-32601,"message":"method nope not found"}`,
+	}
+	responses := lspResponses(t, &out)
+	if len(responses) != len(expected) {
+		t.Fatalf("expected %v responses, but got %v", len(expected), len(responses))
+	}
+	for i, res := range responses {
+		result, ok := res["result"]
+		if !ok {
+			result = res["error"]
+		}
+		if got, _ := json.Marshal(result); string(got) != expected[i] {
+			t.Errorf("expected %v, but got %s", expected[i], got)
+		}
+	}
+}
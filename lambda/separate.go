@@ -0,0 +1,226 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotSeparable is returned for terms BohmOut can't separate, which are
+// equal up to beta-eta, or not closed
+var ErrNotSeparable = errors.New("terms are not separable")
+
+var (
+	churchTrue  = abstraction{variable{"x"}, abstraction{variable{"y"}, variable{"x"}}}
+	churchFalse = abstraction{variable{"x"}, abstraction{variable{"y"}, variable{"y"}}}
+	identity    = abstraction{variable{"w"}, variable{"w"}}
+)
+
+// lambdas wraps body in binders named u1, u2, ... un, or with prefix
+func lambdas(prefix string, n int, body Expression) Expression {
+	for i := n; i >= 1; i-- {
+		body = abstraction{variable{fmt.Sprintf("%v%v", prefix, i)}, body}
+	}
+	return body
+}
+
+// tuple is 𝞴u1 ... un z.z u1 ... un, which a Böhm transformation substitutes
+// for a variable applied to at most n arguments: the arguments are kept, to
+// be picked out by a projection passed for z
+func tuple(n int) Expression {
+	var body Expression = variable{"z"}
+	for i := 1; i <= n; i++ {
+		body = application{body, variable{fmt.Sprintf("u%v", i)}}
+	}
+	return lambdas("u", n, abstraction{variable{"z"}, body})
+}
+
+// projection is 𝞴v1 ... vn.vi
+func projection(i, n int) Expression {
+	return lambdas("v", n, variable{fmt.Sprintf("v%v", i)})
+}
+
+// maxArity is the most arguments name is applied to anywhere free in exp
+func maxArity(exp Expression, name string) int {
+	head, args := spine(exp)
+	res := 0
+	if v, ok := head.(variable); ok && v.identifier == name {
+		res = len(args)
+	}
+	for _, arg := range args {
+		res = maxInt(res, maxArity(arg, name))
+	}
+	if abs, ok := head.(abstraction); ok && abs.param.identifier != name {
+		res = maxInt(res, maxArity(abs.expr, name))
+	}
+	return res
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// betaEtaNormal is the beta-eta normal form of exp
+func betaEtaNormal(exp Expression, maxSteps int) (Expression, error) {
+	exp, err := normalize(exp, maxSteps)
+	return Simplify(exp), err
+}
+
+// BohmOut finds closed terms T1 ... Tk such that a T1 ... Tk reduces to
+// 𝞴x y.x and b T1 ... Tk to 𝞴x y.y, for closed terms a and b with distinct
+// beta-eta normal forms, following the proof of Böhm's theorem. At each level
+// the binders of both head normal forms are replaced: when their heads or the
+// numbers of arguments differ, by terms returning true and false right away,
+// otherwise by tuples keeping the arguments, so that a projection picks out a
+// pair of arguments that still differ, to separate in turn.
+func BohmOut(a, b Expression, maxSteps int) ([]Expression, error) {
+	if len(freeVariables(a)) > 0 || len(freeVariables(b)) > 0 {
+		return nil, fmt.Errorf("%w: only closed terms can be separated", ErrNotSeparable)
+	}
+	a, err := betaEtaNormal(a, maxSteps)
+	if err != nil {
+		return nil, err
+	}
+	b, err = betaEtaNormal(b, maxSteps)
+	if err != nil {
+		return nil, err
+	}
+	origA, origB := a, b
+	context := []Expression{}
+	// widest is the widest tuple substituted at any level so far
+	widest := 0
+	for {
+		if len(Diff(a, b, true)) == 0 {
+			return nil, fmt.Errorf("%w: they are beta-eta equal", ErrNotSeparable)
+		}
+		hA, _ := HeadNormalize(a, 0)
+		hB, _ := HeadNormalize(b, 0)
+		n := maxInt(len(hA.Binders), len(hB.Binders))
+		// applied to n arguments, a term with fewer binders passes the rest to its head
+		indexA, indexB := binderIndex(hA), binderIndex(hB)
+		arityA := len(hA.Args) + n - len(hA.Binders)
+		arityB := len(hB.Args) + n - len(hB.Binders)
+		args := make([]Expression, n)
+		for j := range args {
+			args[j] = identity
+		}
+		done := true
+		switch {
+		case indexA != indexB:
+			args[indexA] = lambdas("u", arityA, churchTrue)
+			args[indexB] = lambdas("u", arityB, churchFalse)
+		case arityA != arityB:
+			// the head drops every argument and returns the one after them,
+			// which comes d arguments later for the head with fewer
+			fewer, more, result := arityA, arityB, Expression(churchTrue)
+			other := Expression(churchFalse)
+			if arityA > arityB {
+				fewer, more, result, other = arityB, arityA, churchFalse, churchTrue
+			}
+			args[indexA] = lambdas("z", more+1, variable{fmt.Sprintf("z%v", more+1)})
+			d := more - fewer + 1
+			args = append(args, lambdas("t", d-1, other))
+			for k := 2; k < d; k++ {
+				args = append(args, identity)
+			}
+			args = append(args, result)
+		default:
+			done = false
+			// wider than any use, so no tuple can be mistaken for a term the
+			// arguments already held, such as 𝞴z.z for the identity, and of a
+			// different width for each binder, even those of levels above, so
+			// no two can be mistaken for one another
+			width := maxInt(arityA, widest) + 1
+			for j := 0; j < n; j++ {
+				width = maxInt(width, maxInt(binderArity(hA, j), binderArity(hB, j))+1)
+			}
+			arities := make([]int, n)
+			for j := range arities {
+				arities[j] = width + j
+			}
+			widest = maxInt(widest, width+n-1)
+			for j := range args {
+				args[j] = tuple(arities[j])
+			}
+			for k := arityA; k < arities[indexA]; k++ {
+				args = append(args, identity)
+			}
+		}
+		applyA, applyB := a, b
+		for _, arg := range args {
+			applyA, applyB = application{applyA, arg}, application{applyB, arg}
+		}
+		context = append(context, args...)
+		if done {
+			break
+		}
+		// both are now 𝞴z.z A1 ... Ar, differing in some argument
+		if a, err = betaEtaNormal(applyA, maxSteps); err != nil {
+			return nil, err
+		}
+		if b, err = betaEtaNormal(applyB, maxSteps); err != nil {
+			return nil, err
+		}
+		tA, _ := HeadNormalize(a, 0)
+		tB, _ := HeadNormalize(b, 0)
+		r := len(tA.Args)
+		for i := range tA.Args {
+			if len(Diff(tA.Args[i], tB.Args[i], true)) > 0 {
+				context = append(context, projection(i+1, r))
+				a, b = tA.Args[i], tB.Args[i]
+				break
+			}
+		}
+	}
+	if err := checkSeparates(origA, origB, context, maxSteps); err != nil {
+		return nil, err
+	}
+	return context, nil
+}
+
+// binderIndex is the position of the head variable among the binders
+func binderIndex(h HeadNormalForm) int {
+	for i := len(h.Binders) - 1; i >= 0; i-- {
+		if h.Binders[i] == h.Head {
+			return i
+		}
+	}
+	return -1
+}
+
+// binderArity is the most arguments the jth binder of h is applied to
+func binderArity(h HeadNormalForm, j int) int {
+	if j >= len(h.Binders) {
+		return 0
+	}
+	for _, later := range h.Binders[j+1:] {
+		if later == h.Binders[j] {
+			return 0
+		}
+	}
+	return maxArity(HeadNormalForm{Head: h.Head, Args: h.Args}.Expression(), h.Binders[j])
+}
+
+func checkSeparates(a, b Expression, context []Expression, maxSteps int) error {
+	for _, arg := range context {
+		a, b = application{a, arg}, application{b, arg}
+	}
+	a, errA := normalize(a, maxSteps)
+	b, errB := normalize(b, maxSteps)
+	if errA != nil || errB != nil || len(Diff(a, churchTrue, true)) > 0 || len(Diff(b, churchFalse, true)) > 0 {
+		return fmt.Errorf("%w: the context found gives %v and %v", ErrNotSeparable, format(a), format(b))
+	}
+	return nil
+}
+
+// FormatContext prints the context applying the hole to args, as [] T1 ... Tk
+func FormatContext(args []Expression) string {
+	res := []string{"[]"}
+	for _, arg := range args {
+		res = append(res, formatAtom(arg))
+	}
+	return strings.Join(res, " ")
+}
@@ -0,0 +1,65 @@
+package lambda
+
+import "testing"
+
+func TestBohmOut(t *testing.T) {
+	separateCases := []struct {
+		a string
+		b string
+	}{
+		{"𝞴x y.x", "𝞴x y.y"},
+		{"𝞴x y.y", "𝞴x y.x"},
+		// same head, different numbers of arguments
+		{"𝞴x.x", "𝞴x.x x"},
+		{"𝞴f x.f (f x)", "𝞴f x.f x"},
+		// Church numerals, the difference deep inside
+		{"𝞴f x.f (f (f x))", "𝞴f x.f (f x)"},
+		{"𝞴x.x (𝞴y.y x)", "𝞴x.x (𝞴y.x y y)"},
+		// the head occurs again with other arities
+		{"𝞴x.x (x x) x", "𝞴x.x (x (𝞴y.y)) x"},
+		// a tuple of a deeper level as wide as one of the level above
+		{"𝞴x.x (𝞴y.y x)", "𝞴x.x (𝞴y.y y)"},
+	}
+	for _, tt := range separateCases {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, _ := parse(tt.a)
+			b, _ := parse(tt.b)
+			context, err := BohmOut(a, b, 10000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := checkSeparates(a, b, context, 10000); err != nil {
+				t.Errorf("%v: %v", FormatContext(context), err)
+			}
+		})
+	}
+	a, _ := parse("𝞴f.𝞴x.f x")
+	b, _ := parse("𝞴g.g")
+	if _, err := BohmOut(a, b, 1000); err == nil || err.Error() != "terms are not separable: they are beta-eta equal" {
+		t.Errorf("expected them not to be separable, but got %v", err)
+	}
+}
+
+// every two closed normal forms that differ up to eta are separated
+func TestBohmOutNormalForms(t *testing.T) {
+	terms := []Expression{}
+	EnumerateNormal(8, func(term Expression) bool {
+		terms = append(terms, term)
+		return true
+	})
+	for i, a := range terms {
+		for _, b := range terms[i+1:] {
+			if len(Diff(Simplify(a), Simplify(b), true)) == 0 {
+				continue
+			}
+			context, err := BohmOut(a, b, 10000)
+			if err != nil {
+				t.Errorf("%v and %v: %v", format(a), format(b), err)
+				continue
+			}
+			if err := checkSeparates(a, b, context, 10000); err != nil {
+				t.Errorf("%v and %v by %v: %v", format(a), format(b), FormatContext(context), err)
+			}
+		}
+	}
+}
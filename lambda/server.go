@@ -0,0 +1,174 @@
+package lambda
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+//go:embed playground
+var playground embed.FS
+
+// Server evaluates programs over HTTP, each request in a fresh environment
+type Server struct {
+	MaxSteps int
+	Timeout  time.Duration
+	// Playground serves the browser playground at /
+	Playground bool
+	// WasmDir holds lambda.wasm and wasm_exec.js for the playground to run
+	// in the browser, without it the playground evaluates through /eval
+	WasmDir string
+	// SessionTTL is how long an idle session is kept, 0 keeps them forever
+	SessionTTL time.Duration
+	// MaxSessions bounds how many sessions exist at once, 0 means unbounded
+	MaxSessions int
+	// ShareTTL is how long a shared program is kept, 0 keeps them forever
+	ShareTTL time.Duration
+	// MaxShareSize bounds shared programs in bytes, 0 means 64KiB
+	MaxShareSize int
+	// MaxShares bounds how many shared programs are kept, 0 means unbounded
+	MaxShares int
+	// Prelude, if set, is the definitions every request and session starts
+	// with, shared by all of them
+	Prelude *Prelude
+	// Workers bounds how many evaluations run at once, 0 means one per CPU.
+	// MaxQueue more may wait for a worker, as long as the timeout, 0 meaning
+	// as many as there are workers; those past that are refused with 503.
+	Workers  int
+	MaxQueue int
+	// MaxPerClient bounds how many requests from one address are handled at
+	// once, 0 means unbounded; those past it are refused with 429
+	MaxPerClient int
+	// MaxBodySize bounds request bodies in bytes, 0 means 1MiB; larger ones
+	// are refused with 413
+	MaxBodySize int64
+	sessions    *sessions
+	shares      *shares
+	metrics     *metrics
+	pool        *pool
+	clients     *clients
+	// once makes the state above when the first handler is made, so that
+	// every handler of the server shares it
+	once sync.Once
+	mu   sync.Mutex
+	http *http.Server
+}
+
+type evalRequest struct {
+	Program string      `json:"program"`
+	Options EvalOptions `json:"options"`
+}
+
+// Handler serves the server's endpoints. Handlers made by calling it again
+// share the sessions, shared programs, metrics and limits of the first.
+func (s *Server) Handler() http.Handler {
+	s.once.Do(s.setup)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eval", s.eval)
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/sessions", s.handleSessions)
+	mux.HandleFunc("/sessions/", s.handleSessions)
+	mux.HandleFunc("/share", s.handleShares)
+	mux.HandleFunc("/share/", s.handleShares)
+	if s.Playground {
+		page, _ := fs.Sub(playground, "playground")
+		mux.Handle("/", http.FileServer(http.FS(page)))
+		if s.WasmDir != "" {
+			mux.Handle("/wasm/", http.StripPrefix("/wasm/", http.FileServer(http.Dir(s.WasmDir))))
+		}
+	}
+	return s.limit(mux)
+}
+
+// setup makes the state the server's handlers share from its options
+func (s *Server) setup() {
+	s.sessions = &sessions{byName: map[string]*session{}, ttl: s.SessionTTL, capacity: s.MaxSessions, prelude: s.Prelude}
+	maxShareSize := s.MaxShareSize
+	if maxShareSize <= 0 {
+		maxShareSize = defaultShareSize
+	}
+	s.shares = &shares{byID: map[string]share{}, ttl: s.ShareTTL, maxSize: maxShareSize, capacity: s.MaxShares}
+	s.metrics = newMetrics()
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	queue := s.MaxQueue
+	if queue <= 0 {
+		queue = workers
+	}
+	s.pool = newPool(workers, queue)
+	s.clients = &clients{inFlight: map[string]int{}, capacity: s.MaxPerClient}
+}
+
+// ListenAndServe serves on addr until Shutdown is called, when it returns
+// http.ErrServerClosed. Clients slow to send their requests are cut off.
+func (s *Server) ListenAndServe(addr string) error {
+	s.mu.Lock()
+	s.http = &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	server := s.http
+	s.mu.Unlock()
+	return server.ListenAndServe()
+}
+
+// Shutdown stops accepting requests and waits for those being handled to
+// finish, or for ctx to be done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	server := s.http
+	s.mu.Unlock()
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
+}
+
+// tighter picks the client's limit when it is tighter than the server's
+func tighter(requested, allowed int) int {
+	if requested > 0 && (allowed == 0 || requested < allowed) {
+		return requested
+	}
+	return allowed
+}
+
+// decodeEval reads an evaluation request, holding it to the server's limits
+func (s *Server) decodeEval(w http.ResponseWriter, r *http.Request) (evalRequest, bool) {
+	var req evalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		bodyError(w, err)
+		return req, false
+	}
+	req.Options.MaxSteps = tighter(req.Options.MaxSteps, s.MaxSteps)
+	req.Options.TimeoutMs = tighter(req.Options.TimeoutMs, int(s.Timeout/time.Millisecond))
+	return req, true
+}
+
+func (s *Server) eval(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	if req, ok := s.decodeEval(w, r); ok {
+		s.evaluate(w, r, func() {
+			res, _ := evalProgram(req.Program, req.Options, s.Prelude.Environment())
+			s.metrics.record("eval", res)
+			writeJSON(w, res)
+		})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,316 @@
+package lambda
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServerEval(t *testing.T) {
+	server := httptest.NewServer((&Server{MaxSteps: 1000}).Handler())
+	defer server.Close()
+	serverCases := []struct {
+		body       string
+		normalForm string
+		diagnostic string
+	}{
+		{`{"program": "(𝞴x.x) y"}`, "y", ""},
+		{`{"program": "(𝞴x.x"}`, "", "expect rightParen, but got eof"},
+		{`{"program": "y", "options": {"strict": true}}`, "", "unbound variable y"},
+		{`{"program": "(𝞴x.x x) (𝞴x.x x)", "options": {"maxSteps": 10}}`, "", "step limit reached"},
+	}
+	for _, tt := range serverCases {
+		t.Run(tt.body, func(t *testing.T) {
+			resp, err := http.Post(server.URL+"/eval", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var res EvalResult
+			if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+			if res.NormalForm != tt.normalForm {
+				t.Errorf("expected %v, but got %v", tt.normalForm, res.NormalForm)
+			}
+			if tt.diagnostic == "" && len(res.Diagnostics) != 0 {
+				t.Errorf("expected no diagnostics, but got %v", res.Diagnostics)
+			}
+			if tt.diagnostic != "" && (len(res.Diagnostics) != 1 || res.Diagnostics[0].Message != tt.diagnostic) {
+				t.Errorf("expected %v, but got %v", tt.diagnostic, res.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestServerSessions(t *testing.T) {
+	server := httptest.NewServer((&Server{MaxSessions: 1}).Handler())
+	defer server.Close()
+	request := func(method, path, body string) (int, string) {
+		req, _ := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var b strings.Builder
+		io.Copy(&b, resp.Body)
+		return resp.StatusCode, strings.TrimSpace(b.String())
+	}
+	sessionCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		res    string
+	}{
+		{"POST", "/sessions", `{"name": "alice"}`, http.StatusCreated, ""},
+		{"POST", "/sessions", `{"name": "alice"}`, http.StatusConflict, "session alice already exists"},
+		{"POST", "/sessions", `{"name": "bob"}`, http.StatusServiceUnavailable, "at most 1 sessions are allowed"},
+		{"POST", "/sessions/alice/eval", `{"program": "'id = 𝞴x.x"}`, http.StatusOK, `{"normalForm":"'id = 𝞴x.x","size":3,"steps":0,"diagnostics":[]}`},
+		{"POST", "/sessions/alice/eval", `{"program": "id y"}`, http.StatusOK, `{"normalForm":"y","size":1,"steps":1,"diagnostics":[]}`},
+		{"DELETE", "/sessions/alice", "", http.StatusNoContent, ""},
+		{"POST", "/sessions/alice/eval", `{"program": "id y"}`, http.StatusNotFound, "no session alice"},
+		{"POST", "/sessions", `{"name": "a b"}`, http.StatusBadRequest, "session names are 1 to 64 letters, digits, _ or -"},
+	}
+	for _, tt := range sessionCases {
+		status, res := request(tt.method, tt.path, tt.body)
+		if status != tt.status || res != tt.res {
+			t.Errorf("%v %v: expected %v %v, but got %v %v", tt.method, tt.path, tt.status, tt.res, status, res)
+		}
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	s := sessions{byName: map[string]*session{}, ttl: time.Minute}
+	s.create("alice")
+	s.byName["alice"].lastUsed = time.Now().Add(-2 * time.Minute)
+	if _, ok := s.get("alice"); ok {
+		t.Errorf("expected alice to have expired")
+	}
+}
+
+func TestServerMetrics(t *testing.T) {
+	server := httptest.NewServer((&Server{MaxSteps: 10}).Handler())
+	defer server.Close()
+	for _, body := range []string{`{"program": "(𝞴x.x) y"}`, `{"program": "(𝞴x.x x) (𝞴x.x x)"}`} {
+		resp, err := http.Post(server.URL+"/eval", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	http.Post(server.URL+"/sessions", "application/json", strings.NewReader(`{"name": "alice"}`))
+	http.Post(server.URL+"/sessions/alice/eval", "application/json", strings.NewReader(`{"program": "'id = 𝞴x.x"}`))
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var b strings.Builder
+	io.Copy(&b, resp.Body)
+	for _, line := range []string{
+		`lambda_requests_total{endpoint="eval"} 2`,
+		`lambda_requests_total{endpoint="session"} 1`,
+		`lambda_step_limits_total 1`,
+		`lambda_eval_steps_bucket{le="10"} 2`,
+		`lambda_normal_form_size_count 2`,
+		`lambda_sessions 1`,
+		`lambda_session_memory_bytes{session="alice"} 96`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("expected %v in\n%v", line, b.String())
+		}
+	}
+}
+
+func TestServerShares(t *testing.T) {
+	server := httptest.NewServer((&Server{MaxShareSize: 16}).Handler())
+	defer server.Close()
+	resp, err := http.Post(server.URL+"/share", "application/json", strings.NewReader(`{"program": "(𝞴x.x) y"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created struct {
+		ID   string `json:"id"`
+		Link string `json:"link"`
+	}
+	json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || created.Link != "/?share="+created.ID {
+		t.Fatalf("expected a link, but got %v %+v", resp.StatusCode, created)
+	}
+	resp, err = http.Get(server.URL + "/share/" + created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shared struct {
+		Program string `json:"program"`
+	}
+	json.NewDecoder(resp.Body).Decode(&shared)
+	resp.Body.Close()
+	if shared.Program != "(𝞴x.x) y" {
+		t.Errorf("expected (𝞴x.x) y, but got %v", shared.Program)
+	}
+	resp, _ = http.Post(server.URL+"/share", "application/json", strings.NewReader(`{"program": "let two = 𝞴f x.f (f x) in two"}`))
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected %v, but got %v", http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+	resp, _ = http.Get(server.URL + "/share/missing")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected %v, but got %v", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestShareExpiry(t *testing.T) {
+	s := shares{byID: map[string]share{}, ttl: time.Minute, maxSize: defaultShareSize}
+	id, _, _ := s.add("x")
+	s.byID[id] = share{"x", time.Now().Add(-2 * time.Minute)}
+	if _, ok := s.get(id); ok {
+		t.Errorf("expected %v to have expired", id)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	server := httptest.NewServer((&Server{MaxSteps: 1000, MaxBodySize: 32}).Handler())
+	defer server.Close()
+	for _, c := range []struct {
+		body   string
+		status int
+	}{
+		{`{"program": "(𝞴x.x) y"}`, http.StatusOK},
+		{`{"program": "(𝞴x.x x) (𝞴x.x x) (𝞴x.x x)"}`, http.StatusRequestEntityTooLarge},
+		{`{"program": `, http.StatusBadRequest},
+	} {
+		resp, err := http.Post(server.URL+"/eval", "application/json", strings.NewReader(c.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.status {
+			t.Errorf("expected %v for %v, but got %v", c.status, c.body, resp.StatusCode)
+		}
+	}
+
+	c := clients{inFlight: map[string]int{}, capacity: 2}
+	if !c.acquire("a") || !c.acquire("a") || c.acquire("a") || !c.acquire("b") {
+		t.Errorf("expected two requests from each address")
+	}
+	c.release("a")
+	if !c.acquire("a") {
+		t.Errorf("expected a released request to make room")
+	}
+
+	p := newPool(1, 1)
+	if err := p.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := p.acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("expected to give up waiting, but got %v", err)
+	}
+	waiting := make(chan error)
+	go func() { waiting <- p.acquire(context.Background()) }()
+	for len(p.admitted) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.acquire(context.Background()); err != errBusy {
+		t.Errorf("expected a full queue, but got %v", err)
+	}
+	p.release()
+	if err := <-waiting; err != nil {
+		t.Errorf("expected the waiting evaluation to get the worker, but got %v", err)
+	}
+}
+
+func TestServerHandlerShared(t *testing.T) {
+	for _, ttl := range []time.Duration{0, time.Minute} {
+		s := &Server{SessionTTL: ttl}
+		first := httptest.NewServer(s.Handler())
+		resp, err := http.Post(first.URL+"/sessions", "application/json", strings.NewReader(`{"name": "alice"}`))
+		first.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		second := httptest.NewServer(s.Handler())
+		resp, err = http.Get(second.URL + "/sessions")
+		second.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var infos []map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&infos)
+		resp.Body.Close()
+		if len(infos) != 1 || infos[0]["name"] != "alice" {
+			t.Fatalf("ttl %v: expected alice to outlive the first handler, but got %v", ttl, infos)
+		}
+		if _, ok := infos[0]["expires"]; ok != (ttl > 0) {
+			t.Errorf("ttl %v: expected an expiry only with a ttl, but got %v", ttl, infos[0])
+		}
+	}
+}
+
+func TestMetricsDuringSessionEval(t *testing.T) {
+	s := &Server{}
+	s.Handler()
+	sess, _, _ := s.sessions.create("alice")
+	// an evaluation in alice holds its lock while /metrics is written
+	sess.mu.Lock()
+	written := make(chan bool)
+	go func() {
+		s.metrics.write(io.Discard, s.sessions)
+		written <- true
+	}()
+	time.Sleep(10 * time.Millisecond)
+	recorded := make(chan bool)
+	go func() {
+		s.metrics.record("session", EvalResult{})
+		recorded <- true
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the evaluation to record its metrics while /metrics waits for it")
+	}
+	sess.mu.Unlock()
+	<-written
+}
+
+func TestSessionsList(t *testing.T) {
+	s := sessions{byName: map[string]*session{}, prelude: newPrelude([]envBinding{{name: variable{"id"}, value: parseOrPanic("𝞴x.x")}})}
+	alice, _, _ := s.create("alice")
+	alice.env = alice.env.bind(variable{"k"}, parseOrPanic("𝞴x y.x"))
+	// the prelude isn't counted, being shared by every session
+	if infos := s.list(); len(infos) != 1 || infos[0].Bindings != 1 || infos[0].Nodes != 3 {
+		t.Errorf("expected alice's one binding of 3 nodes, but got %+v", infos)
+	}
+	// alice evaluating holds up listing it, but not the other sessions
+	alice.mu.Lock()
+	listed := make(chan bool)
+	go func() {
+		s.list()
+		listed <- true
+	}()
+	time.Sleep(10 * time.Millisecond)
+	created := make(chan bool)
+	go func() {
+		s.create("bob")
+		created <- true
+	}()
+	select {
+	case <-created:
+	case <-time.After(time.Second):
+		t.Fatalf("expected bob to be created while the sessions are listed")
+	}
+	alice.mu.Unlock()
+	<-listed
+}
@@ -0,0 +1,183 @@
+package lambda
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var sessionName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// session is a named environment kept on the server between requests
+type session struct {
+	mu       sync.Mutex
+	name     string
+	env      Environment
+	lastUsed time.Time
+}
+
+type sessionInfo struct {
+	Name     string `json:"name"`
+	Bindings int    `json:"bindings"`
+	Nodes    int    `json:"nodes"`
+	// Expires is left out when sessions are kept forever
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// sessions holds the server's sessions, dropping those unused for longer than ttl
+type sessions struct {
+	mu       sync.Mutex
+	byName   map[string]*session
+	ttl      time.Duration
+	capacity int
+	// prelude is what every session starts with
+	prelude *Prelude
+}
+
+// expire removes idle sessions, the caller holds s.mu
+func (s *sessions) expire(now time.Time) {
+	if s.ttl <= 0 {
+		return
+	}
+	for name, sess := range s.byName {
+		if now.Sub(sess.lastUsed) > s.ttl {
+			delete(s.byName, name)
+		}
+	}
+}
+
+func (s *sessions) create(name string) (*session, int, error) {
+	if !sessionName.MatchString(name) {
+		return nil, http.StatusBadRequest, fmt.Errorf("session names are 1 to 64 letters, digits, _ or -")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.expire(now)
+	if _, ok := s.byName[name]; ok {
+		return nil, http.StatusConflict, fmt.Errorf("session %v already exists", name)
+	}
+	if s.capacity > 0 && len(s.byName) >= s.capacity {
+		return nil, http.StatusServiceUnavailable, fmt.Errorf("at most %v sessions are allowed", s.capacity)
+	}
+	sess := &session{name: name, env: s.prelude.Environment(), lastUsed: now}
+	s.byName[name] = sess
+	return sess, http.StatusCreated, nil
+}
+
+func (s *sessions) get(name string) (*session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.expire(now)
+	sess, ok := s.byName[name]
+	if ok {
+		sess.lastUsed = now
+	}
+	return sess, ok
+}
+
+func (s *sessions) remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.byName[name]
+	delete(s.byName, name)
+	return ok
+}
+
+func (s *sessions) list() []sessionInfo {
+	// the sessions are locked one by one after s.mu is let go, so that one
+	// evaluating doesn't hold up the others
+	s.mu.Lock()
+	s.expire(time.Now())
+	held := []*session{}
+	infos := []sessionInfo{}
+	for _, sess := range s.byName {
+		held = append(held, sess)
+		info := sessionInfo{Name: sess.name}
+		if s.ttl > 0 {
+			expires := sess.lastUsed.Add(s.ttl)
+			info.Expires = &expires
+		}
+		infos = append(infos, info)
+	}
+	s.mu.Unlock()
+	for i, sess := range held {
+		// the session's own bindings, the prelude's being shared by all
+		sess.mu.Lock()
+		own := Environment{bindings: sess.env.bindings}
+		for _, b := range own.bindings {
+			infos[i].Nodes += size(b.value)
+		}
+		infos[i].Bindings = len(own.names())
+		sess.mu.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// handleSessions serves
+//
+//	GET    /sessions             list sessions
+//	POST   /sessions             create a session, the body is {"name": ...}
+//	DELETE /sessions/{name}      delete a session
+//	POST   /sessions/{name}/eval evaluate in a session, the body is as for /eval
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sessions"), "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		writeJSON(w, s.sessions.list())
+	case path == "" && r.Method == http.MethodPost:
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			bodyError(w, err)
+			return
+		}
+		_, status, err := s.sessions.create(req.Name)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.WriteHeader(status)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if !s.sessions.remove(parts[0]) {
+			http.Error(w, fmt.Sprintf("no session %v", parts[0]), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(parts) == 2 && parts[1] == "eval" && r.Method == http.MethodPost:
+		sess, ok := s.sessions.get(parts[0])
+		if !ok {
+			http.Error(w, fmt.Sprintf("no session %v", parts[0]), http.StatusNotFound)
+			return
+		}
+		req, ok := s.decodeEval(w, r)
+		if !ok {
+			return
+		}
+		var res EvalResult
+		evaluated := false
+		sess.mu.Lock()
+		s.evaluate(w, r, func() {
+			res, sess.env = evalProgram(req.Program, req.Options, sess.env)
+			evaluated = true
+		})
+		sess.mu.Unlock()
+		// recorded without the session's lock, which listing the sessions
+		// for the metrics takes
+		if evaluated {
+			s.metrics.record("session", res)
+			writeJSON(w, res)
+		}
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
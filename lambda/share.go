@@ -0,0 +1,130 @@
+package lambda
+
+import "strconv"
+
+// With :set share on, the REPL prints a value in which a large subterm occurs
+// more than once with the subterm let bound, as let s1 = ... in pair s1 s1,
+// rather than writing it out every time, so a value sharing much of itself
+// stays short and says that it does.
+
+// shareMinSize is the fewest nodes a subterm must have to be shared, as a
+// name for a small one saves little and hides what it is
+const shareMinSize = 10
+
+// shareMaxSize is the most nodes of a value whose subterms are compared, as
+// every subterm is compared with every other alike in size
+const shareMaxSize = 100000
+
+// shareRepeats let binds the largest subterm of exp occurring more than once
+// around the whole of it, as long as there is one with shareMinSize nodes. A
+// subterm is only shared when none of its free variables are bound around
+// any occurrence, so it means the same bound outside. The lets found later,
+// being smaller, go outside those found before, so each name is defined
+// before it is used.
+func shareRepeats(exp Expression) Expression {
+	if size(exp) > shareMaxSize {
+		return exp
+	}
+	avoid := map[string]bool{}
+	allNames(exp, avoid)
+	for n := 1; ; {
+		repeated, ok := largestRepeat(exp)
+		if !ok {
+			return exp
+		}
+		name := "s" + strconv.Itoa(n)
+		for avoid[name] {
+			n++
+			name = "s" + strconv.Itoa(n)
+		}
+		avoid[name] = true
+		exp = binding{variable{name}, repeated, replaceRepeat(exp, repeated, name, map[string]int{})}
+	}
+}
+
+// sharable reports whether exp, with the names bound around it counted in
+// bound, is large enough to share and means the same outside them
+func sharable(exp Expression, bound map[string]int) bool {
+	switch exp.(type) {
+	case variable, freeVariable:
+		return false
+	}
+	return size(exp) >= shareMinSize && !capturedBy(exp, bound)
+}
+
+// largestRepeat finds the largest sharable subterm of exp occurring at least
+// twice up to renaming of bound variables, the first of those as large
+func largestRepeat(exp Expression) (Expression, bool) {
+	type seen struct {
+		exp   Expression
+		count int
+	}
+	found := map[uint64][]*seen{}
+	var best *seen
+	var walk func(exp Expression, bound map[string]int)
+	walk = func(exp Expression, bound map[string]int) {
+		if sharable(exp, bound) {
+			key := Hash(exp)
+			var s *seen
+			for _, candidate := range found[key] {
+				if len(Diff(candidate.exp, exp, true)) == 0 {
+					s = candidate
+				}
+			}
+			if s == nil {
+				s = &seen{exp: exp}
+				found[key] = append(found[key], s)
+			}
+			s.count++
+			if s.count == 2 && (best == nil || size(exp) > size(best.exp)) {
+				best = s
+			}
+		}
+		switch exp := exp.(type) {
+		case binding:
+			walk(exp.value, bound)
+			bound[exp.name.identifier]++
+			walk(exp.body, bound)
+			bound[exp.name.identifier]--
+		case replBinding:
+			walk(exp.value, bound)
+		case abstraction:
+			bound[exp.param.identifier]++
+			walk(exp.expr, bound)
+			bound[exp.param.identifier]--
+		case application:
+			walk(exp.left, bound)
+			walk(exp.right, bound)
+		}
+	}
+	walk(exp, map[string]int{})
+	if best == nil {
+		return nil, false
+	}
+	return best.exp, true
+}
+
+// replaceRepeat replaces the occurrences of repeated in exp that
+// largestRepeat counted with name
+func replaceRepeat(exp, repeated Expression, name string, bound map[string]int) Expression {
+	if size(exp) == size(repeated) && sharable(exp, bound) && len(Diff(exp, repeated, true)) == 0 {
+		return variable{name}
+	}
+	switch exp := exp.(type) {
+	case binding:
+		value := replaceRepeat(exp.value, repeated, name, bound)
+		bound[exp.name.identifier]++
+		defer func() { bound[exp.name.identifier]-- }()
+		return binding{exp.name, value, replaceRepeat(exp.body, repeated, name, bound)}
+	case replBinding:
+		return replBinding{exp.name, replaceRepeat(exp.value, repeated, name, bound)}
+	case abstraction:
+		bound[exp.param.identifier]++
+		defer func() { bound[exp.param.identifier]-- }()
+		return abstraction{exp.param, replaceRepeat(exp.expr, repeated, name, bound)}
+	case application:
+		return application{replaceRepeat(exp.left, repeated, name, bound), replaceRepeat(exp.right, repeated, name, bound)}
+	default:
+		return exp
+	}
+}
@@ -0,0 +1,125 @@
+package lambda
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultShareSize bounds shared programs when the server sets no limit
+const defaultShareSize = 64 << 10
+
+const shareAlphabet = "abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// share is a program stored for a playground link
+type share struct {
+	program string
+	created time.Time
+}
+
+// shares holds shared programs by id, dropping those older than ttl
+type shares struct {
+	mu       sync.Mutex
+	byID     map[string]share
+	ttl      time.Duration
+	maxSize  int
+	capacity int
+}
+
+// expire removes shares past their ttl, the caller holds s.mu
+func (s *shares) expire(now time.Time) {
+	if s.ttl <= 0 {
+		return
+	}
+	for id, sh := range s.byID {
+		if now.Sub(sh.created) > s.ttl {
+			delete(s.byID, id)
+		}
+	}
+}
+
+// newShareID picks 8 characters that can't be confused with one another
+func newShareID() (string, error) {
+	id := make([]byte, 8)
+	for i := range id {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(shareAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		id[i] = shareAlphabet[n.Int64()]
+	}
+	return string(id), nil
+}
+
+func (s *shares) add(program string) (string, int, error) {
+	if len(program) > s.maxSize {
+		return "", http.StatusRequestEntityTooLarge, fmt.Errorf("shared programs are at most %v bytes", s.maxSize)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.expire(now)
+	if s.capacity > 0 && len(s.byID) >= s.capacity {
+		return "", http.StatusServiceUnavailable, fmt.Errorf("at most %v shared programs are allowed", s.capacity)
+	}
+	for {
+		id, err := newShareID()
+		if err != nil {
+			return "", http.StatusInternalServerError, err
+		}
+		if _, taken := s.byID[id]; !taken {
+			s.byID[id] = share{program, now}
+			return id, http.StatusCreated, nil
+		}
+	}
+}
+
+func (s *shares) get(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire(time.Now())
+	sh, ok := s.byID[id]
+	return sh.program, ok
+}
+
+// handleShares serves
+//
+//	POST /share      store a program, the body is {"program": ...}, answering
+//	                 with its id and a link reopening the playground with it
+//	GET  /share/{id} the stored program, as {"program": ...}
+func (s *Server) handleShares(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/share"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		var req struct {
+			Program string `json:"program"`
+		}
+		body := http.MaxBytesReader(w, r.Body, int64(s.shares.maxSize)+1024)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			bodyError(w, err)
+			return
+		}
+		id, status, err := s.shares.add(req.Program)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"id": id, "link": "/?share=" + id})
+	case id != "" && !strings.Contains(id, "/") && r.Method == http.MethodGet:
+		program, ok := s.shares.get(id)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no shared program %v", id), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, map[string]string{"program": program})
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
@@ -0,0 +1,86 @@
+package lambda
+
+// occurrences counts the free occurrences of name in exp
+func occurrences(name string, exp Expression) int {
+	switch exp := exp.(type) {
+	case binding:
+		n := occurrences(name, exp.value)
+		if exp.name.identifier != name {
+			n += occurrences(name, exp.body)
+		}
+		return n
+	case replBinding:
+		return occurrences(name, exp.value)
+	case abstraction:
+		if exp.param.identifier == name {
+			return 0
+		}
+		return occurrences(name, exp.expr)
+	case application:
+		return occurrences(name, exp.left) + occurrences(name, exp.right)
+	case variable:
+		if exp.identifier == name {
+			return 1
+		}
+		return 0
+	default:
+		return 0
+	}
+}
+
+// Simplify applies local rewrites that never copy a term, until none apply:
+// beta on redexes, lets included, whose parameter is used once or whose
+// argument is a variable, eta on 𝞴x.f x where f doesn't mention x, and dropping
+// let bindings the body never refers to, such as those shadowed before any use.
+// Work can still be duplicated: an argument whose one use is under an
+// abstraction is moved inside it, so when that abstraction is applied many
+// times the argument is reduced on every application rather than once.
+func Simplify(e Expression) Expression {
+	for {
+		next, changed := simplify(e)
+		if !changed {
+			return e
+		}
+		e = next
+	}
+}
+
+// simplify rewrites bottom up once, every rewrite shrinks the term so repeating terminates
+func simplify(exp Expression) (Expression, bool) {
+	switch exp := exp.(type) {
+	case binding:
+		body, bodyChanged := simplify(exp.body)
+		uses := occurrences(exp.name.identifier, body)
+		if uses == 0 {
+			return body, true
+		}
+		value, valueChanged := simplify(exp.value)
+		// a let is a redex too
+		if _, trivial := value.(variable); trivial || uses == 1 {
+			return substitute(body, exp.name.identifier, value), true
+		}
+		return binding{exp.name, value, body}, bodyChanged || valueChanged
+	case replBinding:
+		value, changed := simplify(exp.value)
+		return replBinding{exp.name, value}, changed
+	case abstraction:
+		body, changed := simplify(exp.expr)
+		if app, ok := body.(application); ok && app.right == Expression(exp.param) &&
+			occurrences(exp.param.identifier, app.left) == 0 {
+			return app.left, true
+		}
+		return abstraction{exp.param, body}, changed
+	case application:
+		left, leftChanged := simplify(exp.left)
+		right, rightChanged := simplify(exp.right)
+		if abs, ok := left.(abstraction); ok {
+			_, trivial := right.(variable)
+			if trivial || occurrences(abs.param.identifier, abs.expr) == 1 {
+				return substitute(abs.expr, abs.param.identifier, right), true
+			}
+		}
+		return application{left, right}, leftChanged || rightChanged
+	default:
+		return exp, false
+	}
+}
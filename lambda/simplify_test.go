@@ -0,0 +1,29 @@
+package lambda
+
+import "testing"
+
+func TestSimplify(t *testing.T) {
+	simplifyCases := []struct {
+		program    string
+		simplified string
+	}{
+		{"(𝞴x.f x) y", "f y"},
+		{"(𝞴x.x x) y", "y y"},
+		{"(𝞴x.x x) (f y)", "(𝞴x.x x) (f y)"},
+		{"𝞴x.f x", "f"},
+		{"𝞴x.x x", "𝞴x.x x"},
+		{"let x = a in let x = b in x", "b"},
+		{"let x = f a in x x", "let x = f a in x x"},
+		{"let x = a in x x", "a a"},
+		{"𝞴y.(𝞴x.𝞴y.x) y", "𝞴y y'.y"},
+		{"(𝞴x.(𝞴y.g y) x) z", "g z"},
+	}
+	for _, tt := range simplifyCases {
+		t.Run(tt.program, func(t *testing.T) {
+			ast, _ := parse(tt.program)
+			if res := format(Simplify(ast)); res != tt.simplified {
+				t.Errorf("expected %v, but got %v", tt.simplified, res)
+			}
+		})
+	}
+}
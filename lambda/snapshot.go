@@ -0,0 +1,34 @@
+package lambda
+
+// Snapshot is an Environment as it was when taken. Environments are never
+// changed in place, only replaced by new ones, so a snapshot shares their
+// definitions instead of copying them, and costs the same however many
+// there are.
+type Snapshot struct {
+	shared   *Prelude
+	bindings []envBinding
+}
+
+// Snapshot checkpoints e, to roll back to with Restore
+func (e Environment) Snapshot() Snapshot {
+	return Snapshot{e.shared, e.bindings[:len(e.bindings):len(e.bindings)]}
+}
+
+// Restore rolls e back to snapshot, forgetting the definitions made since
+// it was taken, and bringing back any forgotten
+func (e *Environment) Restore(snapshot Snapshot) {
+	e.shared, e.bindings = snapshot.shared, snapshot.bindings
+}
+
+// Run evaluates program in e as EvalProgram does, and, if it is a '
+// definition, binds it in e
+func (e *Environment) Run(program string, options EvalOptions) EvalResult {
+	var res EvalResult
+	res, *e = evalProgram(program, options, *e)
+	return res
+}
+
+// Names lists the names e binds, most recently bound first
+func (e Environment) Names() []string {
+	return e.names()
+}
@@ -0,0 +1,31 @@
+package lambda
+
+import "testing"
+
+func TestSnapshotRestore(t *testing.T) {
+	var env Environment
+	env.Run("'id = 𝞴x.x", EvalOptions{})
+	snapshot := env.Snapshot()
+	env.Run("'k = 𝞴x y.x", EvalOptions{})
+	env.Run("'id = 𝞴y.y", EvalOptions{})
+	if res := env.Run("k (id a) b", EvalOptions{}); res.NormalForm != "a" {
+		t.Errorf("expected a, but got %v", res.NormalForm)
+	}
+	later := env.Snapshot()
+	env.Restore(snapshot)
+	if names := env.Names(); len(names) != 1 || names[0] != "id" {
+		t.Errorf("expected only id, but got %v", names)
+	}
+	if res := env.Run("id a", EvalOptions{}); res.NormalForm != "a" {
+		t.Errorf("expected a, but got %v", res.NormalForm)
+	}
+	// restoring a snapshot doesn't change another
+	env.Run("'two = 𝞴f x.f (f x)", EvalOptions{})
+	env.Restore(later)
+	if names := env.Names(); len(names) != 2 || names[0] != "id" || names[1] != "k" {
+		t.Errorf("expected id and k, but got %v", names)
+	}
+	if value, _ := env.find(variable{"id"}); value.String() != "(𝞴y.y)" {
+		t.Errorf("expected id restored as 𝞴y.y, but got %v", value)
+	}
+}
@@ -0,0 +1,30 @@
+package lambda
+
+// The AST nests an application h a1 ... an to the left, as ((h a1) ...) an,
+// so reaching its head means walking the whole left branch, recursing once
+// per argument. Head reduction, redex search and printing work on the head
+// and its arguments laid out flat instead, nesting them again only to build
+// the terms they return.
+
+// spine splits an application into its head and the arguments it is applied to
+func spine(exp Expression) (Expression, []Expression) {
+	n := 0
+	for app, ok := exp.(application); ok; app, ok = app.left.(application) {
+		n += 1
+	}
+	args := make([]Expression, n)
+	for i := n - 1; i >= 0; i-- {
+		app := exp.(application)
+		args[i] = app.right
+		exp = app.left
+	}
+	return exp, args
+}
+
+// unspine applies head to args, undoing spine
+func unspine(head Expression, args []Expression) Expression {
+	for _, arg := range args {
+		head = application{head, arg}
+	}
+	return head
+}
@@ -0,0 +1,30 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpine(t *testing.T) {
+	exp, _ := parse("f ((𝞴x.x) a) ((𝞴y.y) b)")
+	head, args := spine(exp)
+	if format(head) != "f" || len(args) != 2 || unspine(head, args) != exp {
+		t.Errorf("expected f and 2 arguments, but got %v %v", format(head), len(args))
+	}
+	// the leftmost redex is in the first argument
+	next, c, ok := contractStep(exp, false)
+	if !ok || format(next) != "f a ((𝞴y.y) b)" || c.path != "/fn/arg" {
+		t.Errorf("expected f a ((𝞴y.y) b) at /fn/arg, but got %v at %v", format(next), c.path)
+	}
+
+	// a long spine is taken apart without recursing once per argument
+	long := strings.Repeat(" x", 100000)
+	exp, _ = parse("(𝞴f.f)" + long)
+	h, err := HeadNormalize(exp, 10)
+	if err != nil || h.Head != "x" || len(h.Args) != 99999 || h.Steps != 1 {
+		t.Errorf("expected x applied to 99999 arguments in 1 step, but got %v %v %v", h.Head, len(h.Args), err)
+	}
+	if res := format(h.Expression()); res != long[1:] {
+		t.Errorf("expected %v arguments, but got %v", 99999, len(strings.Fields(res))-1)
+	}
+}
@@ -0,0 +1,207 @@
+package lambda
+
+import (
+	"fmt"
+	"strings"
+)
+
+// standardWork bounds how many head steps Standardize may try in all
+const standardWork = 100000
+
+// contractAt contracts the redex at path in exp, as reducts names it
+func contractAt(exp Expression, path string) (Expression, contraction, bool) {
+	for _, r := range reducts(exp) {
+		if r.c.path == path {
+			return r.term, r.c, true
+		}
+	}
+	return exp, contraction{}, false
+}
+
+// Replay contracts the redexes at paths in turn, starting from e, returning
+// every term along the way. Paths are as Diff gives them, / for the root.
+func Replay(e Expression, paths []string) ([]Expression, error) {
+	terms := []Expression{e}
+	for i, path := range paths {
+		next, _, ok := contractAt(e, strings.TrimSuffix(path, "/"))
+		if !ok {
+			return terms, fmt.Errorf("step %v: no redex at %v in %v", i+1, path, format(e))
+		}
+		e = next
+		terms = append(terms, e)
+	}
+	return terms, nil
+}
+
+// headStep contracts the head redex of exp, the one in function position
+// under its leading binders, which every standard reduction contracts first
+func headStep(exp Expression) (Expression, string, bool) {
+	switch exp := exp.(type) {
+	case binding:
+		return contract(exp.name, exp.body, exp.value, true, false).result, "", true
+	case replBinding:
+		value, path, ok := headStep(exp.value)
+		return replBinding{exp.name, value}, "/value" + path, ok
+	case abstraction:
+		body, path, ok := headStep(exp.expr)
+		return abstraction{exp.param, body}, "/body" + path, ok
+	case application:
+		head, args := spine(exp)
+		if b, ok := head.(binding); ok {
+			res := contract(b.name, b.body, b.value, true, false).result
+			return unspine(res, args), strings.Repeat("/fn", len(args)), true
+		}
+		abs, ok := head.(abstraction)
+		if !ok {
+			return exp, "", false
+		}
+		res := contract(abs.param, abs.expr, args[0], false, false).result
+		return unspine(res, args[1:]), strings.Repeat("/fn", len(args)-1), true
+	default:
+		return exp, "", false
+	}
+}
+
+// component is a pair of corresponding subterms, at path in both terms
+type component struct {
+	from, to Expression
+	path     string
+}
+
+// components splits two terms of the same head shape into the subterms a
+// reduction from one to the other has to reduce, left to right, reporting
+// false when the shapes differ. Binders are renamed alike in both.
+func components(from, to Expression) ([]component, bool) {
+	sameBinder := func(a, b variable, aBody, bBody Expression) (Expression, Expression) {
+		avoid := map[string]bool{}
+		allNames(aBody, avoid)
+		allNames(bBody, avoid)
+		z := fresh(a.identifier, avoid)
+		return rename(aBody, a.identifier, z), rename(bBody, b.identifier, z)
+	}
+	switch f := from.(type) {
+	case binding:
+		t, ok := to.(binding)
+		if !ok {
+			return nil, false
+		}
+		fBody, tBody := sameBinder(f.name, t.name, f.body, t.body)
+		return []component{{f.value, t.value, "/value"}, {fBody, tBody, "/body"}}, true
+	case replBinding:
+		t, ok := to.(replBinding)
+		if !ok || f.name != t.name {
+			return nil, false
+		}
+		return []component{{f.value, t.value, "/value"}}, true
+	case abstraction:
+		t, ok := to.(abstraction)
+		if !ok {
+			return nil, false
+		}
+		fBody, tBody := sameBinder(f.param, t.param, f.expr, t.expr)
+		return []component{{fBody, tBody, "/body"}}, true
+	case application:
+		fHead, fArgs := spine(f)
+		tHead, tArgs := spine(to)
+		if len(fArgs) != len(tArgs) {
+			return nil, false
+		}
+		res := []component{}
+		fAbs, fIsAbs := fHead.(abstraction)
+		tAbs, tIsAbs := tHead.(abstraction)
+		if fIsAbs && tIsAbs {
+			res = append(res, component{fAbs, tAbs, strings.Repeat("/fn", len(fArgs))})
+		} else if !sameName(fHead, tHead) {
+			return nil, false
+		}
+		for i := range fArgs {
+			res = append(res, component{fArgs[i], tArgs[i], strings.Repeat("/fn", len(fArgs)-1-i) + "/arg"})
+		}
+		return res, true
+	default:
+		return nil, sameName(from, to)
+	}
+}
+
+func sameName(a, b Expression) bool {
+	aName, aOk := variableName(a)
+	bName, bOk := variableName(b)
+	return aOk && bOk && aName == bName
+}
+
+type standardizer struct {
+	// bound is the most head steps tried at each level
+	bound int
+	work  int
+}
+
+// standard finds a standard reduction from one term to the other: head steps
+// until the head shapes agree, then a standard reduction of each component in
+// turn, as in Plotkin's proof of the standardization theorem
+func (s *standardizer) standard(from, to Expression) ([]string, bool, error) {
+	steps := []string{}
+	for h := 0; ; h++ {
+		if parts, ok := components(from, to); ok {
+			inner, ok, err := s.standardParts(parts)
+			if err != nil || ok {
+				return append(steps, inner...), ok, err
+			}
+			// the head step of these is in their only component, already tried
+			switch from.(type) {
+			case abstraction, replBinding:
+				return nil, false, nil
+			}
+		}
+		if h == s.bound {
+			return nil, false, nil
+		}
+		if s.work += 1; s.work > standardWork {
+			return nil, false, ErrStepLimit
+		}
+		next, path, ok := headStep(from)
+		if !ok {
+			return nil, false, nil
+		}
+		from = next
+		steps = append(steps, path)
+	}
+}
+
+func (s *standardizer) standardParts(parts []component) ([]string, bool, error) {
+	steps := []string{}
+	for _, part := range parts {
+		inner, ok, err := s.standard(part.from, part.to)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+		for _, path := range inner {
+			steps = append(steps, part.path+path)
+		}
+	}
+	return steps, true, nil
+}
+
+// Standardize turns a reduction of e, given as the paths of the redexes it
+// contracts, into the standard reduction to the same term: one contracting
+// redexes from left to right, never going back to a redex left of the last.
+// The result is again a list of paths.
+func Standardize(e Expression, reduction []string) ([]string, error) {
+	terms, err := Replay(e, reduction)
+	if err != nil {
+		return nil, err
+	}
+	// a head step contracts a residual of the head redex, which the given
+	// reduction must have contracted too, so it bounds the head steps
+	s := standardizer{bound: len(reduction)}
+	steps, ok, err := s.standard(e, terms[len(terms)-1])
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("no standard reduction found")
+	}
+	for i, path := range steps {
+		steps[i] = rootPath(path)
+	}
+	return steps, nil
+}
@@ -0,0 +1,46 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStandardize(t *testing.T) {
+	standardCases := []struct {
+		program   string
+		reduction string
+		standard  string
+	}{
+		{"(𝞴x.x x) ((𝞴y.y) z)", "/arg /", "/ /fn /arg"},
+		{"(𝞴x.x) ((𝞴y.y) z)", "/arg /", "/ /"},
+		{"(𝞴x y.y) ((𝞴y.y) z)", "/arg /", "/"},
+		{"𝞴f.f ((𝞴x.x) a) ((𝞴x.x) b)", "/body/arg /body/fn/arg", "/body/fn/arg /body/arg"},
+		{"(𝞴x.x) a", "", ""},
+		// a let at the head of an application is its head redex
+		{"(let i = 𝞴x.x in i) ((𝞴y.y) a)", "/arg /fn /", "/fn / /"},
+	}
+	for _, tt := range standardCases {
+		t.Run(tt.program+" "+tt.reduction, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			res, err := Standardize(exp, strings.Fields(tt.reduction))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if standard := strings.Join(res, " "); standard != tt.standard {
+				t.Errorf("expected %v, but got %v", tt.standard, standard)
+			}
+			given, _ := Replay(exp, strings.Fields(tt.reduction))
+			terms, err := Replay(exp, res)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(Diff(terms[len(terms)-1], given[len(given)-1], true)) != 0 {
+				t.Errorf("expected %v, but got %v", format(given[len(given)-1]), format(terms[len(terms)-1]))
+			}
+		})
+	}
+	exp, _ := parse("(𝞴x.x) a")
+	if _, err := Standardize(exp, []string{"/arg"}); err == nil || err.Error() != "step 1: no redex at /arg in (𝞴x.x) a" {
+		t.Errorf("expected no redex at /arg, but got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package lambda
+
+import "sort"
+
+// DefinitionStats measures a definition of a module, as written and as it
+// normalizes, so an encoding far larger or costlier than it should be stands
+// out among the rest
+type DefinitionStats struct {
+	Name string `json:"name"`
+	// Size counts the nodes of the value, and Depth those on the longest
+	// path from its root down
+	Size  int `json:"size"`
+	Depth int `json:"depth"`
+	// Binders counts the abstractions and lets of the value, and Redexes the
+	// beta redexes and lets
+	Binders int `json:"binders"`
+	Redexes int `json:"redexes"`
+	// Free is the variables free in the value, the definitions it uses among
+	// them
+	Free []string `json:"free"`
+	// Steps estimates the cost of normalizing the value, with the
+	// definitions it uses filled in, as the applications of abstractions the
+	// bytecode machine takes, which its sharing makes fewer than normal order
+	// reduction takes. Normalizes is false when that was more than the limit.
+	Steps      int  `json:"steps"`
+	Normalizes bool `json:"normalizes"`
+}
+
+// Stats measures each definition of the module in file, in order, giving up
+// normalizing one after maxSteps applications of abstractions. The module is
+// checked as Compile checks it, and its imports are looked for along path,
+// or the default path when it is nil.
+func Stats(file string, path []string, maxSteps int) ([]DefinitionStats, error) {
+	c, mod, _, err := checkModule(file, path)
+	if err != nil {
+		return nil, err
+	}
+	stats := []DefinitionStats{}
+	for _, d := range mod.definitions {
+		ast, _ := parse(d.source)
+		value := ast.(replBinding).value
+		s := DefinitionStats{Name: d.name.identifier, Size: size(value), Free: []string{}}
+		s.Depth, s.Binders, s.Redexes = measure(value)
+		for name := range freeVariables(value) {
+			s.Free = append(s.Free, name)
+		}
+		sort.Strings(s.Free)
+		if p, err := compileBytecode(c.program(d.value), ArtifactInfo{}); err == nil {
+			_, steps, err := p.execute(maxSteps)
+			s.Steps, s.Normalizes = steps, err == nil
+			if err == ErrStepLimit {
+				s.Steps = maxSteps
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// measure finds the depth of exp, and counts its binders and redexes
+func measure(exp Expression) (depth, binders, redexes int) {
+	switch exp := exp.(type) {
+	case binding:
+		valueDepth, valueBinders, valueRedexes := measure(exp.value)
+		bodyDepth, bodyBinders, bodyRedexes := measure(exp.body)
+		return 1 + maxInt(valueDepth, bodyDepth), 1 + valueBinders + bodyBinders, 1 + valueRedexes + bodyRedexes
+	case replBinding:
+		depth, binders, redexes = measure(exp.value)
+		return depth + 1, binders, redexes
+	case abstraction:
+		depth, binders, redexes = measure(exp.expr)
+		return depth + 1, binders + 1, redexes
+	case application:
+		leftDepth, leftBinders, leftRedexes := measure(exp.left)
+		rightDepth, rightBinders, rightRedexes := measure(exp.right)
+		redexes = leftRedexes + rightRedexes
+		if _, ok := exp.left.(abstraction); ok {
+			redexes++
+		}
+		return 1 + maxInt(leftDepth, rightDepth), leftBinders + rightBinders, redexes
+	default:
+		return 1, 0, 0
+	}
+}
@@ -0,0 +1,37 @@
+package lambda
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"bool": "module bool\n'true = 𝞴x y.x\n'false = 𝞴x y.y\n",
+		"prog": `import bool
+'not = 𝞴b.b bool.false bool.true
+'twice = 𝞴f x.let y = f x in f y
+'omega = (𝞴x.x x) (𝞴x.x x)
+'main = not (not bool.true)
+`,
+	})
+	stats, err := Stats(filepath.Join(dir, "prog.lam"), []string{dir}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"{not 6 4 1 0 [bool.false bool.true] 5 true}",
+		"{twice 9 5 3 1 [] 2 true}",
+		"{omega 9 4 2 1 [] 100 false}",
+		"{main 5 3 0 0 [bool.true not] 8 true}",
+	}
+	if len(stats) != len(expected) {
+		t.Fatalf("expected %v, but got %v", expected, stats)
+	}
+	for i := range expected {
+		if got := fmt.Sprint(stats[i]); got != expected[i] {
+			t.Errorf("expected %v, but got %v", expected[i], got)
+		}
+	}
+}
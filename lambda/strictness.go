@@ -0,0 +1,90 @@
+package lambda
+
+// A function needs its argument when applying it to every argument it takes
+// has no value, no weak head normal form, unless the argument has one. Needed
+// arguments can be evaluated before the function is applied without changing
+// whether evaluation terminates, which saves a lazy evaluator suspending them.
+// Neededness is preserved by reduction, so the analysis head reduces the body
+// and looks at what is left: a body reducing to the parameter applied to
+// arguments needs it, one reducing to an abstraction or another variable
+// applied to arguments doesn't.
+
+// strictnessSteps bounds the head reductions spent analysing each parameter
+// before evaluating, after which it isn't known to be needed
+const strictnessSteps = 100
+
+// StrictParam says whether an abstraction definitely needs the argument
+// passed for its parameter Name
+type StrictParam struct {
+	Name   string
+	Needed bool
+}
+
+// Strictness analyses the parameters of f, an abstraction of one or more
+// parameters, each within maxSteps head reductions. A parameter not found to
+// be needed within them may still be.
+func Strictness(f Expression, maxSteps int) []StrictParam {
+	params := []StrictParam{}
+	body := f
+	for {
+		abs, ok := body.(abstraction)
+		if !ok {
+			break
+		}
+		params = append(params, StrictParam{Name: abs.param.identifier})
+		body = abs.expr
+	}
+	h, err := HeadNormalize(body, maxSteps)
+	if err != nil || len(h.Binders) > 0 {
+		return params
+	}
+	// a later parameter of the same name shadows an earlier one
+	for i := len(params) - 1; i >= 0; i-- {
+		if params[i].Name == h.Head {
+			params[i].Needed = true
+			break
+		}
+	}
+	return params
+}
+
+// strictApplication is an application passing an argument its function
+// needs, which the HOAS evaluator evaluates right away instead of suspending
+type strictApplication struct {
+	application
+}
+
+// markStrict marks the applications in exp of an abstraction to an argument
+// it needs
+func markStrict(exp Expression) Expression {
+	switch exp := exp.(type) {
+	case binding:
+		return binding{exp.name, markStrict(exp.value), markStrict(exp.body)}
+	case replBinding:
+		return replBinding{exp.name, markStrict(exp.value)}
+	case abstraction:
+		return abstraction{exp.param, markStrict(exp.expr)}
+	case application:
+		head, args := spine(exp)
+		// partly applied, the abstraction is a value whatever its arguments
+		var params []StrictParam
+		if _, ok := head.(abstraction); ok {
+			params = Strictness(head, strictnessSteps)
+			if len(params) > len(args) {
+				params = nil
+			}
+		}
+		res := markStrict(head)
+		for i, arg := range args {
+			app := application{res, markStrict(arg)}
+			if i < len(params) && params[i].Needed {
+				res = strictApplication{app}
+			} else {
+				res = app
+			}
+		}
+		return res
+	default:
+		return exp
+	}
+}
@@ -0,0 +1,43 @@
+package lambda
+
+import "testing"
+
+func TestStrictness(t *testing.T) {
+	strictnessCases := []struct {
+		program string
+		needed  []bool
+	}{
+		{"𝞴x y.x", []bool{true, false}},
+		{"𝞴x y.y x", []bool{false, true}},
+		// the body reduces to x applied to y
+		{"𝞴x y.(𝞴f.f y) x", []bool{true, false}},
+		// the result is an abstraction, a value whatever x is
+		{"𝞴x.(𝞴y.y) (𝞴y.x)", []bool{false}},
+		{"𝞴x x.x", []bool{false, true}},
+		{"𝞴x.(𝞴y.y y) (𝞴y.y y)", []bool{false}},
+	}
+	for _, tt := range strictnessCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, _ := parse(tt.program)
+			params := Strictness(exp, 100)
+			if len(params) != len(tt.needed) {
+				t.Fatalf("expected %v parameters, but got %v", len(tt.needed), params)
+			}
+			for i := range params {
+				if params[i].Needed != tt.needed[i] {
+					t.Errorf("expected %v to be needed: %v", params[i].Name, tt.needed[i])
+				}
+			}
+		})
+	}
+
+	// y x needs y but not x, so the diverging argument is never evaluated
+	exp, _ := parse("(𝞴x y.y x) ((𝞴x.x x) (𝞴x.x x)) (𝞴a b.b)")
+	if normal, err := NormalizeHOAS(exp, 1000); err != nil || format(normal) != "𝞴a.a" {
+		t.Errorf("expected 𝞴a.a, but got %v %v", format(normal), err)
+	}
+	exp, _ = parse("(𝞴x y.x) a b")
+	if _, ok := markStrict(exp).(application).left.(strictApplication); !ok {
+		t.Errorf("expected the argument for x to be evaluated right away")
+	}
+}
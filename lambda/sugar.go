@@ -0,0 +1,50 @@
+package lambda
+
+// The parser reads sugar into the core calculus only partly: let stays a
+// binding of its own, and macros are expanded afterwards. With :set sugar on
+// the REPL shows what a program is in the core, every let an applied 𝞴 and
+// every 𝞴 binding one variable, beside it sugared again as it reads best.
+
+// desugar turns the lets of exp into the abstractions they stand for
+func desugar(exp Expression) Expression {
+	switch exp := exp.(type) {
+	case binding:
+		return application{abstraction{exp.name, desugar(exp.body)}, desugar(exp.value)}
+	case replBinding:
+		return replBinding{exp.name, desugar(exp.value)}
+	case abstraction:
+		return abstraction{exp.param, desugar(exp.expr)}
+	case application:
+		return application{desugar(exp.left), desugar(exp.right)}
+	case quoted:
+		return quoted{desugar(exp.expr)}
+	case unquoted:
+		return unquoted{desugar(exp.expr)}
+	default:
+		return exp
+	}
+}
+
+// resugar turns abstractions applied right away into the lets they read
+// better as
+func resugar(exp Expression) Expression {
+	switch exp := exp.(type) {
+	case binding:
+		return binding{exp.name, resugar(exp.value), resugar(exp.body)}
+	case replBinding:
+		return replBinding{exp.name, resugar(exp.value)}
+	case abstraction:
+		return abstraction{exp.param, resugar(exp.expr)}
+	case application:
+		if abs, ok := exp.left.(abstraction); ok {
+			return binding{abs.param, resugar(exp.right), resugar(abs.expr)}
+		}
+		return application{resugar(exp.left), resugar(exp.right)}
+	case quoted:
+		return quoted{resugar(exp.expr)}
+	case unquoted:
+		return unquoted{resugar(exp.expr)}
+	default:
+		return exp
+	}
+}
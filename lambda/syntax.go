@@ -0,0 +1,480 @@
+package lambda
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// notation reads and writes terms in the syntax of another tool
+type notation struct {
+	parse  func(p *foreignParser) Expression
+	format func(exp Expression) string
+	// valid tells the names the notation takes for variables, nil for all
+	// of them
+	valid func(name string) bool
+}
+
+// syntaxes are the notations ParseSyntax and FormatSyntax know, by name
+var syntaxes = map[string]notation{
+	"lambda": {nil, format, nil},
+	// \x y -> x y, with let x = v in b
+	"haskell": {(*foreignParser).haskell, formatHaskell, validHaskell},
+	// lambda x, y: x(y), where let becomes an applied lambda
+	"python": {(*foreignParser).python, formatPython, validPython},
+}
+
+// the keywords of Haskell and Python, which can't name variables
+var (
+	haskellKeywords = keywordSet("case class data default deriving do else foreign if import in infix infixl infixr instance let module newtype of then type where")
+	pythonKeywords  = keywordSet("False None True and as assert async await break class continue def del elif else except finally for from global if import in is lambda nonlocal not or pass raise return try while with yield")
+)
+
+func keywordSet(words string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// isNameRune tells the runes both notations allow after the first of a name
+func isNameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_'
+}
+
+// validHaskell tells a Haskell variable: a lowercase letter or _ and then
+// letters, digits, _ and primes, but not a keyword or _ alone
+func validHaskell(name string) bool {
+	if name == "" || name == "_" || haskellKeywords[name] || !(name[0] >= 'a' && name[0] <= 'z' || name[0] == '_') {
+		return false
+	}
+	for _, r := range name {
+		if !isNameRune(r) && r != '\'' {
+			return false
+		}
+	}
+	return true
+}
+
+// validPython tells a Python name: a letter or _ and then letters, digits and
+// _, but not a keyword
+func validPython(name string) bool {
+	if name == "" || pythonKeywords[name] || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for _, r := range name {
+		if !isNameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// foreignNames renames the variables of exp whose names valid doesn't take,
+// every occurrence of a name alike, bound or free. A name becomes its letters,
+// digits and _ with _1, _2, ... after them, as y' becomes y_1, the first that
+// is valid and no other name of exp.
+func foreignNames(exp Expression, valid func(name string) bool) Expression {
+	used := map[string]bool{}
+	allNames(exp, used)
+	renamed := map[string]string{}
+	for name := range used {
+		if valid(name) {
+			continue
+		}
+		base := strings.Map(func(r rune) rune {
+			if isNameRune(r) {
+				return r
+			}
+			return -1
+		}, name)
+		if base == "" || base[0] >= '0' && base[0] <= '9' {
+			base = "v" + base
+		}
+		if base[0] >= 'A' && base[0] <= 'Z' {
+			base = strings.ToLower(base[:1]) + base[1:]
+		}
+		renamed[name] = ""
+		for n := 1; renamed[name] == ""; n++ {
+			if candidate := fmt.Sprintf("%v_%v", base, n); valid(candidate) && !used[candidate] {
+				renamed[name], used[candidate] = candidate, true
+			}
+		}
+	}
+	if len(renamed) == 0 {
+		return exp
+	}
+	var walk func(exp Expression) Expression
+	name := func(v variable) variable {
+		if to, ok := renamed[v.identifier]; ok {
+			return variable{to}
+		}
+		return v
+	}
+	walk = func(exp Expression) Expression {
+		switch exp := exp.(type) {
+		case binding:
+			return binding{name(exp.name), walk(exp.value), walk(exp.body)}
+		case replBinding:
+			return replBinding{name(exp.name), walk(exp.value)}
+		case abstraction:
+			return abstraction{name(exp.param), walk(exp.expr)}
+		case application:
+			return application{walk(exp.left), walk(exp.right)}
+		case variable:
+			return name(exp)
+		case freeVariable:
+			return freeVariable(name(variable(exp)))
+		default:
+			return exp
+		}
+	}
+	return walk(exp)
+}
+
+func lookupSyntax(name string) (notation, error) {
+	n, ok := syntaxes[name]
+	if !ok {
+		names := []string{}
+		for name := range syntaxes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return n, fmt.Errorf("unknown syntax %v, expected one of %v", name, strings.Join(names, ", "))
+	}
+	return n, nil
+}
+
+// ParseSyntax reads a program written in the named syntax: lambda, haskell or python
+func ParseSyntax(program, syntax string) (Expression, error) {
+	n, err := lookupSyntax(syntax)
+	if err != nil {
+		return nil, err
+	}
+	if n.parse == nil {
+		return parse(program)
+	}
+	tokens, err := scanForeign([]rune(program))
+	if err != nil {
+		return nil, err
+	}
+	p := foreignParser{tokens: tokens, end: len([]rune(program))}
+	return p.run(n.parse)
+}
+
+// FormatSyntax prints an expression in the named syntax: lambda, haskell or
+// python, renaming the variables whose names the syntax doesn't take
+func FormatSyntax(exp Expression, syntax string) (string, error) {
+	n, err := lookupSyntax(syntax)
+	if err != nil {
+		return "", err
+	}
+	if n.valid != nil {
+		exp = foreignNames(exp, n.valid)
+	}
+	return n.format(exp), nil
+}
+
+type foreignToken struct {
+	lexeme string
+	start  int
+}
+
+// scanForeign splits a program into names and the punctuation the other
+// notations use; keywords are names the parsers look out for
+func scanForeign(program []rune) ([]foreignToken, error) {
+	tokens := []foreignToken{}
+	isNameStart := func(c rune) bool {
+		return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	isName := func(c rune) bool {
+		return isNameRune(c) || c == '\''
+	}
+	for i := 0; i < len(program); {
+		c := program[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i += 1
+		case c == '-' && i+1 < len(program) && program[i+1] == '>':
+			tokens = append(tokens, foreignToken{"->", i})
+			i += 2
+		case strings.ContainsRune("\\λ𝞴()=,:", c):
+			tokens = append(tokens, foreignToken{string(c), i})
+			i += 1
+		// a name may start with _, as the names the others are renamed to
+		// for Haskell do, but _ alone is no name
+		case isNameStart(c) || c == '_' && i+1 < len(program) && isName(program[i+1]):
+			start := i
+			for i < len(program) && isName(program[i]) {
+				i += 1
+			}
+			tokens = append(tokens, foreignToken{string(program[start:i]), start})
+		default:
+			return nil, syntaxError{i, fmt.Sprintf("%v cannot be used in identifier", string(c))}
+		}
+	}
+	return tokens, nil
+}
+
+// foreignParser is a recursive descent parser over foreign tokens, which like
+// Parser panics on errors and recovers them as a syntaxError
+type foreignParser struct {
+	tokens []foreignToken
+	cur    int
+	end    int
+}
+
+func (p *foreignParser) run(parse func(p *foreignParser) Expression) (exp Expression, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = syntaxError{p.offset(), fmt.Sprintf("%v", r)}
+		}
+	}()
+	exp = parse(p)
+	if !p.isEnd() {
+		panic(fmt.Sprintf("unexpected %v", p.current()))
+	}
+	return exp, nil
+}
+
+func (p *foreignParser) isEnd() bool {
+	return p.cur >= len(p.tokens)
+}
+
+func (p *foreignParser) offset() int {
+	if p.isEnd() {
+		return p.end
+	}
+	return p.tokens[p.cur].start
+}
+
+func (p *foreignParser) current() string {
+	if p.isEnd() {
+		panic("unexpected eof")
+	}
+	return p.tokens[p.cur].lexeme
+}
+
+// peek looks ahead n tokens, returning "" past the end
+func (p *foreignParser) peek(n int) string {
+	if p.cur+n >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.cur+n].lexeme
+}
+
+func (p *foreignParser) consume(lexeme string) {
+	if p.isEnd() {
+		panic(fmt.Sprintf("expect %v, but got eof", lexeme))
+	}
+	if p.current() != lexeme {
+		panic(fmt.Sprintf("expect %v, but got %v", lexeme, p.current()))
+	}
+	p.cur += 1
+}
+
+func isForeignName(lexeme string) bool {
+	return lexeme != "" && (lexeme[0] >= 'a' && lexeme[0] <= 'z' || lexeme[0] >= 'A' && lexeme[0] <= 'Z' || lexeme[0] == '_')
+}
+
+func (p *foreignParser) name(keywords ...string) variable {
+	name := p.current()
+	if !isForeignName(name) {
+		panic(fmt.Sprintf("expect a name, but got %v", name))
+	}
+	for _, k := range keywords {
+		if name == k {
+			panic(fmt.Sprintf("expect a name, but got %v", name))
+		}
+	}
+	p.cur += 1
+	return variable{name}
+}
+
+// definition parses a top level name = value, which is a replBinding
+func (p *foreignParser) definition(value func() Expression, keywords ...string) (Expression, bool) {
+	if !isForeignName(p.peek(0)) || p.peek(1) != "=" {
+		return nil, false
+	}
+	name := p.name(keywords...)
+	p.consume("=")
+	return replBinding{name, value()}, true
+}
+
+func abstractOver(params []variable, body Expression) Expression {
+	for i := len(params) - 1; i >= 0; i-- {
+		body = abstraction{params[i], body}
+	}
+	return body
+}
+
+func (p *foreignParser) haskell() Expression {
+	if exp, ok := p.definition(p.haskellExpression, "let", "in"); ok {
+		return exp
+	}
+	return p.haskellExpression()
+}
+
+func (p *foreignParser) haskellExpression() Expression {
+	switch p.current() {
+	case "\\", "λ", "𝞴":
+		p.cur += 1
+		params := []variable{p.name("let", "in")}
+		for p.peek(0) != "->" {
+			params = append(params, p.name("let", "in"))
+		}
+		p.consume("->")
+		return abstractOver(params, p.haskellExpression())
+	case "let":
+		p.consume("let")
+		name := p.name("let", "in")
+		p.consume("=")
+		value := p.haskellExpression()
+		p.consume("in")
+		return binding{name, value, p.haskellExpression()}
+	}
+	exp := p.haskellAtom()
+	for !p.isEnd() {
+		switch next := p.current(); {
+		case next == "\\" || next == "λ" || next == "𝞴" || next == "let":
+			// a trailing lambda or let takes the rest of the expression
+			return application{exp, p.haskellExpression()}
+		case next == "(" || isForeignName(next) && next != "in":
+			exp = application{exp, p.haskellAtom()}
+		default:
+			return exp
+		}
+	}
+	return exp
+}
+
+func (p *foreignParser) haskellAtom() Expression {
+	if p.current() == "(" {
+		p.consume("(")
+		exp := p.haskellExpression()
+		p.consume(")")
+		return exp
+	}
+	return p.name("let", "in")
+}
+
+func (p *foreignParser) python() Expression {
+	if exp, ok := p.definition(p.pythonExpression, "lambda"); ok {
+		return exp
+	}
+	return p.pythonExpression()
+}
+
+func (p *foreignParser) pythonExpression() Expression {
+	if p.current() == "lambda" {
+		p.consume("lambda")
+		params := []variable{p.name("lambda")}
+		for p.peek(0) == "," {
+			p.consume(",")
+			params = append(params, p.name("lambda"))
+		}
+		p.consume(":")
+		return abstractOver(params, p.pythonExpression())
+	}
+	var exp Expression
+	if p.current() == "(" {
+		p.consume("(")
+		exp = p.pythonExpression()
+		p.consume(")")
+	} else {
+		exp = p.name("lambda")
+	}
+	// f(a, b) applies f to a and then to b, as f(a)(b) does
+	for p.peek(0) == "(" {
+		p.consume("(")
+		exp = application{exp, p.pythonExpression()}
+		for p.peek(0) == "," {
+			p.consume(",")
+			exp = application{exp, p.pythonExpression()}
+		}
+		p.consume(")")
+	}
+	return exp
+}
+
+// formatHaskell prints an expression with the parentheses format uses
+func formatHaskell(exp Expression) string {
+	switch exp := exp.(type) {
+	case binding:
+		return fmt.Sprintf("let %v = %v in %v", exp.name, formatHaskellValue(exp.value), formatHaskell(exp.body))
+	case replBinding:
+		return fmt.Sprintf("%v = %v", exp.name, formatHaskell(exp.value))
+	case abstraction:
+		params := []string{}
+		var body Expression = exp
+		for {
+			abs, ok := body.(abstraction)
+			if !ok {
+				break
+			}
+			params = append(params, abs.param.identifier)
+			body = abs.expr
+		}
+		return fmt.Sprintf("\\%v -> %v", strings.Join(params, " "), formatHaskell(body))
+	case application:
+		left := formatHaskell(exp.left)
+		if _, ok := exp.left.(application); !ok {
+			left = formatHaskellAtom(exp.left)
+		}
+		return fmt.Sprintf("%v %v", left, formatHaskellAtom(exp.right))
+	default:
+		return exp.String()
+	}
+}
+
+func formatHaskellValue(exp Expression) string {
+	switch exp.(type) {
+	case binding, replBinding:
+		return "(" + formatHaskell(exp) + ")"
+	default:
+		return formatHaskell(exp)
+	}
+}
+
+func formatHaskellAtom(exp Expression) string {
+	switch exp.(type) {
+	case variable, freeVariable:
+		return formatHaskell(exp)
+	default:
+		return "(" + formatHaskell(exp) + ")"
+	}
+}
+
+// formatPython prints an expression as Python, which has no let, so a binding
+// is printed as the application of a lambda to its value
+func formatPython(exp Expression) string {
+	switch exp := exp.(type) {
+	case binding:
+		return formatPython(application{abstraction{exp.name, exp.body}, exp.value})
+	case replBinding:
+		return fmt.Sprintf("%v = %v", exp.name, formatPython(exp.value))
+	case abstraction:
+		params := []string{}
+		var body Expression = exp
+		for {
+			abs, ok := body.(abstraction)
+			if !ok {
+				break
+			}
+			params = append(params, abs.param.identifier)
+			body = abs.expr
+		}
+		return fmt.Sprintf("lambda %v: %v", strings.Join(params, ", "), formatPython(body))
+	case application:
+		left := formatPython(exp.left)
+		switch exp.left.(type) {
+		case application, variable, freeVariable:
+		default:
+			left = "(" + left + ")"
+		}
+		return fmt.Sprintf("%v(%v)", left, formatPython(exp.right))
+	default:
+		return exp.String()
+	}
+}
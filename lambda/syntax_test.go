@@ -0,0 +1,84 @@
+package lambda
+
+import "testing"
+
+func TestSyntax(t *testing.T) {
+	syntaxCases := []struct {
+		program string
+		syntax  string
+		native  string
+		output  string
+	}{
+		{"\\x y -> x y", "haskell", "𝞴x y.x y", "\\x y -> x y"},
+		{"(\\x -> x) (f a)", "haskell", "(𝞴x.x) (f a)", "(\\x -> x) (f a)"},
+		{"f \\x -> x", "haskell", "f (𝞴x.x)", "f (\\x -> x)"},
+		{"let id = \\x -> x in id id", "haskell", "let id = 𝞴x.x in id id", "let id = \\x -> x in id id"},
+		{"k = \\x y -> x", "haskell", "'k = 𝞴x y.x", "k = \\x y -> x"},
+		{"lambda x, y: x(y)", "python", "𝞴x y.x y", "lambda x, y: x(y)"},
+		{"f(a, b)(c)", "python", "f a b c", "f(a)(b)(c)"},
+		{"(lambda x: x)(lambda y: y)", "python", "(𝞴x.x) (𝞴y.y)", "(lambda x: x)(lambda y: y)"},
+		{"f(g(a))", "python", "f (g a)", "f(g(a))"},
+		{"let id = 𝞴x.x in id", "lambda", "let id = 𝞴x.x in id", "let id = 𝞴x.x in id"},
+	}
+	for _, tt := range syntaxCases {
+		t.Run(tt.program, func(t *testing.T) {
+			exp, err := ParseSyntax(tt.program, tt.syntax)
+			if err != nil {
+				t.Fatalf("expected %v, but got %v", tt.native, err)
+			}
+			if res := format(exp); res != tt.native {
+				t.Errorf("expected %v, but got %v", tt.native, res)
+			}
+			if res, _ := FormatSyntax(exp, tt.syntax); res != tt.output {
+				t.Errorf("expected %v, but got %v", tt.output, res)
+			}
+		})
+	}
+	exp, _ := parse("let id = 𝞴x.x in id")
+	if res, _ := FormatSyntax(exp, "python"); res != "(lambda id: id)(lambda x: x)" {
+		t.Errorf("expected %v, but got %v", "(lambda id: id)(lambda x: x)", res)
+	}
+	// names the notations don't take are renamed, as the primes of a binder
+	// renamed to avoid capture
+	renamed, _ := normalize(parseOrPanic("(𝞴x y.x y) y"), 100)
+	for _, tt := range []struct {
+		exp    Expression
+		syntax string
+		output string
+	}{
+		{renamed, "python", "lambda y_1: y(y_1)"},
+		{renamed, "haskell", "\\y' -> y y'"},
+		{parseOrPanic("𝞴X of.X of"), "haskell", "\\x_1 of_1 -> x_1 of_1"},
+		{parseOrPanic("𝞴x.if x 1 x'"), "python", "lambda x: if_1(x)(v1_1)(x_1)"},
+		{abstraction{variable{"x"}, application{variable{"x"}, variable{"_x"}}}, "haskell", "\\x -> x _x"},
+	} {
+		if res, _ := FormatSyntax(tt.exp, tt.syntax); res != tt.output {
+			t.Errorf("expected %v, but got %v", tt.output, res)
+		}
+		// and read back as they were written
+		exp, err := ParseSyntax(tt.output, tt.syntax)
+		if res, _ := FormatSyntax(exp, tt.syntax); err != nil || res != tt.output {
+			t.Errorf("expected %v to read back, but got %v %v", tt.output, res, err)
+		}
+	}
+	for _, program := range []string{"\\x -> ", "\\x -> x)", "lambda: x", "_"} {
+		syntax := "haskell"
+		if program == "lambda: x" {
+			syntax = "python"
+		}
+		if _, err := ParseSyntax(program, syntax); err == nil {
+			t.Errorf("expected an error for %v, but got none", program)
+		}
+	}
+	if _, err := ParseSyntax("x", "ocaml"); err == nil {
+		t.Errorf("expected an error for an unknown syntax, but got none")
+	}
+}
+
+func parseOrPanic(program string) Expression {
+	exp, err := parse(program)
+	if err != nil {
+		panic(err)
+	}
+	return exp
+}
@@ -0,0 +1,83 @@
+package lambda
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// traceTable prints a trace as a table with a row for each step: its number,
+// the rule it applied, the path to its redex and the size of the term it led
+// to, that term indented beneath the row. The columns line up however long
+// the terms are, so a long trace can be skimmed down them.
+func traceTable(out io.Writer, terms []Expression, contractions []contraction) {
+	rows := [][4]string{{"step", "rule", "path", "size"}, {"0", "-", "-", strconv.Itoa(size(terms[0]))}}
+	for i, c := range contractions {
+		rows = append(rows, [4]string{strconv.Itoa(i + 1), c.rule(), rootPath(c.path), strconv.Itoa(size(terms[i+1]))})
+	}
+	widths := [4]int{}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = maxInt(widths[i], len([]rune(cell)))
+		}
+	}
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, cell := range row {
+			// sizes are numbers, aligned to the right
+			if j == len(row)-1 {
+				cells[j] = strings.Repeat(" ", widths[j]-len(cell)) + cell
+			} else {
+				cells[j] = cell + strings.Repeat(" ", widths[j]-len([]rune(cell)))
+			}
+		}
+		fmt.Fprintln(out, strings.Join(cells, "  "))
+		if i > 0 {
+			fmt.Fprintf(out, "    %v\n", format(terms[i-1]))
+		}
+	}
+}
+
+// TraceStep is a row of a trace for analysis elsewhere: the term Step steps
+// into reducing, of Size nodes, after the step of Rule at Path that took
+// Elapsed. The first, the term reduced, is step 0, and has rule and path -.
+type TraceStep struct {
+	Step       int
+	Rule, Path string
+	Size       int
+	Elapsed    time.Duration
+	Term       Expression
+}
+
+// TraceSteps reduces exp, with the definitions of env filled in, a normal
+// order step at a time, calling yield with each step as it is taken rather
+// than keeping them, so a long trace takes no more memory than its terms do.
+// With alpha, renaming to avoid capture is a step of its own, which doesn't
+// count towards maxSteps. It fails with ErrStepLimit after maxSteps, or with
+// what yield fails with.
+func TraceSteps(exp Expression, env Environment, maxSteps int, alpha bool, yield func(TraceStep) error) error {
+	exp = resolve(exp, env)
+	if err := yield(TraceStep{0, "-", "-", size(exp), 0, exp}); err != nil {
+		return err
+	}
+	for i, steps := 1, 0; ; i++ {
+		start := time.Now()
+		next, c, ok := contractStep(exp, alpha)
+		elapsed := time.Since(start)
+		if !ok {
+			return nil
+		}
+		if !c.alpha {
+			if steps == maxSteps {
+				return ErrStepLimit
+			}
+			steps++
+		}
+		exp = next
+		if err := yield(TraceStep{i, c.rule(), rootPath(c.path), size(exp), elapsed, exp}); err != nil {
+			return err
+		}
+	}
+}
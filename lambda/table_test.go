@@ -0,0 +1,42 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestTraceSteps(t *testing.T) {
+	id, _ := parse("𝞴x.x")
+	env := Environment{}.define(variable{"id"}, id, "'id = 𝞴x.x")
+	exp, _ := parse("let y = id in id y")
+	rows := []string{}
+	err := TraceSteps(exp, env, 10, false, func(s TraceStep) error {
+		if s.Elapsed < 0 {
+			t.Errorf("expected a duration, but got %v", s.Elapsed)
+		}
+		rows = append(rows, fmt.Sprintf("%v %v %v %v %v", s.Step, s.Rule, s.Path, s.Size, format(s.Term)))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"0 - - 7 let y = 𝞴x.x in (𝞴x.x) y",
+		"1 let / 5 (𝞴x.x) (𝞴x.x)",
+		"2 beta / 2 𝞴x.x",
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, but got %v", expected, rows)
+	}
+
+	omega, _ := parse("(𝞴x.x x) (𝞴x.x x)")
+	steps := 0
+	if err := TraceSteps(omega, Environment{}, 5, false, func(TraceStep) error { steps++; return nil }); err != ErrStepLimit || steps != 6 {
+		t.Errorf("expected a step limit after 5 steps, but got %v after %v", err, steps-1)
+	}
+	stop := errors.New("stop")
+	if err := TraceSteps(omega, Environment{}, 5, false, func(TraceStep) error { return stop }); err != stop {
+		t.Errorf("expected the error yield fails with, but got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package lambda
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeTree draws exp as a tree, a node on each line beneath its parent with
+// box-drawing lines joining them: abstractions as 𝞴x, applications as @,
+// and lets as let x over their value and body
+func writeTree(out io.Writer, exp Expression) {
+	var walk func(exp Expression, prefix, branch, indent string)
+	walk = func(exp Expression, prefix, branch, indent string) {
+		label, children := treeNode(exp)
+		fmt.Fprintf(out, "%v%v%v\n", prefix, branch, label)
+		for i, child := range children {
+			if i == len(children)-1 {
+				walk(child, prefix+indent, "└── ", "    ")
+			} else {
+				walk(child, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+	walk(exp, "", "", "")
+}
+
+// treeNode labels a node of the tree of a term, and finds its children
+func treeNode(exp Expression) (string, []Expression) {
+	switch exp := exp.(type) {
+	case binding:
+		return "let " + exp.name.identifier, []Expression{exp.value, exp.body}
+	case replBinding:
+		return "'" + exp.name.identifier, []Expression{exp.value}
+	case abstraction:
+		return "𝞴" + exp.param.identifier, []Expression{exp.expr}
+	case application:
+		return "@", []Expression{exp.left, exp.right}
+	case strictApplication:
+		return treeNode(exp.application)
+	case quoted:
+		return "quote", []Expression{exp.expr}
+	case unquoted:
+		return "~", []Expression{exp.expr}
+	default:
+		return exp.String(), nil
+	}
+}
@@ -0,0 +1,286 @@
+package lambda
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// lambda tui steps a term on a full screen, a debugger for reductions: one
+// pane shows the term, one the redexes it has with the one to contract next
+// selected, and one the definitions the term uses. Keys step by the strategy,
+// contract the selected redex instead, undo steps and switch the strategy.
+
+// Stepper is the state of lambda tui: the terms the term has been stepped
+// through, and which redex of the last one is selected
+type Stepper struct {
+	used     []envBinding
+	terms    []Expression
+	steps    []contraction
+	strategy Strategy
+	selected int
+	// message says what the last key did
+	message string
+}
+
+// NewStepper parses term to step, with the definitions of env filled in
+func NewStepper(term string, env Environment) (*Stepper, error) {
+	ast, err := parse(term)
+	if err != nil {
+		return nil, err
+	}
+	s := &Stepper{terms: []Expression{resolve(ast, env)}}
+	free := freeVariables(ast)
+	for _, b := range env.definitions() {
+		if free[b.name.identifier] {
+			s.used = append(s.used, b)
+		}
+	}
+	sort.Slice(s.used, func(i, j int) bool { return s.used[i].name.identifier < s.used[j].name.identifier })
+	s.selected = s.pick()
+	return s, nil
+}
+
+// LoadStepper is NewStepper with the definitions of the module in file, whose
+// imports are looked for along path, or the default path when it is nil. With
+// no file only the term's own definitions are in scope.
+func LoadStepper(term, file string, path []string) (*Stepper, error) {
+	if file == "" {
+		return NewStepper(term, Environment{})
+	}
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if path == nil {
+		path = defaultModulePath()
+	}
+	m := modules{path: path}
+	mod, err := m.read("", file, string(text))
+	if err != nil {
+		return nil, err
+	}
+	return NewStepper(term, mod.scope)
+}
+
+func (s *Stepper) current() Expression {
+	return s.terms[len(s.terms)-1]
+}
+
+// pick is the redex of the current term the strategy contracts next, -1 for
+// a normal form. reducts lists a redex before the ones within it, so the
+// leftmost innermost is the first with none after it beneath its path.
+func (s *Stepper) pick() int {
+	redexes := reducts(s.current())
+	if len(redexes) == 0 {
+		return -1
+	}
+	if s.strategy == NormalOrder {
+		return 0
+	}
+	for i, r := range redexes {
+		if i+1 == len(redexes) || !strings.HasPrefix(redexes[i+1].c.path, r.c.path+"/") {
+			return i
+		}
+	}
+	return 0
+}
+
+// contract steps the current term by its redex i
+func (s *Stepper) contract(i int) {
+	redexes := reducts(s.current())
+	if i < 0 || i >= len(redexes) {
+		s.message = "normal form"
+		return
+	}
+	s.terms = append(s.terms, redexes[i].term)
+	s.steps = append(s.steps, redexes[i].c)
+	s.selected = s.pick()
+	s.message = fmt.Sprintf("%v at %v", redexes[i].c.rule(), rootPath(redexes[i].c.path))
+}
+
+// Key acts on a key pressed, one of the letters of stepperKeys or up, down or
+// enter, reporting false when it quits
+func (s *Stepper) Key(key string) bool {
+	switch key {
+	case "q":
+		return false
+	case "n", " ":
+		s.contract(s.pick())
+	case "enter":
+		s.contract(s.selected)
+	case "r":
+		start := len(s.steps)
+		for len(s.steps)-start < defaultTraceSteps && s.pick() >= 0 {
+			s.contract(s.pick())
+		}
+		s.message = fmt.Sprintf("ran %v steps", len(s.steps)-start)
+		if s.pick() >= 0 {
+			s.message += ", and stopped short of a normal form"
+		}
+	case "u":
+		if len(s.steps) == 0 {
+			s.message = "nothing to undo"
+			break
+		}
+		s.terms, s.steps = s.terms[:len(s.terms)-1], s.steps[:len(s.steps)-1]
+		s.selected = s.pick()
+		s.message = "undone"
+	case "s":
+		s.strategy = 1 - s.strategy
+		s.selected = s.pick()
+		s.message = s.strategy.String()
+	case "up", "k":
+		if s.selected > 0 {
+			s.selected--
+		}
+	case "down", "j":
+		if s.selected+1 < len(reducts(s.current())) {
+			s.selected++
+		}
+	}
+	return true
+}
+
+// stepperKeys is the key bar at the foot of the screen
+const stepperKeys = "n step · ↑↓ select · enter contract selected · r run · u undo · s strategy · q quit"
+
+// Draw writes the screen, width columns by height lines, clearing it first
+func (s *Stepper) Draw(out io.Writer, width, height int) {
+	lines := []string{fmt.Sprintf("term, step %v, %v", len(s.steps), s.strategy)}
+	// the selected redex is highlighted in the term as :trace highlights
+	// the one it contracts
+	redexes := reducts(s.current())
+	term := format(s.current())
+	if s.selected >= 0 {
+		term = formatMarked(s.current(), redexes[s.selected].c.path, ansiRedex, ansiReset)
+	}
+	for _, line := range wrap(term, width-2) {
+		lines = append(lines, "  "+line)
+	}
+	lines = append(lines, "", fmt.Sprintf("redexes, %v", len(redexes)))
+	if len(redexes) == 0 {
+		lines = append(lines, "  none, a normal form")
+	}
+	for i, r := range redexes {
+		marker := " "
+		if i == s.selected {
+			marker = "▸"
+		}
+		lines = append(lines, fmt.Sprintf("%v %v %v", marker, rootPath(r.c.path), format(subterm(s.current(), r.c.path))))
+	}
+	lines = append(lines, "", "environment")
+	if len(s.used) == 0 {
+		lines = append(lines, "  nothing defined is used")
+	}
+	for _, b := range s.used {
+		lines = append(lines, fmt.Sprintf("  %v = %v", b.name.identifier, formatValue(b.value)))
+	}
+	// the message and keys stay at the foot, cutting the panes short
+	foot := []string{s.message, stepperKeys}
+	if len(lines) > height-len(foot) {
+		lines = lines[:maxInt(height-len(foot), 0)]
+	}
+	for len(lines) < height-len(foot) {
+		lines = append(lines, "")
+	}
+	fmt.Fprint(out, "\x1b[H\x1b[2J")
+	for i, line := range append(lines, foot...) {
+		if i > 0 {
+			fmt.Fprint(out, "\r\n")
+		}
+		if !strings.Contains(line, "\x1b") {
+			line = clip(line, width)
+		}
+		fmt.Fprint(out, line)
+	}
+}
+
+// clip cuts line to width runes, marking that it was cut with …
+func clip(line string, width int) string {
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// wrap breaks text into lines of width runes, not counting the ANSI escapes
+// in it
+func wrap(text string, width int) []string {
+	lines := []string{}
+	var line strings.Builder
+	n, escape := 0, false
+	for _, c := range text {
+		if n == width && width > 0 && !escape && c != '\x1b' {
+			lines = append(lines, line.String())
+			line.Reset()
+			n = 0
+		}
+		line.WriteRune(c)
+		switch {
+		case c == '\x1b':
+			escape = true
+		case escape:
+			escape = c != 'm'
+		default:
+			n++
+		}
+	}
+	return append(lines, line.String())
+}
+
+// RunStepper draws s on out, a terminal width columns by height lines in raw
+// mode, acting on the keys read from in until q
+func RunStepper(in io.Reader, out io.Writer, s *Stepper, width, height int) error {
+	keys := bufio.NewReader(in)
+	// the alternate screen leaves the terminal as it was on quitting
+	fmt.Fprint(out, "\x1b[?1049h")
+	defer fmt.Fprint(out, "\x1b[?1049l")
+	for {
+		s.Draw(out, width, height)
+		key, err := readKey(keys)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !s.Key(key) {
+			return nil
+		}
+	}
+}
+
+// readKey reads a key a raw terminal sends: a character, enter, or up or down
+// as their escape sequences. Ctrl-C and Ctrl-D quit as q does.
+func readKey(in *bufio.Reader) (string, error) {
+	c, _, err := in.ReadRune()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case 3, 4:
+		return "q", nil
+	case '\x1b':
+		if next, err := in.Peek(2); err == nil && next[0] == '[' {
+			in.Discard(2)
+			switch next[1] {
+			case 'A':
+				return "up", nil
+			case 'B':
+				return "down", nil
+			}
+		}
+		return "", nil
+	}
+	return string(c), nil
+}
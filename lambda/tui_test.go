@@ -0,0 +1,75 @@
+package lambda
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestStepper(t *testing.T) {
+	env := Environment{}.bind(variable{"id"}, abstraction{variable{"x"}, variable{"x"}})
+	s, err := NewStepper("id (id z)", env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// normal order contracts the outer redex, applicative order the argument
+	s.Key("n")
+	if got := format(s.current()); got != "(𝞴x.x) z" || s.message != "beta at /" {
+		t.Errorf("expected the outer redex contracted, but got %v after %v", got, s.message)
+	}
+	s.Key("u")
+	s.Key("s")
+	s.Key("n")
+	if got := format(s.current()); got != "(𝞴x.x) z" || s.message != "beta at /arg" {
+		t.Errorf("expected the argument contracted, but got %v after %v", got, s.message)
+	}
+	s.Key("u")
+	s.Key("u")
+	if s.message != "nothing to undo" {
+		t.Errorf("expected nothing to undo, but got %v", s.message)
+	}
+	// enter contracts the selected redex, whatever the strategy
+	s.Key("up")
+	s.Key("enter")
+	if got := format(s.current()); got != "(𝞴x.x) z" || s.message != "beta at /" {
+		t.Errorf("expected the selected redex contracted, but got %v after %v", got, s.message)
+	}
+	s.Key("r")
+	if got := format(s.current()); got != "z" || s.message != "ran 1 steps" {
+		t.Errorf("expected the normal form z, but got %v after %v", got, s.message)
+	}
+	if s.Key("q") {
+		t.Errorf("expected q to quit")
+	}
+
+	var screen strings.Builder
+	s.Draw(&screen, 40, 12)
+	lines := strings.Split(screen.String(), "\r\n")
+	expected := []string{
+		"\x1b[H\x1b[2Jterm, step 2, applicative order",
+		"  z",
+		"",
+		"redexes, 0",
+		"  none, a normal form",
+		"",
+		"environment",
+		"  id = 𝞴x.x",
+		"",
+		"",
+		"ran 1 steps",
+		"n step · ↑↓ select · enter contract sel…",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected screen\n%v\nbut got\n%v", strings.Join(expected, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("n\x1b[A\x1b[B\r\x03"))
+	expected := []string{"n", "up", "down", "enter", "q"}
+	for _, e := range expected {
+		if key, err := readKey(in); err != nil || key != e {
+			t.Errorf("expected %v, but got %v, %v", e, key, err)
+		}
+	}
+}
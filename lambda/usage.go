@@ -0,0 +1,65 @@
+package lambda
+
+// Multiplicity is how many times a bound variable occurs in the body of its
+// binder, counted no further than many
+type Multiplicity int
+
+const (
+	Unused Multiplicity = iota
+	Once
+	Many
+)
+
+func (m Multiplicity) String() string {
+	switch m {
+	case Unused:
+		return "unused"
+	case Once:
+		return "once"
+	default:
+		return "many times"
+	}
+}
+
+// BinderUsage is how often the variable bound at Path, a Diff path to the
+// 𝞴 or let binding it, is used
+type BinderUsage struct {
+	Name string
+	Path string
+	Uses Multiplicity
+}
+
+// Usage reports how many times each variable e binds with 𝞴 or let occurs
+// in its body, binders in the order they appear. A term using every variable
+// once is linear, at most once affine, and a variable used at most once can
+// be substituted without copying its value.
+func Usage(e Expression) []BinderUsage {
+	usages := []BinderUsage{}
+	var walk func(e Expression, path string)
+	walk = func(e Expression, path string) {
+		switch e := e.(type) {
+		case binding:
+			usages = append(usages, BinderUsage{e.name.identifier, rootPath(path), multiplicity(occurrences(e.name.identifier, e.body))})
+			walk(e.value, path+"/value")
+			walk(e.body, path+"/body")
+		case replBinding:
+			walk(e.value, path+"/value")
+		case abstraction:
+			usages = append(usages, BinderUsage{e.param.identifier, rootPath(path), multiplicity(occurrences(e.param.identifier, e.expr))})
+			walk(e.expr, path+"/body")
+		case application:
+			walk(e.left, path+"/fn")
+			walk(e.right, path+"/arg")
+		}
+	}
+	walk(e, "")
+	return usages
+}
+
+// multiplicity counts n occurrences
+func multiplicity(n int) Multiplicity {
+	if n > 1 {
+		return Many
+	}
+	return Multiplicity(n)
+}
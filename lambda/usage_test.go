@@ -0,0 +1,24 @@
+package lambda
+
+import "testing"
+
+func TestUsage(t *testing.T) {
+	exp, _ := parse("let k = 𝞴x y.x in 𝞴f.f (f (k f)) (𝞴f.z)")
+	expected := []BinderUsage{
+		{"k", "/", Once},
+		{"x", "/value", Once},
+		{"y", "/value/body", Unused},
+		{"f", "/body", Many},
+		// the inner f shadows the outer one
+		{"f", "/body/body/arg", Unused},
+	}
+	usages := Usage(exp)
+	if len(usages) != len(expected) {
+		t.Fatalf("expected %v, but got %v", expected, usages)
+	}
+	for i := range expected {
+		if usages[i] != expected[i] {
+			t.Errorf("expected %v, but got %v", expected[i], usages[i])
+		}
+	}
+}
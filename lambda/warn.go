@@ -0,0 +1,149 @@
+package lambda
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Warnings point out programs that run, but likely not as their author
+// meant. Like the linearity checker they work on the binders the parser
+// records, so they can say where in the source the problem is.
+
+// Warning is something suspicious about a program at Offset, in runes from
+// its start, which is at Line and Column counting from 1
+type Warning struct {
+	Message              string
+	Offset, Line, Column int
+	// OuterLine and OuterColumn are, for a binder shadowing another, where
+	// the one shadowed is, and 0 for other warnings
+	OuterLine, OuterColumn int
+	// where what it is about ends
+	end int
+	// outer is Message with the position of the binder shadowed left to
+	// fill in, so it can be told again once moved
+	outer string
+}
+
+// relocate moves w, about a program starting at line and column of a file,
+// both counting from 1, to where it is in the file
+func (w Warning) relocate(line, column int) Warning {
+	move := func(l, c int) (int, int) {
+		if l == 1 {
+			c += column - 1
+		}
+		return l + line - 1, c
+	}
+	w.Line, w.Column = move(w.Line, w.Column)
+	if w.outer != "" {
+		w.OuterLine, w.OuterColumn = move(w.OuterLine, w.OuterColumn)
+		w.Message = fmt.Sprintf(w.outer, w.OuterLine, w.OuterColumn)
+	}
+	return w
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%v:%v: warning: %v", w.Line, w.Column, w.Message)
+}
+
+// isDefinition tells the binders of ' definitions, in scope for the rest of
+// the session, from those of let and 𝞴
+func (b binder) isDefinition() bool {
+	return b.scopeStart == b.scopeEnd && b.value != nil
+}
+
+// warning makes a warning about the part of the program of d from start to
+// end
+func (d *document) warning(start, end int, format string, args ...interface{}) Warning {
+	line, column := position(d.text, start)
+	return Warning{Message: fmt.Sprintf(format, args...), Offset: start, Line: line, Column: column, end: end}
+}
+
+// CheckShadowing parses program and warns of each binder hiding a variable
+// bound around it, or one of the names definitions, in the order of the
+// program
+func CheckShadowing(program string, definitions []string) ([]Warning, error) {
+	d := newDocument(program)
+	if d.err != nil {
+		return nil, d.err
+	}
+	defined := map[string]bool{}
+	for _, name := range definitions {
+		defined[name] = true
+	}
+	return d.shadowing(defined), nil
+}
+
+func (d *document) shadowing(defined map[string]bool) []Warning {
+	warnings := []Warning{}
+	for _, inner := range d.parser.binders {
+		if inner.isDefinition() {
+			continue
+		}
+		var outer binder
+		found := false
+		for _, b := range d.parser.binders {
+			if b.name != inner.name || b.start == inner.start || b.isDefinition() {
+				continue
+			}
+			// the parameters of one 𝞴 share its body, the later hiding the earlier
+			within := b.scopeStart <= inner.start && inner.start < b.scopeEnd ||
+				b.scopeStart == inner.scopeStart && b.scopeEnd == inner.scopeEnd && b.start < inner.start
+			if within && (!found || b.start > outer.start) {
+				outer, found = b, true
+			}
+		}
+		switch {
+		case found:
+			w := d.warning(inner.start, inner.end, "%v shadows the %v bound at", inner.name, outer.name)
+			w.outer = w.Message + " %v:%v"
+			w.OuterLine, w.OuterColumn = position(d.text, outer.start)
+			w.Message = fmt.Sprintf(w.outer, w.OuterLine, w.OuterColumn)
+			warnings = append(warnings, w)
+		case defined[inner.name.identifier]:
+			warnings = append(warnings, d.warning(inner.start, inner.end, "%v shadows the definition of %v", inner.name, inner.name))
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Offset < warnings[j].Offset
+	})
+	return warnings
+}
+
+// CheckUnused parses program and warns of each variable it binds with let,
+// if lets, or with 𝞴, if params, that isn't used, in the order of the
+// program.
+// When a free variable in scope is named much like it, the variable was
+// likely meant to be used there.
+func CheckUnused(program string, lets, params bool) ([]Warning, error) {
+	d := newDocument(program)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.unused(lets, params), nil
+}
+
+func (d *document) unused(lets, params bool) []Warning {
+	uses, free := d.uses()
+	warnings := []Warning{}
+	for _, b := range d.parser.binders {
+		if b.isDefinition() || b.value != nil && !lets || b.value == nil && !params || len(uses[b.start]) > 0 {
+			continue
+		}
+		best, bestDistance := "", len([]rune(b.name.identifier))/2+1
+		for _, s := range free {
+			name := s.exp.(variable).identifier
+			if dist := editDistance(name, b.name.identifier); s.start >= b.scopeStart && s.end <= b.scopeEnd && dist < bestDistance {
+				best, bestDistance = name, dist
+			}
+		}
+		if best != "" {
+			warnings = append(warnings, d.warning(b.start, b.end, "%v is never used, did you mean it for %v?", b.name, best))
+		} else {
+			warnings = append(warnings, d.warning(b.start, b.end, "%v is never used", b.name))
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Offset < warnings[j].Offset
+	})
+	return warnings
+}
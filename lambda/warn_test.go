@@ -0,0 +1,86 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckShadowing(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected []string
+	}{
+		{"𝞴x y.x y", nil},
+		{"𝞴x.let x = x in x", []string{"1:8: warning: x shadows the x bound at 1:2"}},
+		{"𝞴x x.x", []string{"1:4: warning: x shadows the x bound at 1:2"}},
+		{"𝞴x.(𝞴y.𝞴x.x) (𝞴x.x)", []string{
+			"1:9: warning: x shadows the x bound at 1:2",
+			"1:16: warning: x shadows the x bound at 1:2",
+		}},
+		// the innermost is the one shadowed
+		{"let x = a in\n𝞴x.𝞴x.x", []string{
+			"2:2: warning: x shadows the x bound at 1:5",
+			"2:5: warning: x shadows the x bound at 2:2",
+		}},
+		// the value of a let is outside its scope
+		{"let f = 𝞴f.f in f", nil},
+		{"𝞴id.id", []string{"1:2: warning: id shadows the definition of id"}},
+		{"'id = 𝞴x.x", nil},
+	}
+	for _, test := range tests {
+		warnings, err := CheckShadowing(test.program, []string{"id"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := []string{}
+		for _, w := range warnings {
+			res = append(res, w.String())
+		}
+		if strings.Join(res, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%v: expected %v, but got %v", test.program, test.expected, res)
+		}
+	}
+}
+
+func TestWarningRelocate(t *testing.T) {
+	// a program of two lines starting at column 5 of line 3 of a file
+	warnings, _ := CheckShadowing("let x = a in\n𝞴x.x", nil)
+	w := warnings[0].relocate(3, 5)
+	if w.String() != "4:2: warning: x shadows the x bound at 3:9" || w.OuterLine != 3 || w.OuterColumn != 9 {
+		t.Errorf("expected the warning and the binder shadowed moved, but got %v", w)
+	}
+}
+
+func TestCheckUnused(t *testing.T) {
+	tests := []struct {
+		program  string
+		params   bool
+		expected []string
+	}{
+		{"let id = 𝞴x.x in id", false, nil},
+		{"let id = 𝞴x.x in y", false, []string{"1:5: warning: id is never used"}},
+		{"let flase = 𝞴x y.y in 𝞴b.b false true", false, []string{"1:5: warning: flase is never used, did you mean it for false?"}},
+		// a free variable out of scope isn't the one meant
+		{"(let flase = a in b) false", false, []string{"1:6: warning: flase is never used"}},
+		{"𝞴x y.x", false, nil},
+		{"𝞴x y.x", true, []string{"1:4: warning: y is never used"}},
+		{"let k = 𝞴x y.x in let i = a in k", true, []string{
+			"1:12: warning: y is never used",
+			"1:23: warning: i is never used",
+		}},
+		{"'k = 𝞴x y.x", false, nil},
+	}
+	for _, test := range tests {
+		warnings, err := CheckUnused(test.program, true, test.params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := []string{}
+		for _, w := range warnings {
+			res = append(res, w.String())
+		}
+		if strings.Join(res, "\n") != strings.Join(test.expected, "\n") {
+			t.Errorf("%v: expected %v, but got %v", test.program, test.expected, res)
+		}
+	}
+}
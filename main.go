@@ -1,23 +1,891 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
 	"june/lambda/lambda"
 )
 
+// seed is the seed given before the command, as in lambda -seed 42 quiz,
+// which the commands generating random terms default to. Given the same seed
+// they generate the same terms on every platform.
+var seed = time.Now().UnixNano()
+
 func main() {
-	lambda.Repl()
-
-	// program := "let \na = b in c"
-	// scanner := lambda.Scanner{Program: []rune(program)}
-	// tokens, err := scanner.Scan()
-	// if err != nil {
-	// 	fmt.Println(err)
-	// 	return
-	// }
-	// fmt.Println(tokens)
-	// parser := lambda.Parser{Tokens: tokens}
-	// ast := parser.Parse()
-	// fmt.Println(ast)
-	// interpreter := lambda.Interpreter{Ast: ast}
-	// fmt.Println(interpreter.Interpret())
+	os.Args = append(os.Args[:1], globalFlags(os.Args[1:])...)
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		repl(os.Args[1:])
+		return
+	}
+	switch os.Args[1] {
+	case "serve":
+		serve(os.Args[2:])
+	case "rpc":
+		if err := lambda.ServeRPC(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	case "transform":
+		transform(os.Args[2:])
+	case "fmt":
+		format(os.Args[2:])
+	case "grade":
+		grade(os.Args[2:])
+	case "quiz":
+		quiz(os.Args[2:])
+	case "random":
+		random(os.Args[2:])
+	case "diff":
+		diff(os.Args[2:])
+	case "eq":
+		eq(os.Args[2:])
+	case "trace":
+		trace(os.Args[2:])
+	case "replay":
+		replay(os.Args[2:])
+	case "doctest":
+		doctest(os.Args[2:])
+	case "check":
+		check(os.Args[2:])
+	case "stats":
+		stats(os.Args[2:])
+	case "compile":
+		compile(os.Args[2:])
+	case "exec":
+		execute(os.Args[2:])
+	case "tui":
+		tui(os.Args[2:])
+	case "lsp":
+		if err := lambda.ServeLSP(os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %v\n", os.Args[1])
+		os.Exit(2)
+	}
+}
+
+// globalFlags reads the flags for every command given before it, -seed n or
+// -seed=n, returning the arguments after them
+func globalFlags(args []string) []string {
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[0], "-"), "=")
+		if !strings.HasPrefix(args[0], "-") || name != "seed" {
+			return args
+		}
+		args = args[1:]
+		if !hasValue {
+			if len(args) == 0 {
+				fmt.Fprintln(os.Stderr, "flag needs an argument: -seed")
+				os.Exit(2)
+			}
+			value, args = args[0], args[1:]
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid value %q for flag -seed: %v\n", value, err)
+			os.Exit(2)
+		}
+		seed = n
+	}
+	return args
+}
+
+func repl(args []string) {
+	flags := flag.NewFlagSet("lambda", flag.ExitOnError)
+	options := replFlags(flags)
+	startPprof := pprofFlag(flags)
+	flags.Parse(args)
+	startPprof()
+	lambda.RunRepl(os.Stdin, os.Stdout, options())
+}
+
+// replFlags defines the flags setting up a REPL, returning the options they
+// give once parsed
+func replFlags(flags *flag.FlagSet) func() lambda.ReplOptions {
+	linear := flags.Bool("linear", false, "reject programs that don't use each variable they bind exactly once")
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	allowNet := flags.Bool("allow-net", false, "let :load read definitions from http and https URLs")
+	debug := flags.Bool("debug", false, "print every expression evaluated, with the names bound there")
+	persist := flags.String("persist", "", "state file to restore definitions from on start and save them to on exit, such as ~/.lambda/state")
+	maxOutput := flags.Int("max-output", 2000, "most characters of a value to print, the rest summarized, 0 for no limit")
+	syntax := flags.String("syntax", "", "syntax to print values in: lambda, haskell or python (default lambda fully parenthesized)")
+	glyph := flags.String("glyph", "", "how to print 𝞴 in values: 𝞴, λ or \\ (default 𝞴)")
+	width := flags.Int("width", 0, "width of the lines to break values into (default $COLUMNS or 80 on a terminal, one line otherwise)")
+	prelude := flags.String("prelude", "", "module whose definitions the REPL starts with")
+	cache := flags.String("cache", "", "directory to keep the normal forms of closed terms in, so they aren't evaluated again in later sessions, such as ~/.lambda/cache")
+	return func() lambda.ReplOptions {
+		options := lambda.ReplOptions{Linear: *linear, AllowNet: *allowNet, Persist: *persist, Debug: *debug, MaxOutput: *maxOutput, Syntax: *syntax, Glyph: *glyph, Width: *width, CacheDir: *cache}
+		if home, err := os.UserHomeDir(); err == nil {
+			if strings.HasPrefix(*persist, "~/") {
+				options.Persist = filepath.Join(home, (*persist)[2:])
+			}
+			if strings.HasPrefix(*cache, "~/") {
+				options.CacheDir = filepath.Join(home, (*cache)[2:])
+			}
+		}
+		if *path != "" {
+			options.Path = filepath.SplitList(*path)
+		}
+		if *prelude != "" {
+			p, err := lambda.LoadPrelude(*prelude, options.Path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			options.Prelude = p
+		}
+		return options
+	}
+}
+
+// replay runs a script recorded with :record, failing if it prints other
+// than it did
+func replay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	options := replFlags(flags)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda replay [flags] session.script")
+		os.Exit(2)
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	n, err := lambda.ReplayScript(f, os.Stdout, options())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("replayed %v inputs\n", n)
+}
+
+// doctest runs the examples in the comments of a module, failing if any
+// evaluates other than they say
+func doctest(args []string) {
+	flags := flag.NewFlagSet("doctest", flag.ExitOnError)
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda doctest [-path dirs] file.lam")
+		os.Exit(2)
+	}
+	var dirs []string
+	if *path != "" {
+		dirs = filepath.SplitList(*path)
+	}
+	examples, err := lambda.Doctest(flags.Arg(0), dirs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	failed := 0
+	for _, e := range examples {
+		if !e.Passed {
+			failed++
+			fmt.Printf("%v:%v\n", flags.Arg(0), e)
+		}
+	}
+	fmt.Printf("%v examples, %v failed\n", len(examples), failed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// check reports the errors and warnings of a module without evaluating it,
+// failing if there are errors
+func check(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda check [-path dirs] file.lam")
+		os.Exit(2)
+	}
+	var dirs []string
+	if *path != "" {
+		dirs = filepath.SplitList(*path)
+	}
+	problems, err := lambda.Check(flags.Arg(0), dirs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if lambda.Failed(problems) {
+		os.Exit(1)
+	}
+}
+
+// stats measures each definition of a module, to spot encodings far larger
+// or costlier than they should be
+func stats(args []string) {
+	flags := flag.NewFlagSet("stats", flag.ExitOnError)
+	maxSteps := flags.Int("max-steps", 100000, "most applications of abstractions to normalize each definition in")
+	asJSON := flags.Bool("json", false, "print the stats as JSON, one definition per line")
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	files := parseInterspersed(flags, args)
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda stats [-max-steps n] [-json] [-path dirs] file.lam")
+		os.Exit(2)
+	}
+	var dirs []string
+	if *path != "" {
+		dirs = filepath.SplitList(*path)
+	}
+	definitions, err := lambda.Stats(files[0], dirs, *maxSteps)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *asJSON {
+		for _, d := range definitions {
+			line, _ := json.Marshal(d)
+			fmt.Println(string(line))
+		}
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "name\tsize\tdepth\tbinders\tredexes\tsteps\tfree\t")
+	for _, d := range definitions {
+		steps := strconv.Itoa(d.Steps)
+		if !d.Normalizes {
+			steps = ">" + steps
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n", d.Name, d.Size, d.Depth, d.Binders, d.Redexes, steps, strings.Join(d.Free, " "))
+	}
+	w.Flush()
+}
+
+// pprofFlag defines the -pprof flag, returning a function that serves the
+// net/http/pprof endpoints on its address, once the flags are parsed, if it
+// was given. They are served apart from lambda serve's handler, so profiling
+// needn't be exposed with it.
+func pprofFlag(flags *flag.FlagSet) func() {
+	addr := flags.String("pprof", "", "address to serve the net/http/pprof profiling endpoints on while running, such as localhost:6060")
+	return func() {
+		if *addr == "" {
+			return
+		}
+		listener, err := net.Listen("tcp", *addr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		fmt.Fprintf(os.Stderr, "profiling at http://%v/debug/pprof/\n", listener.Addr())
+		go func() {
+			log.Print(http.Serve(listener, mux))
+		}()
+	}
+}
+
+// parseInterspersed parses flags that may come after the arguments, as in
+// lambda compile prog.lam -o prog.lbc, returning the arguments
+func parseInterspersed(flags *flag.FlagSet, args []string) []string {
+	positional := []string{}
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+}
+
+// compile compiles a module's entry to an artifact lambda exec or go build
+// runs
+func compile(args []string) {
+	flags := flag.NewFlagSet("compile", flag.ExitOnError)
+	out := flags.String("o", "", "file to write the artifact to (default the module's name with .lbc, .ski or .go)")
+	target := flags.String("target", "bytecode", "what to compile to: bytecode, ski or go")
+	entry := flags.String("entry", "main", "definition to run")
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	files := parseInterspersed(flags, args)
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda compile [-o file] [-target bytecode|ski|go] [-entry name] [-path dirs] prog.lam")
+		os.Exit(2)
+	}
+	var dirs []string
+	if *path != "" {
+		dirs = filepath.SplitList(*path)
+	}
+	artifact, err := lambda.Compile(files[0], dirs, lambda.CompileOptions{Target: *target, Entry: *entry})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		extension := map[string]string{"bytecode": ".lbc", "ski": ".ski", "go": ".go"}[*target]
+		*out = strings.TrimSuffix(files[0], filepath.Ext(files[0])) + extension
+	}
+	if err := os.WriteFile(*out, artifact, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// execute runs an artifact lambda compile wrote, printing the normal form of
+// its entry
+func execute(args []string) {
+	flags := flag.NewFlagSet("exec", flag.ExitOnError)
+	maxSteps := flags.Int("max-steps", 0, "most beta reductions the program may take, 0 for no limit")
+	info := flags.Bool("info", false, "print what the artifact was compiled from and how, as JSON, instead of running it")
+	startPprof := pprofFlag(flags)
+	files := parseInterspersed(flags, args)
+	if len(files) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda exec [-max-steps n] [-info] [-pprof addr] prog.lbc")
+		os.Exit(2)
+	}
+	startPprof()
+	artifact, err := os.ReadFile(files[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *info {
+		i, err := lambda.ReadArtifactInfo(artifact)
+		if err != nil {
+			log.Fatalf("%v: %v", files[0], err)
+		}
+		line, _ := json.Marshal(i)
+		fmt.Println(string(line))
+		return
+	}
+	value, err := lambda.Exec(artifact, *maxSteps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v: %v\n", files[0], err)
+		os.Exit(1)
+	}
+	fmt.Println(lambda.Format(value))
+}
+
+// tui steps a term on a full screen, with the definitions of a module
+func tui(args []string) {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	load := flags.String("load", "", "module whose definitions the term may use")
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: lambda tui [-load file.lam] [-path dirs] term")
+		os.Exit(2)
+	}
+	var dirs []string
+	if *path != "" {
+		dirs = filepath.SplitList(*path)
+	}
+	s, err := lambda.LoadStepper(strings.Join(flags.Args(), " "), *load, dirs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	width, height := 80, 24
+	if size, err := stty("size"); err == nil {
+		fmt.Sscan(size, &height, &width)
+	}
+	// stty puts the terminal in raw mode, so keys are read as they are
+	// pressed, and restores it after
+	saved, err := stty("-g")
+	if err != nil {
+		log.Fatal("lambda tui needs a terminal: ", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		log.Fatal(err)
+	}
+	err = lambda.RunStepper(os.Stdin, os.Stdout, s, width, height)
+	stty(strings.TrimSpace(saved))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// stty runs stty on the terminal of stdin
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	maxSteps := flags.Int("max-steps", 1000000, "most beta reductions a request may take")
+	timeout := flags.Duration("timeout", 5*time.Second, "longest a request may evaluate")
+	playground := flags.Bool("playground", false, "serve the browser playground at /")
+	wasmDir := flags.String("wasm", "", "directory with lambda.wasm and wasm_exec.js for the playground")
+	sessionTTL := flags.Duration("session-ttl", time.Hour, "how long an idle session is kept")
+	maxSessions := flags.Int("max-sessions", 100, "most sessions that may exist at once")
+	shareTTL := flags.Duration("share-ttl", 30*24*time.Hour, "how long a shared playground link works")
+	maxShareSize := flags.Int("max-share-size", 64<<10, "largest program in bytes that may be shared")
+	maxShares := flags.Int("max-shares", 10000, "most shared programs kept at once")
+	prelude := flags.String("prelude", "", "module whose definitions every request and session starts with, read once and shared by all")
+	workers := flags.Int("workers", 0, "most evaluations running at once, 0 for one per CPU")
+	maxQueue := flags.Int("max-queue", 0, "most evaluations waiting for a worker, 0 for as many as there are workers")
+	maxPerClient := flags.Int("max-per-client", 8, "most requests from one address handled at once, 0 for unbounded")
+	maxBody := flags.Int64("max-body", 1<<20, "largest request body in bytes")
+	grace := flags.Duration("grace", 10*time.Second, "how long to let requests finish when shutting down")
+	startPprof := pprofFlag(flags)
+	flags.Parse(args)
+	startPprof()
+	server := lambda.Server{
+		MaxSteps:     *maxSteps,
+		Timeout:      *timeout,
+		Playground:   *playground,
+		WasmDir:      *wasmDir,
+		SessionTTL:   *sessionTTL,
+		MaxSessions:  *maxSessions,
+		ShareTTL:     *shareTTL,
+		MaxShareSize: *maxShareSize,
+		MaxShares:    *maxShares,
+		Workers:      *workers,
+		MaxQueue:     *maxQueue,
+		MaxPerClient: *maxPerClient,
+		MaxBodySize:  *maxBody,
+	}
+	if *prelude != "" {
+		p, err := lambda.LoadPrelude(*prelude, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		server.Prelude = p
+	}
+	// on an interrupt, stop accepting requests and let those being handled
+	// finish, for no longer than the grace period
+	stopped := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		log.Print("shutting down")
+		ctx, cancel := context.WithTimeout(context.Background(), *grace)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Print(err)
+		}
+		close(stopped)
+	}()
+	if err := server.ListenAndServe(*addr); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
+}
+
+// readProgram reads the file named by args, or stdin when there is none
+func readProgram(args []string) string {
+	var text []byte
+	var err error
+	if len(args) == 0 {
+		text, err = io.ReadAll(os.Stdin)
+	} else {
+		text, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(text)
+}
+
+func transform(args []string) {
+	flags := flag.NewFlagSet("transform", flag.ExitOnError)
+	simplify := flags.Bool("simplify", false, "apply local rewrites that never copy a term")
+	partial := flags.Bool("partial", false, "reduce every redex that doesn't depend on the -dynamic variables")
+	dynamic := flags.String("dynamic", "", "comma separated free variables unknown until run time")
+	maxSteps := flags.Int("max-steps", 100000, "most reductions partial evaluation may take")
+	canonical := flags.Bool("canonical", false, "rename bound variables to a, b, c, ... in binder order")
+	flags.Parse(args)
+	exp, err := lambda.Parse(readProgram(flags.Args()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *partial {
+		names := []string{}
+		if *dynamic != "" {
+			names = strings.Split(*dynamic, ",")
+		}
+		if exp, err = lambda.PartialEval(exp, names, *maxSteps); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *simplify {
+		exp = lambda.Simplify(exp)
+	}
+	if *canonical {
+		exp = lambda.Canonicalize(exp)
+	}
+	// a transformed term may be huge, so it is written as it is formatted
+	out := bufio.NewWriter(os.Stdout)
+	if err := lambda.WriteFormat(out, exp); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintln(out)
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func format(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	from := flags.String("from", "lambda", "syntax of the input: lambda, haskell or python")
+	to := flags.String("to", "lambda", "syntax of the output: lambda, haskell or python")
+	flags.Parse(args)
+	exp, err := lambda.ParseSyntax(readProgram(flags.Args()), *from)
+	if err != nil {
+		log.Fatal(err)
+	}
+	text, err := lambda.FormatSyntax(exp, *to)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(text)
+}
+
+// diff compares two terms, or the definitions of two modules, exiting 1 when
+// they differ and 2 when they can't be read
+func diff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	alpha := flags.Bool("alpha", false, "treat terms differing only in bound variable names as equal")
+	files := parseInterspersed(flags, args)
+	if len(files) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: lambda diff [-alpha] old.lam new.lam")
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	texts := []string{}
+	for _, name := range files {
+		text, err := os.ReadFile(name)
+		if err != nil {
+			fail(err)
+		}
+		texts = append(texts, string(text))
+	}
+	color := colorStdout()
+	out := bufio.NewWriter(os.Stdout)
+	differ := false
+	var err error
+	if lambda.IsDefinitions(texts[0]) && lambda.IsDefinitions(texts[1]) {
+		var diffs []lambda.DefinitionDiff
+		if diffs, err = lambda.DiffDefinitions(texts[0], texts[1], *alpha); err != nil {
+			fail(err)
+		}
+		differ = len(diffs) > 0
+		err = lambda.WriteDefinitionDiff(out, diffs, color)
+	} else {
+		terms := []lambda.Expression{}
+		for i, text := range texts {
+			exp, err := lambda.Parse(text)
+			if err != nil {
+				fail(fmt.Errorf("%v: %v", files[i], err))
+			}
+			terms = append(terms, exp)
+		}
+		differences := lambda.Diff(terms[0], terms[1], *alpha)
+		differ = len(differences) > 0
+		err = lambda.WriteDiff(out, "", differences, color)
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		fail(err)
+	}
+	if differ {
+		os.Exit(1)
+	}
+}
+
+// colorStdout reports whether stdout is a terminal, which differences are
+// colored on
+func colorStdout() bool {
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// eq decides whether two terms are beta-eta equivalent, exiting 0 when they
+// are, 1 when they are distinct and 2 when it can't tell within the limit or
+// can't read them, for grading scripts and git hooks to call
+func eq(args []string) {
+	flags := flag.NewFlagSet("eq", flag.ExitOnError)
+	maxSteps := flags.Int("max-steps", 100000, "most normal order reductions of each term")
+	load := flags.String("load", "", "module whose definitions the terms may use")
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	files := parseInterspersed(flags, args)
+	if len(files) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: lambda eq [-max-steps n] [-load file.lam] [-path dirs] a.lam b.lam")
+		os.Exit(2)
+	}
+	fail := func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	var env lambda.Environment
+	if *load != "" {
+		var dirs []string
+		if *path != "" {
+			dirs = filepath.SplitList(*path)
+		}
+		p, err := lambda.LoadPrelude(*load, dirs)
+		if err != nil {
+			fail(err)
+		}
+		env = p.Environment()
+	}
+	terms := []lambda.Expression{}
+	for _, name := range files {
+		text, err := os.ReadFile(name)
+		if err != nil {
+			fail(err)
+		}
+		exp, err := lambda.Parse(string(text))
+		if err != nil {
+			fail(fmt.Errorf("%v: %v", name, err))
+		}
+		terms = append(terms, exp)
+	}
+	verdict, differences := lambda.ProveIn(terms[0], terms[1], env, *maxSteps)
+	fmt.Println(verdict)
+	switch verdict {
+	case lambda.Distinct:
+		if err := lambda.WriteDiff(os.Stdout, "", differences, colorStdout()); err != nil {
+			fail(err)
+		}
+		os.Exit(1)
+	case lambda.Unknown:
+		os.Exit(2)
+	}
+}
+
+// trace writes a row for each normal order step of a program, as text, CSV
+// or JSON, for analysis in spreadsheets or notebooks
+func trace(args []string) {
+	flags := flag.NewFlagSet("trace", flag.ExitOnError)
+	outFormat := flags.String("format", "text", "how to write the steps: text, csv or json, one object per line")
+	maxSteps := flags.Int("max-steps", 1000, "most beta and let reductions to take")
+	alpha := flags.Bool("alpha", false, "make renaming to avoid capture a step of its own")
+	terms := flags.Bool("terms", false, "add the term each step leads to, which text always shows")
+	load := flags.String("load", "", "module whose definitions the program may use")
+	path := flags.String("path", "", "directories to look for imported modules in, separated as in PATH (default $LAMBDA_PATH, then .)")
+	files := parseInterspersed(flags, args)
+	if len(files) > 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda trace [-format text|csv|json] [-max-steps n] [-alpha] [-terms] [-load file.lam] [-path dirs] [prog.lam]")
+		os.Exit(2)
+	}
+	var env lambda.Environment
+	if *load != "" {
+		var dirs []string
+		if *path != "" {
+			dirs = filepath.SplitList(*path)
+		}
+		p, err := lambda.LoadPrelude(*load, dirs)
+		if err != nil {
+			log.Fatal(err)
+		}
+		env = p.Environment()
+	}
+	exp, err := lambda.Parse(readProgram(files))
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	var write func(s lambda.TraceStep) error
+	switch *outFormat {
+	case "text":
+		write = func(s lambda.TraceStep) error {
+			_, err := fmt.Fprintf(out, "%v\t%v\t%v\t%v\t%v\n    %v\n", s.Step, s.Rule, s.Path, s.Size, s.Elapsed, lambda.Format(s.Term))
+			return err
+		}
+	case "csv":
+		w := csv.NewWriter(out)
+		header := []string{"step", "rule", "path", "size", "elapsed_ns"}
+		if *terms {
+			header = append(header, "term")
+		}
+		w.Write(header)
+		write = func(s lambda.TraceStep) error {
+			row := []string{strconv.Itoa(s.Step), s.Rule, s.Path, strconv.Itoa(s.Size), strconv.FormatInt(s.Elapsed.Nanoseconds(), 10)}
+			if *terms {
+				row = append(row, lambda.Format(s.Term))
+			}
+			w.Write(row)
+			w.Flush()
+			return w.Error()
+		}
+	case "json":
+		write = func(s lambda.TraceStep) error {
+			row := map[string]interface{}{"step": s.Step, "rule": s.Rule, "path": s.Path, "size": s.Size, "elapsedNs": s.Elapsed.Nanoseconds()}
+			if *terms {
+				row["term"] = lambda.Format(s.Term)
+			}
+			line, _ := json.Marshal(row)
+			_, err := fmt.Fprintf(out, "%s\n", line)
+			return err
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %v, expected one of text, csv, json\n", *outFormat)
+		os.Exit(2)
+	}
+	err = lambda.TraceSteps(exp, env, *maxSteps, *alpha, write)
+	if flushErr := out.Flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// grade reads one directory per student from the submissions directory, each
+// holding an answer per exercise in a file named after it, as in alice/two.lam
+func grade(args []string) {
+	flags := flag.NewFlagSet("grade", flag.ExitOnError)
+	specFile := flags.String("spec", "", "JSON file listing the exercises and their reference terms")
+	traces := flags.Bool("traces", false, "attach the trace and first divergence of every incorrect answer")
+	asJSON := flags.Bool("json", false, "print the reports as JSON, one per line")
+	flags.Parse(args)
+	if *specFile == "" || flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: lambda grade -spec spec.json submissions/")
+		os.Exit(2)
+	}
+	var spec lambda.GradeSpec
+	if err := json.Unmarshal([]byte(readProgram([]string{*specFile})), &spec); err != nil {
+		log.Fatalf("%v: %v", *specFile, err)
+	}
+	spec.Traces = spec.Traces || *traces
+	entries, err := os.ReadDir(flags.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		answers := map[string]string{}
+		for _, exercise := range spec.Exercises {
+			text, err := os.ReadFile(filepath.Join(flags.Arg(0), entry.Name(), exercise.Name+".lam"))
+			if err == nil {
+				answers[exercise.Name] = string(text)
+			}
+		}
+		report, err := lambda.Grade(spec, entry.Name(), answers)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *asJSON {
+			line, _ := json.Marshal(report)
+			fmt.Println(string(line))
+			continue
+		}
+		fmt.Printf("%v %v/%v\n", report.Student, report.Score, len(report.Results))
+		for _, res := range report.Results {
+			if res.Passed {
+				fmt.Printf("  %v: ok\n", res.Exercise)
+				continue
+			}
+			fmt.Printf("  %v: %v\n", res.Exercise, res.Message)
+			if res.Divergence != "" {
+				fmt.Printf("    diverges at %v\n", res.Divergence)
+			}
+			for i, term := range res.Trace {
+				fmt.Printf("    %v: %v\n", i, term)
+			}
+		}
+	}
+}
+
+// random writes generated terms, one per line or one per file, for fuzz
+// corpora and stress tests of other implementations
+func random(args []string) {
+	flags := flag.NewFlagSet("random", flag.ExitOnError)
+	count := flags.Int("count", 10, "how many terms to generate")
+	size := flags.Int("size", 10, "nodes in each term")
+	closed := flags.Bool("closed", false, "generate closed terms only")
+	free := flags.Int("free", 3, "how many free variables, a, b, c, ..., terms that aren't -closed may have")
+	seed := flags.Int64("seed", seed, "seed for the random terms, to generate the same ones again on any platform, the global -seed or the time if not given")
+	syntax := flags.String("syntax", "lambda", "syntax to write the terms in: lambda, haskell or python")
+	dir := flags.String("o", "", "directory to write each term to a file of its own in, as 0001.lam (default one per line on stdout)")
+	flags.Parse(args)
+	rng := rand.New(rand.NewSource(*seed))
+	if *dir != "" {
+		if err := os.MkdirAll(*dir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	out := bufio.NewWriter(os.Stdout)
+	digits := len(strconv.Itoa(*count))
+	for i := 1; i <= *count; i++ {
+		var term lambda.Expression
+		if *closed {
+			term = lambda.GenerateClosed(*size, rng)
+		} else {
+			term = lambda.GenerateOpen(*size, *free, rng)
+		}
+		if term == nil {
+			log.Fatalf("there are no terms of size %v", *size)
+		}
+		text, err := lambda.FormatSyntax(term, *syntax)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *dir == "" {
+			fmt.Fprintln(out, text)
+			continue
+		}
+		file := filepath.Join(*dir, fmt.Sprintf("%0*d.lam", digits, i))
+		if err := os.WriteFile(file, []byte(text+"\n"), 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func quiz(args []string) {
+	flags := flag.NewFlagSet("quiz", flag.ExitOnError)
+	count := flags.Int("count", 10, "how many terms to generate")
+	size := flags.Int("size", 5, "nodes in each term")
+	steps := flags.Int("steps", 3, "most normal order steps a term may take")
+	seed := flags.Int64("seed", seed, "seed for the random terms, to generate the same quiz again on any platform, the global -seed or the time if not given")
+	hideAnswers := flags.Bool("hide-answers", false, "leave out the answer key")
+	flags.Parse(args)
+	questions, err := lambda.Quiz(rand.New(rand.NewSource(*seed)), *count, *size, *steps)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("Reduce to normal form:")
+	for i, q := range questions {
+		fmt.Printf("%v. %v\n", i+1, lambda.Format(q.Term))
+	}
+	if *hideAnswers {
+		return
+	}
+	fmt.Println("\nAnswers:")
+	for i, q := range questions {
+		fmt.Printf("%v. %v (%v steps)\n", i+1, lambda.Format(q.Answer), q.Steps)
+	}
 }
@@ -0,0 +1,31 @@
+//go:build js && wasm
+
+// Command wasm exposes the interpreter to JavaScript as lambdaEval and
+// lambdaTrace, each taking a program and JSON options and returning JSON.
+//
+//	GOOS=js GOARCH=wasm go build -o lambda.wasm ./wasm
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"june/lambda/lambda"
+)
+
+func export(name string, run func(string, lambda.EvalOptions) lambda.EvalResult) {
+	js.Global().Set(name, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		var options lambda.EvalOptions
+		if len(args) > 1 {
+			json.Unmarshal([]byte(args[1].String()), &options)
+		}
+		res, _ := json.Marshal(run(args[0].String(), options))
+		return string(res)
+	}))
+}
+
+func main() {
+	export("lambdaEval", lambda.EvalProgram)
+	export("lambdaTrace", lambda.TraceProgram)
+	select {}
+}